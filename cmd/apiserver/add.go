@@ -0,0 +1,86 @@
+package apiserver
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewAddCmd 创建添加服务器命令
+func NewAddCmd(configManager *config.ConfigManager) *cobra.Command {
+	var (
+		name              string
+		url               string
+		acceptFingerprint string
+		tlsServerName     string
+		proxy             string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "添加 APIserver（不登录）",
+		Long: `添加 APIserver 但不登录。HTTPS 服务器会先确认并固定服务器证书。
+示例:
+  cli apiserver add --url https://tt1.test.com:8443
+  cli apiserver add --url https://tt1.test.com:8443 --name prod
+  cli apiserver add --url https://tt1.test.com:8443 --proxy http://proxy.example.com:3128
+
+代理以外的网络选项可以在添加后通过 cli config set 设置:
+  cli config set servers.prod.no_proxy .internal,10.0.0.0/8
+  cli config set servers.prod.hosts tt1.test.com=10.0.0.5
+  cli config set servers.prod.unix_socket /var/run/xce.sock
+  cli config set servers.prod.connect_timeout 5s`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := configManager.GetConfig()
+			if err != nil {
+				return fmt.Errorf("获取配置失败: %v", err)
+			}
+			serverURL, err := config.NormalizeURL(url)
+			if err != nil {
+				return err
+			}
+			if cfg.FindServer(serverURL) != nil {
+				return fmt.Errorf("APIserver %s 已存在", serverURL)
+			}
+
+			netOpts, err := serverTransport(cfg, serverURL, proxy)
+			if err != nil {
+				return err
+			}
+			certPath, fingerprint, err := trustServerCert(cmd, configManager, &config.APIServerInfo{URL: serverURL}, acceptFingerprint, tlsServerName, netOpts)
+			if err != nil {
+				return err
+			}
+
+			var server *config.APIServerInfo
+			err = configManager.Update(func(cfg *config.Config) error {
+				server, err = cfg.AddServer(config.APIServerInfo{
+					Name:          name,
+					URL:           serverURL,
+					CACert:        certPath,
+					Fingerprint:   fingerprint,
+					TLSServerName: tlsServerName,
+					Proxy:         proxy,
+				})
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已添加 APIserver %s (%s)\n", server.Name, server.URL)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&name, "name", "", "APIserver 名称，默认自动生成")
+	flags.StringVar(&url, "url", "", "APIserver 地址，如 https://tt1.test.com:8443")
+	flags.StringVar(&acceptFingerprint, "accept-fingerprint", "", "预先确认的服务器证书 SHA-256 指纹，跳过交互确认")
+	flags.StringVar(&tlsServerName, "tls-server-name", "", "TLS 握手及证书校验使用的服务器名称 (SNI)，默认为 URL 中的主机名")
+	flags.StringVar(&proxy, "proxy", "", "连接该服务器使用的代理，如 http://proxy:3128 或 socks5://proxy:1080，默认使用 HTTPS_PROXY 等环境变量")
+	cmd.MarkFlagRequired("url")
+
+	return cmd
+}
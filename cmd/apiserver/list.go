@@ -2,11 +2,12 @@ package apiserver
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
-	"github.com/xx/pkg/config"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/pkg/config"
 )
 
 // NewListCmd 创建 list 命令
@@ -16,34 +17,45 @@ func NewListCmd(configManager *config.ConfigManager) *cobra.Command {
 		Short: "列出所有 APIserver",
 		Long:  "显示所有已配置的 APIserver 和默认 APIserver",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runList(configManager)
+			return runList(cmd.OutOrStdout(), configManager)
 		},
 	}
 }
 
-func runList(cm *config.ConfigManager) error {
+func runList(out io.Writer, cm *config.ConfigManager) error {
 	cfg, err := cm.GetConfig()
 	if err != nil {
 		return fmt.Errorf("获取配置失败: %v", err)
 	}
 
 	// 使用 tabwriter 格式化输出
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "Default\tName\tVersion\tURL")
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Default\tName\tVersion\tAPI\tLogin\tURL")
 
 	for _, server := range cfg.APIServerInfo {
 		isDefault := " "
 		if server.URL == cfg.DefaultAPIServer {
 			isDefault = "*"
 		}
+		version := server.Version
+		if version == "" {
+			version = "-"
+		}
+		api := server.APIVersion
+		if api == "" {
+			api = client.DefaultAPIVersion
+		}
+		login := "yes"
 		if server.Token == "" {
-			continue
+			login = "no"
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
 			isDefault,
 			server.Name,
-			server.Version,
+			version,
+			api,
+			login,
 			server.URL)
 	}
 
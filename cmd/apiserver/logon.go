@@ -2,46 +2,34 @@ package apiserver
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/xx/internal/cert"
-	"github.com/xx/internal/client"
-	"github.com/xx/pkg/config"
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/transport"
+	"github.com/zhengyansheng/xcli/pkg/config"
 )
 
 // updateServerConfig 更新服务器配置
 func updateServerConfig(cfg *config.Config, url, username string, loginResp *client.LogonResponse) error {
-	// 检查是否已存在相同URL的服务器
-	found := false
-	for i, server := range cfg.APIServerInfo {
-		if server.URL == url {
-			// 更新现有服务器信息
-			cfg.APIServerInfo[i].Token = loginResp.Data.Token
-			cfg.APIServerInfo[i].Path = loginResp.Data.Path // 保存路径
-			found = true
-			break
-		}
-	}
-
 	// 如果是新服务器，添加到列表
-	if !found {
-		// 生成新服务器名称
-		serverName := fmt.Sprintf("apiserver%d", len(cfg.APIServerInfo)+1)
-
-		newServer := config.APIServerInfo{
-			Name:  serverName,
-			URL:   url,
-			Token: loginResp.Data.Token,
-			Path:  loginResp.Data.Path, // 保存路径
+	server := cfg.FindServer(url)
+	if server == nil {
+		var err error
+		if server, err = cfg.AddServer(config.APIServerInfo{URL: url}); err != nil {
+			return err
 		}
-
-		// 添加到数组末尾
-		cfg.APIServerInfo = append(cfg.APIServerInfo, newServer)
 	}
 
-	// 如果是第一个服务器，设置为默认服务器
-	if len(cfg.APIServerInfo) == 1 {
-		cfg.DefaultAPIServer = url
+	// 更新服务器信息
+	server.Token = loginResp.Data.Token
+	server.Path = loginResp.Data.Path // 保存路径
+
+	// 如果还没有默认服务器，设置为默认服务器
+	if cfg.DefaultAPIServer == "" {
+		cfg.DefaultAPIServer = server.URL
 	}
 
 	// 设置当前用户账号
@@ -50,18 +38,58 @@ func updateServerConfig(cfg *config.Config, url, username string, loginResp *cli
 	return nil
 }
 
-func NewLogonCmd(configManager *config.ConfigManager) *cobra.Command {
-	var opts struct {
-		username string
-		password string
-		url      string
+// logonOptions 定义登录命令参数
+type logonOptions struct {
+	username          string
+	password          string
+	url               string
+	acceptFingerprint string
+	clientCert        string
+	clientKey         string
+	pkcs12            string
+	tlsServerName     string
+	proxy             string
+	apiVersion        string
+}
+
+// tlsOptions 返回登录时使用的 TLS 选项: 以服务器已保存的 CA、客户端证书和 SNI 等配置为基础，
+// 只覆盖命令行中显式指定的参数
+func (o logonOptions) tlsOptions(cmd *cobra.Command, server *config.APIServerInfo) client.TLSOptions {
+	tlsOpts := client.ServerTLSOptions(server)
+	flags := cmd.Flags()
+	if flags.Changed("tls-server-name") {
+		tlsOpts.ServerName = o.tlsServerName
+	}
+	if flags.Changed("client-cert") || flags.Changed("pkcs12") {
+		tlsOpts.ClientCert = absPath(o.clientCert)
+		tlsOpts.ClientKey = absPath(o.clientKey)
+		tlsOpts.PKCS12 = absPath(o.pkcs12)
 	}
+	return tlsOpts
+}
+
+func NewLogonCmd(configManager *config.ConfigManager) *cobra.Command {
+	var opts logonOptions
 
 	cmd := &cobra.Command{
 		Use:   "logon",
 		Short: "登录到 APIserver",
+		Long: `登录到 APIserver。
+首次连接 HTTPS 服务器时会显示证书的 SHA-256 指纹并要求确认，确认后该指纹将被固定，
+之后服务器证书发生变化时连接会直接失败。
+--url 为服务器地址，其中的 /xce/v1/logon 等接口路径会被去掉，协议和主机名不区分大小写，默认端口可以省略。
+登录前会查询服务器版本，选择双方都支持的最高 API 版本，也可以通过 --api-version 指定。
+指定客户端证书且不提供密码时使用证书登录，加密私钥的密码通过环境变量 CLI_CLIENT_KEY_PASSPHRASE 提供。
+已添加的服务器沿用通过 cli config set 设置的 CA 证书、客户端证书和 SNI，命令行参数只覆盖其中显式指定的项；
+设置了 cacert、ca_bundle 或 system_ca 时由 CA 校验服务器证书，不再确认指纹。
+示例:
+  cli apiserver logon -n user1 -p pass --url https://tt1.test.com:8443
+  cli apiserver logon -n user1 --url https://tt1.test.com:8443 --client-cert user1.crt --client-key user1.key
+  cli apiserver logon -n user1 --url https://tt1.test.com:8443 --pkcs12 user1.p12
+  cli apiserver logon -n user1 -p pass --url https://tt1.test.com:8443 --accept-fingerprint AB:CD:...
+  cli apiserver logon -n user1 -p pass --url https://tt1.test.com:8443 --api-version v1`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runLogon(opts, configManager)
+			return runLogon(cmd, opts, configManager)
 		},
 	}
 
@@ -69,17 +97,25 @@ func NewLogonCmd(configManager *config.ConfigManager) *cobra.Command {
 	flags := cmd.Flags()
 	flags.StringVarP(&opts.username, "username", "n", "", "用户名")
 	flags.StringVarP(&opts.password, "password", "p", "", "密码")
-	flags.StringVar(&opts.url, "url", "", "APIserver 地址")
+	flags.StringVar(&opts.url, "url", "", "APIserver 地址，如 https://tt1.test.com:8443")
+	flags.StringVar(&opts.acceptFingerprint, "accept-fingerprint", "", "预先确认的服务器证书 SHA-256 指纹，跳过交互确认")
+	flags.StringVar(&opts.clientCert, "client-cert", "", "双向 TLS 客户端证书 (PEM)")
+	flags.StringVar(&opts.clientKey, "client-key", "", "双向 TLS 客户端私钥 (PEM，可加密)")
+	flags.StringVar(&opts.pkcs12, "pkcs12", "", "包含客户端证书和私钥的 PKCS#12 文件")
+	flags.StringVar(&opts.tlsServerName, "tls-server-name", "", "TLS 握手及证书校验使用的服务器名称 (SNI)，默认为 URL 中的主机名")
+	flags.StringVar(&opts.proxy, "proxy", "", "连接该服务器使用的代理，如 http://proxy:3128 或 socks5://proxy:1080，默认使用 HTTPS_PROXY 等环境变量")
+	flags.StringVar(&opts.apiVersion, "api-version", "", "使用的 API 版本 (v1/v2)，默认为服务器支持的最高版本")
 
 	// 必填参数
 	cmd.MarkFlagRequired("username")
-	cmd.MarkFlagRequired("password")
 	cmd.MarkFlagRequired("url")
+	cmd.MarkFlagsMutuallyExclusive("client-cert", "pkcs12")
+	cmd.MarkFlagsRequiredTogether("client-cert", "client-key")
 
 	return cmd
 }
 
-func runLogon(opts struct{ username, password, url string }, cm *config.ConfigManager) error {
+func runLogon(cmd *cobra.Command, opts logonOptions, cm *config.ConfigManager) error {
 	if cm == nil {
 		return fmt.Errorf("配置管理器未初始化")
 	}
@@ -90,38 +126,157 @@ func runLogon(opts struct{ username, password, url string }, cm *config.ConfigMa
 		return fmt.Errorf("获取配置失败: %v", err)
 	}
 
-	// 创建 API 客户端
-	apiClient := client.NewAPIClient(opts.url)
-	apiClient.SetRootCAs(cfg.CACert)
+	// 服务器地址去掉 /xce/v1/logon 等接口路径并规范化，作为服务器的标识
+	serverURL, err := config.NormalizeURL(opts.url)
+	if err != nil {
+		return err
+	}
+
+	// 已添加的服务器沿用通过 config set 设置的 TLS 配置
+	saved := config.APIServerInfo{URL: serverURL}
+	if existing := cfg.FindServer(serverURL); existing != nil {
+		saved = *existing
+	}
+	tlsOpts := opts.tlsOptions(cmd, &saved)
+
+	// 没有客户端证书时必须使用密码登录
+	if opts.password == "" && !tlsOpts.HasClientCert() {
+		return fmt.Errorf("请提供密码，或使用 --client-cert/--client-key、--pkcs12 进行证书登录")
+	}
 
-	certPath, err := cert.GetCertPath()
+	// 已添加的服务器沿用其代理等网络配置
+	netOpts, err := serverTransport(cfg, serverURL, opts.proxy)
 	if err != nil {
-		return fmt.Errorf("获取证书路径失败: %v", err)
+		return err
 	}
-	err = cert.GeneratorCert(opts.url, certPath)
+
+	// 未配置 CA 证书的 HTTPS 服务器需要先确认并保存服务器证书
+	certPath, fingerprint, err := trustServerCert(cmd, cm, &saved, opts.acceptFingerprint, tlsOpts.ServerName, netOpts)
 	if err != nil {
-		return fmt.Errorf("生成证书失败: %v", err)
+		return err
+	}
+	if certPath != "" {
+		tlsOpts.CACert = certPath
+		tlsOpts.Fingerprint = fingerprint
 	}
 
-	// 执行登录
-	loginResp, err := apiClient.Logon(opts.username, opts.password)
+	// 创建 API 客户端
+	apiClient := client.NewAPIClient(serverURL, client.CommandOptions(cmd)...)
+	apiClient.SetTransportOptions(netOpts)
+	if err := apiClient.SetTLSOptions(tlsOpts); err != nil {
+		return fmt.Errorf("设置 TLS 配置失败: %v", err)
+	}
+
+	// 协商 API 版本，服务器不支持证书登录等功能时在登录前报错
+	version, err := apiClient.Negotiate(cmd.Context(), opts.apiVersion)
 	if err != nil {
-		return fmt.Errorf("登录失败: %v", err)
+		return fmt.Errorf("协商 API 版本失败: %w", err)
 	}
 
-	// 更新服务器信息
-	if err := updateServerConfig(cfg, opts.url, opts.username, loginResp); err != nil {
-		return fmt.Errorf("更新服务器配置失败: %v", err)
+	// 执行登录，提供了客户端证书且没有密码时使用证书登录
+	var loginResp *client.LogonResponse
+	if opts.password == "" {
+		loginResp, err = apiClient.LogonWithCert(cmd.Context(), opts.username)
+	} else {
+		loginResp, err = apiClient.Logon(cmd.Context(), opts.username, opts.password)
+	}
+	if err != nil {
+		return fmt.Errorf("登录失败: %w", err)
 	}
 
-	cfg.CACert = certPath
-	fmt.Println("证书路径: ", certPath)
+	// 登录期间配置文件可能已被其他命令修改，在锁内重新加载后再更新服务器信息
+	err = cm.Update(func(cfg *config.Config) error {
+		if err := updateServerConfig(cfg, serverURL, opts.username, loginResp); err != nil {
+			return fmt.Errorf("更新服务器配置失败: %v", err)
+		}
 
-	// 保存配置
-	if err := cm.SaveConfig(cfg); err != nil {
-		return fmt.Errorf("保存配置失败: %v", err)
+		server := cfg.FindServer(serverURL)
+		server.TLSServerName = tlsOpts.ServerName
+		server.Version = version.Version
+		server.APIVersion = apiClient.APIVersion()
+		server.Capabilities = nil
+		if version.Capabilities != nil {
+			capabilities := strings.Join(version.Capabilities, ",")
+			server.Capabilities = &capabilities
+		}
+		if opts.proxy != "" {
+			server.Proxy = opts.proxy
+		}
+		server.ClientCert = tlsOpts.ClientCert
+		server.ClientKey = tlsOpts.ClientKey
+		server.PKCS12 = tlsOpts.PKCS12
+		if certPath != "" {
+			server.CACert = certPath
+			server.Fingerprint = fingerprint
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("登录成功")
+	out := cmd.OutOrStdout()
+	if certPath != "" {
+		fmt.Fprintln(out, "证书路径: ", certPath)
+		fmt.Fprintln(out, "证书指纹: ", fingerprint)
+	}
+	fmt.Fprintln(out, "登录成功")
 	return nil
 }
+
+// absPath 返回文件的绝对路径，以便在其他目录下执行命令时仍能找到该文件
+func absPath(file string) string {
+	if file == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return file
+}
+
+// serverTransport 返回连接 url 使用的网络选项，已添加的服务器沿用其配置，proxy 非空时覆盖其中的代理
+func serverTransport(cfg *config.Config, url, proxy string) (transport.Options, error) {
+	server := config.APIServerInfo{URL: url}
+	if existing := cfg.FindServer(url); existing != nil {
+		server = *existing
+	}
+	if proxy != "" {
+		server.Proxy = proxy
+	}
+	netOpts, err := transport.ServerOptions(&server)
+	if err != nil {
+		return netOpts, fmt.Errorf("APIserver %s 的网络配置无效: %v", url, err)
+	}
+	return netOpts, nil
+}
+
+// trustServerCert 获取并确认 HTTPS 服务器的证书，返回证书保存路径和指纹。
+// HTTP 服务器以及已通过 cacert、ca_bundle 或 system_ca 配置了 CA 的服务器返回空值，由 CA 校验服务器证书
+func trustServerCert(cmd *cobra.Command, cm *config.ConfigManager, server *config.APIServerInfo, acceptFingerprint, tlsServerName string, netOpts transport.Options) (string, string, error) {
+	if !strings.HasPrefix(server.URL, "https://") {
+		return "", "", nil
+	}
+
+	certPath, err := cert.GetCertPath(cm.CertDir(), server.URL)
+	if err != nil {
+		return "", "", fmt.Errorf("获取证书路径失败: %v", err)
+	}
+	if (server.CACert != "" && server.CACert != certPath) || server.CABundle != "" || server.SystemCA {
+		return "", "", nil
+	}
+
+	// 已登录过的服务器沿用固定的证书指纹
+	fingerprint, err := cert.GeneratorCert(cmd.Context(), server.URL, certPath, cert.TrustOptions{
+		Pinned:            server.Fingerprint,
+		AcceptFingerprint: acceptFingerprint,
+		In:                cmd.InOrStdin(),
+		Out:               cmd.OutOrStdout(),
+		ServerName:        tlsServerName,
+		Transport:         netOpts,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("获取服务器证书失败: %v", err)
+	}
+	return certPath, fingerprint, nil
+}
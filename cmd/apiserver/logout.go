@@ -2,9 +2,10 @@ package apiserver
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
-	"github.com/xx/pkg/config"
+	"github.com/zhengyansheng/xcli/pkg/config"
 )
 
 // NewLogoutCmd 创建登出命令
@@ -19,7 +20,7 @@ func NewLogoutCmd(configManager *config.ConfigManager) *cobra.Command {
   cli apiserver logout --url http://tt1.test.com:8080
   cli apiserver logout --url https://tt1.test.com:8443`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runLogout(url, configManager)
+			return runLogout(cmd.OutOrStdout(), url, configManager)
 		},
 	}
 
@@ -30,34 +31,23 @@ func NewLogoutCmd(configManager *config.ConfigManager) *cobra.Command {
 	return cmd
 }
 
-func runLogout(url string, cm *config.ConfigManager) error {
-	// 获取配置
-	cfg, err := cm.GetConfig()
-	if err != nil {
-		return fmt.Errorf("获取配置失败: %v", err)
-	}
-
-	// 查找指定 URL 的服务器
-	found := false
-	for i, server := range cfg.APIServerInfo {
-		// 比较完整URL或基础URL
-		if server.URL == url {
-			// 清除 token
-			cfg.APIServerInfo[i].Token = ""
-			found = true
-			break
+func runLogout(out io.Writer, url string, cm *config.ConfigManager) error {
+	var serverURL string
+	err := cm.Update(func(cfg *config.Config) error {
+		// 查找指定 URL 的服务器，URL 中的接口路径、默认端口和大小写不影响匹配
+		server := cfg.FindServer(url)
+		if server == nil {
+			return fmt.Errorf("未找到指定的服务器: %s", url)
 		}
+		// 清除 token
+		server.Token = ""
+		serverURL = server.URL
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if !found {
-		return fmt.Errorf("未找到指定的服务器: %s", url)
-	}
-
-	// 保存配置
-	if err := cm.SaveConfig(cfg); err != nil {
-		return fmt.Errorf("保存配置失败: %v", err)
-	}
-
-	fmt.Printf("已清除服务器 %s 的登录信息\n", url)
+	fmt.Fprintf(out, "已清除服务器 %s 的登录信息\n", serverURL)
 	return nil
 }
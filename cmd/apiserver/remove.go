@@ -0,0 +1,57 @@
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewRemoveCmd 创建删除服务器命令
+func NewRemoveCmd(configManager *config.ConfigManager) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:     "remove <name|url>",
+		Aliases: []string{"rm"},
+		Short:   "删除 APIserver",
+		Long: `删除 APIserver 及其登录信息。删除默认 APIserver 时，第一个剩余的 APIserver 将成为默认服务器。
+APIserver 仍被上下文引用时拒绝删除，使用 --force 时一并删除这些上下文。
+示例:
+  cli apiserver remove apiserver2
+  cli apiserver remove https://tt1.test.com:8443
+  cli apiserver remove apiserver2 --force`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var server *config.APIServerInfo
+			var contexts []string
+			var defaultServer string
+			err := configManager.Update(func(cfg *config.Config) error {
+				var err error
+				server, contexts, err = cfg.RemoveServer(args[0], force)
+				if errors.Is(err, config.ErrServerInUse) {
+					return fmt.Errorf("%v，请先修改或删除这些上下文，或使用 --force 一并删除", err)
+				}
+				defaultServer = cfg.DefaultAPIServer
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已删除 APIserver %s (%s)\n", server.Name, server.URL)
+			if len(contexts) > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "已删除引用该 APIserver 的上下文: %s\n", strings.Join(contexts, ", "))
+			}
+			if defaultServer != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "当前默认 APIserver: %s\n", defaultServer)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "同时删除引用该 APIserver 的上下文")
+	return cmd
+}
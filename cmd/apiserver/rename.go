@@ -0,0 +1,31 @@
+package apiserver
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewRenameCmd 创建重命名服务器命令
+func NewRenameCmd(configManager *config.ConfigManager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename <old> <new>",
+		Short: "重命名 APIserver",
+		Long: `重命名 APIserver。
+示例:
+  cli apiserver rename apiserver1 prod`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := configManager.Update(func(cfg *config.Config) error {
+				return cfg.RenameServer(args[0], args[1])
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已将 APIserver %s 重命名为 %s\n", args[0], args[1])
+			return nil
+		},
+	}
+}
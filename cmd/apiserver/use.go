@@ -0,0 +1,34 @@
+package apiserver
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewUseCmd 创建切换默认服务器命令
+func NewUseCmd(configManager *config.ConfigManager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use <name>",
+		Short: "按名称切换默认 APIserver",
+		Long: `按名称或 URL 切换默认 APIserver。
+示例:
+  cli apiserver use prod`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var server *config.APIServerInfo
+			err := configManager.Update(func(cfg *config.Config) error {
+				var err error
+				server, err = cfg.UseServer(args[0])
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "默认 APIserver 已切换为 %s (%s)\n", server.Name, server.URL)
+			return nil
+		},
+	}
+}
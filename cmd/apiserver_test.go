@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+)
+
+// tableRow 返回表格输出中包含 key 的一行按空白分隔后的各列
+func tableRow(t *testing.T, output, key string) []string {
+	t.Helper()
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		for _, field := range fields {
+			if field == key {
+				return fields
+			}
+		}
+	}
+	t.Fatalf("no row for %s in:\n%s", key, output)
+	return nil
+}
+
+func TestAPIServerCommands(t *testing.T) {
+	s1 := newTestServer(t)
+	s2 := newTestServer(t)
+	c := newTestCLI(t)
+
+	// 拒绝信任证书时不保存任何配置
+	stdout, _, err := c.runWithInput("n\n", "apiserver", "logon", "-n", "alice", "-p", "secret", "--url", s1.URL())
+	if err == nil || !strings.Contains(err.Error(), cert.ErrNotTrusted.Error()) {
+		t.Fatalf("logon rejecting the certificate: err = %v, want %v", err, cert.ErrNotTrusted)
+	}
+	assertContains(t, "logon", stdout, "无法确认其证书是否可信", fingerprint(t, s1))
+	if servers := c.loadConfig().APIServerInfo; len(servers) != 0 {
+		t.Errorf("servers after a rejected logon = %v, want none", servers)
+	}
+
+	// 交互确认证书后登录
+	stdout, _, err = c.runWithInput("y\n", "apiserver", "logon", "-n", "alice", "-p", "secret", "--url", s1.URL())
+	if err != nil {
+		t.Fatalf("logon: %v", err)
+	}
+	assertContains(t, "logon", stdout, "证书指纹:  "+fingerprint(t, s1), "登录成功")
+
+	// 密码错误时退出码为 3
+	_, _, err = c.run("apiserver", "logon", "-n", "alice", "-p", "wrong", "--url", s1.URL())
+	if code := ExitCode(err); code != ExitUnauthorized {
+		t.Errorf("logon with a wrong password: exit code %d (%v), want %d", code, err, ExitUnauthorized)
+	}
+
+	// 添加但不登录，指纹不一致时拒绝添加
+	wrong := strings.TrimSuffix(strings.Repeat("00:", 32), ":")
+	if _, _, err := c.run("apiserver", "add", "--url", s2.URL(), "--accept-fingerprint", wrong); err == nil || !strings.Contains(err.Error(), cert.ErrFingerprintMismatch.Error()) {
+		t.Errorf("add with a wrong fingerprint: err = %v, want %v", err, cert.ErrFingerprintMismatch)
+	}
+	stdout = c.mustRun("apiserver", "add", "--url", s2.URL(), "--name", "lab", "--accept-fingerprint", fingerprint(t, s2))
+	assertContains(t, "add", stdout, fmt.Sprintf("已添加 APIserver lab (%s)", s2.URL()))
+	if _, _, err := c.run("apiserver", "add", "--url", s2.URL()+"/xce/v1/logon"); err == nil {
+		t.Error("adding the same server again: want error")
+	}
+
+	stdout = c.mustRun("apiserver", "list")
+	if got, want := tableRow(t, stdout, "apiserver1"), []string{"*", "apiserver1", "2.0.0-xcetest", "v2", "yes", s1.URL()}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("list row = %v, want %v", got, want)
+	}
+	if got, want := tableRow(t, stdout, "lab"), []string{"lab", "-", "v1", "no", s2.URL()}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("list row = %v, want %v", got, want)
+	}
+
+	stdout = c.mustRun("apiserver", "use", "lab")
+	assertContains(t, "use", stdout, fmt.Sprintf("默认 APIserver 已切换为 lab (%s)", s2.URL()))
+	stdout = c.mustRun("apiserver", "rename", "lab", "dev")
+	assertContains(t, "rename", stdout, "已将 APIserver lab 重命名为 dev")
+	if got := tableRow(t, c.mustRun("apiserver", "list"), "dev"); got[0] != "*" {
+		t.Errorf("list row = %v, want dev to be the default", got)
+	}
+
+	stdout = c.mustRun("apiserver", "logout", "--url", s1.URL())
+	assertContains(t, "logout", stdout, fmt.Sprintf("已清除服务器 %s 的登录信息", s1.URL()))
+	if got := tableRow(t, c.mustRun("apiserver", "list"), "apiserver1"); got[len(got)-2] != "no" {
+		t.Errorf("list row after logout = %v, want Login no", got)
+	}
+
+	stdout = c.mustRun("apiserver", "remove", "dev")
+	assertContains(t, "remove", stdout,
+		fmt.Sprintf("已删除 APIserver dev (%s)", s2.URL()),
+		fmt.Sprintf("当前默认 APIserver: %s", s1.URL()))
+	if _, _, err := c.run("apiserver", "remove", "dev"); err == nil {
+		t.Error("removing a missing server: want error")
+	}
+}
+
+func TestRemoveServerWithContexts(t *testing.T) {
+	s1 := newTestServer(t)
+	s2 := newTestServer(t)
+	c := newTestCLI(t)
+	c.logon(s1)
+	c.logon(s2)
+	c.mustRun("config", "set-context", "prod", "--server", "apiserver2")
+	c.mustRun("config", "set-context", "lab", "--server", s2.URL())
+	c.mustRun("config", "set-context", "dev", "--server", "apiserver1")
+	c.mustRun("config", "use-context", "prod")
+
+	// 仍被上下文引用时拒绝删除，并列出这些上下文
+	_, _, err := c.run("apiserver", "remove", "apiserver2")
+	if err == nil || !strings.Contains(err.Error(), "上下文 prod, lab") || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("remove a server used by contexts: err = %v, want the contexts prod and lab", err)
+	}
+	if c.loadConfig().LookupServer("apiserver2") == nil {
+		t.Fatal("server removed although contexts still use it")
+	}
+
+	stdout := c.mustRun("apiserver", "remove", "apiserver2", "--force")
+	assertContains(t, "remove --force", stdout,
+		fmt.Sprintf("已删除 APIserver apiserver2 (%s)", s2.URL()),
+		"已删除引用该 APIserver 的上下文: prod, lab")
+	cfg := c.loadConfig()
+	if len(cfg.Contexts) != 1 || cfg.Contexts[0].Name != "dev" {
+		t.Errorf("contexts = %+v, want only dev", cfg.Contexts)
+	}
+	if cfg.CurrentContext != "" {
+		t.Errorf("current context = %q, want it cleared with the removed context", cfg.CurrentContext)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate after remove --force: %v", err)
+	}
+}
+
+func TestLogonSavesCapabilities(t *testing.T) {
+	none := newTestServer(t, xcetest.WithVersion(client.VersionInfo{APIVersions: []string{client.APIVersionV2}, Capabilities: []string{}}))
+	legacy := newTestServer(t, xcetest.WithLegacyAPI())
+	c := newTestCLI(t)
+	c.logon(none)
+	c.logon(legacy)
+
+	// 服务器声明不支持任何可选能力时保存空列表，旧服务器的能力未知时不保存
+	cfg := c.loadConfig()
+	if got := cfg.FindServer(none.URL()).Capabilities; got == nil || *got != "" {
+		t.Errorf("capabilities of a server without optional capabilities = %v, want an empty list", got)
+	}
+	if got := cfg.FindServer(legacy.URL()).Capabilities; got != nil {
+		t.Errorf("capabilities of a legacy server = %q, want unknown", *got)
+	}
+	data, err := os.ReadFile(c.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), `"capabilities": ""`); got != 1 {
+		t.Errorf("config file has %d empty capability lists, want 1:\n%s", got, data)
+	}
+}
@@ -1,14 +1,15 @@
 package bhosts
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
-	"github.com/xx/internal/client"
-	"github.com/xx/pkg/config"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/pkg/config"
 )
 
 // NewBHostsCmd 创建主机查询命令
@@ -17,6 +18,7 @@ func NewBHostsCmd(configManager *config.ConfigManager) *cobra.Command {
 		infoType string
 		hostType string
 		fullInfo bool
+		list     client.ListOptions
 	)
 
 	cmd := &cobra.Command{
@@ -32,7 +34,7 @@ func NewBHostsCmd(configManager *config.ConfigManager) *cobra.Command {
 			if fullInfo {
 				infoType = "full"
 			}
-			return runBHosts(configManager, infoType, hostType)
+			return runBHosts(cmd.Context(), cmd.OutOrStdout(), configManager, infoType, hostType, list, client.CommandOptions(cmd))
 		},
 	}
 
@@ -41,34 +43,18 @@ func NewBHostsCmd(configManager *config.ConfigManager) *cobra.Command {
 	flags.StringVar(&infoType, "type", "basic", "信息类型 (basic/full)")
 	flags.StringVar(&hostType, "host-type", "", "主机类型过滤 (X86_64/ARM)")
 	flags.BoolVar(&fullInfo, "full", false, "显示详细信息")
+	flags.IntVar(&list.Limit, "limit", 0, "最多显示的主机数，0 表示不限制")
+	flags.IntVar(&list.PageSize, "page-size", client.DefaultPageSize, "每次向服务器请求的主机数")
 	cmd.MarkFlagRequired("host-type")
 
 	return cmd
 }
 
-func runBHosts(cm *config.ConfigManager, infoType, hostType string) error {
-	// 获取配置
-	cfg, err := cm.GetConfig()
+func runBHosts(ctx context.Context, out io.Writer, cm *config.ConfigManager, infoType, hostType string, list client.ListOptions, clientOpts []client.Option) error {
+	// 获取当前服务器信息，使用上下文时为上下文中的服务器
+	serverInfo, err := cm.CurrentServer()
 	if err != nil {
-		return fmt.Errorf("获取配置失败: %v", err)
-	}
-
-	// 检查是否有默认服务器
-	if cfg.DefaultAPIServer == "" {
-		return fmt.Errorf("未设置默认 APIserver，请先设置默认服务器或指定服务器")
-	}
-
-	// 获取服务器信息
-	var serverInfo *config.APIServerInfo
-	for _, server := range cfg.APIServerInfo {
-		if server.URL == cfg.DefaultAPIServer {
-			serverInfo = &server
-			break
-		}
-	}
-
-	if serverInfo == nil {
-		return fmt.Errorf("未找到默认服务器信息")
+		return err
 	}
 
 	if serverInfo.Token == "" {
@@ -82,42 +68,42 @@ func runBHosts(cm *config.ConfigManager, infoType, hostType string) error {
 	}
 
 	// 创建查询参数
-	queryParams := make(map[string]string)
-	queryParams["type"] = infoType
-
-	if hostType != "" {
-		hostType = strings.ToUpper(hostType)
-		if hostType != "X86_64" && hostType != "ARM" {
-			return fmt.Errorf("无效的主机类型: %s，必须是 X86_64 或 ARM", hostType)
-		}
-		queryParams["filter"] = fmt.Sprintf("hostType:eq:%s", hostType)
+	hostType = strings.ToUpper(hostType)
+	if hostType != "" && hostType != "X86_64" && hostType != "ARM" {
+		return fmt.Errorf("无效的主机类型: %s，必须是 X86_64 或 ARM", hostType)
 	}
+	query := client.HostQuery{Type: infoType, HostType: hostType}
 
 	// 创建 API 客户端并查询主机信息
-	apiClient := client.NewAPIClient(cfg.DefaultAPIServer)
-	apiClient.SetRootCAs(cfg.CACert)
-	hosts, err := apiClient.GetHosts(serverInfo.Token, queryParams)
+	apiClient, err := client.NewServerClient(serverInfo, clientOpts...)
 	if err != nil {
-		return fmt.Errorf("查询主机信息失败: %v", err)
+		return err
+	}
+	var hosts []client.Host
+	for host, err := range apiClient.Hosts(ctx, serverInfo.Token, query.Params(), list) {
+		if err != nil {
+			return fmt.Errorf("查询主机信息失败: %w", err)
+		}
+		hosts = append(hosts, host)
 	}
 
 	// 显示结果
-	printHosts(hosts)
+	printHosts(out, hosts)
 	return nil
 }
 
-func printHosts(hosts *client.HostsResponse) {
-	if hosts.Count == 0 {
-		fmt.Println("没有找到主机")
+func printHosts(out io.Writer, hosts []client.Host) {
+	if len(hosts) == 0 {
+		fmt.Fprintln(out, "没有找到主机")
 		return
 	}
 
 	// 打印表头
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
 	fmt.Fprintln(w, "HOST_NAME\tTYPE\tMODEL\tCPU_FACTOR\tMAX_CPUS\tMAX_MEM(MB)\tMAX_SWAP(MB)\tMAX_TMP(MB)\tN_DISKS\tN_RES\tRESOURCES\tN_DRES\tD_RESOURCES\tWINDOWS\tNUM_INDX\tBUSY_THRESHOLD\tIS_SERVER\tCORES\tHOST_ADDR\tPPROCS\tCORES_PER_PROC\tTHREADS_PER_CORE")
 
 	// 打印主机信息
-	for _, host := range hosts.Data {
+	for _, host := range hosts {
 		fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%d\t%s\t%s\t%d\t%s\t%t\t%d\t%s\t%d\t%d\n",
 			host.HostName,
 			host.HostType,
@@ -1,23 +1,23 @@
 package bjobs
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"iter"
+	"strconv"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/spf13/cobra"
-	"github.com/xx/internal/client"
-	"github.com/xx/pkg/config"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/table"
+	"github.com/zhengyansheng/xcli/pkg/config"
 )
 
 // NewBJobsCmd 创建作业查询命令
 func NewBJobsCmd(configManager *config.ConfigManager) *cobra.Command {
-	var (
-		user   string
-		queue  string
-		fields string
-	)
+	var opts bjobsOptions
 
 	cmd := &cobra.Command{
 		Use:   "bjobs",
@@ -27,47 +27,74 @@ func NewBJobsCmd(configManager *config.ConfigManager) *cobra.Command {
   cli bjobs                                # 查询所有作业
   cli bjobs -u user1                      # 查询指定用户的作业
   cli bjobs -q queue1 -u user1            # 查询指定用户在指定队列的作业
-  cli bjobs jobid,status,queue,command    # 查询指定字段`,
+  cli bjobs jobid,status,queue,command    # 查询指定字段
+  cli bjobs -o json                       # 以 json 格式输出
+  cli bjobs --limit 100                   # 最多显示 100 个作业
+作业按页从服务器获取并边获取边输出，每页的条数由 --page-size 指定。
+使用上下文时，未指定 -u 且未设置 defaultqueryall 的情况下只查询上下文账号的作业。`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// 如果有位置参数，作为字段列表
 			if len(args) > 0 {
-				fields = args[0]
+				opts.fields = args[0]
 			}
-			return runBJobs(configManager, user, queue, fields)
+			return runBJobs(cmd.Context(), cmd.OutOrStdout(), configManager, opts, client.CommandOptions(cmd))
 		},
 	}
 
 	// 添加命令行参数
 	flags := cmd.Flags()
-	flags.StringVarP(&user, "user", "u", "", "按用户过滤")
-	flags.StringVarP(&queue, "queue", "q", "", "按队列过滤")
+	flags.StringVarP(&opts.user, "user", "u", "", "按用户过滤")
+	flags.StringVarP(&opts.queue, "queue", "q", "", "按队列过滤")
+	flags.StringVarP(&opts.output, "output", "o", "", "输出格式 (table/json)")
+	flags.IntVar(&opts.limit, "limit", 0, "最多显示的作业数，0 表示不限制")
+	flags.IntVar(&opts.pageSize, "page-size", client.DefaultPageSize, "每次向服务器请求的作业数")
 
 	return cmd
 }
 
-func runBJobs(cm *config.ConfigManager, user, queue, fields string) error {
-	// 获取配置
+// bjobsOptions 定义作业查询命令参数
+type bjobsOptions struct {
+	user     string
+	queue    string
+	fields   string
+	output   string
+	limit    int
+	pageSize int
+}
+
+func runBJobs(ctx context.Context, out io.Writer, cm *config.ConfigManager, opts bjobsOptions, clientOpts []client.Option) error {
 	cfg, err := cm.GetConfig()
 	if err != nil {
 		return fmt.Errorf("获取配置失败: %v", err)
 	}
 
-	// 检查是否有默认服务器
-	if cfg.DefaultAPIServer == "" {
-		return fmt.Errorf("未设置默认 APIserver，请先设置默认服务器或指定服务器")
+	// 获取当前服务器信息，使用上下文时为上下文中的服务器
+	serverInfo, err := cm.CurrentServer()
+	if err != nil {
+		return err
 	}
 
-	// 获取服务器信息
-	var serverInfo *config.APIServerInfo
-	for _, server := range cfg.APIServerInfo {
-		if server.URL == cfg.DefaultAPIServer {
-			serverInfo = &server
-			break
+	// 未指定的参数使用上下文中的默认值
+	cliContext, err := cm.CurrentContext()
+	if err != nil {
+		return err
+	}
+	if cliContext != nil {
+		if opts.user == "" && !cfg.QueryAll() {
+			opts.user = cliContext.Account
+		}
+		if opts.output == "" {
+			opts.output = cliContext.Output
 		}
 	}
-
-	if serverInfo == nil {
-		return fmt.Errorf("未找到默认服务器信息")
+	if opts.output != "" && opts.output != "table" && opts.output != "json" {
+		return fmt.Errorf("无效的输出格式: %s，必须是 table 或 json", opts.output)
+	}
+	if opts.limit < 0 {
+		return fmt.Errorf("--limit 不能为负数")
+	}
+	if opts.pageSize <= 0 {
+		return fmt.Errorf("--page-size 必须大于 0")
 	}
 
 	if serverInfo.Token == "" {
@@ -75,56 +102,87 @@ func runBJobs(cm *config.ConfigManager, user, queue, fields string) error {
 	}
 
 	// 构建查询参数
-	params := make(map[string]string)
-
-	// 处理过滤条件
-	var filters []string
-	if user != "" {
-		filters = append(filters, fmt.Sprintf("user:eq:%s", user))
-	}
-	if queue != "" {
-		filters = append(filters, fmt.Sprintf("queue:eq:%s", queue))
+	query := client.JobQuery{
+		User:  opts.user,
+		Queue: opts.queue,
 	}
-	if len(filters) > 0 {
-		params["filter"] = fmt.Sprintf("[%s]", strings.Join(filters, ","))
-	}
-
-	// 处理字段选择
-	if fields != "" {
-		params["fields"] = fields
+	if opts.fields != "" {
+		query.Fields = strings.Split(opts.fields, ",")
 	}
 
 	// 创建 API 客户端并查询作业信息
-	apiClient := client.NewAPIClient(cfg.DefaultAPIServer)
-	apiClient.SetRootCAs(cfg.CACert)
-	jobs, err := apiClient.GetJobs(serverInfo.Token, params)
+	apiClient, err := client.NewServerClient(serverInfo, clientOpts...)
 	if err != nil {
-		return fmt.Errorf("查询作业失败: %v", err)
+		return err
 	}
+	jobs := apiClient.Jobs(ctx, serverInfo.Token, query.Params(), client.ListOptions{
+		PageSize: opts.pageSize,
+		Limit:    opts.limit,
+	})
 
 	// 显示结果
-	printJobs(jobs)
-	return nil
+	if opts.output == "json" {
+		return printJobsJSON(out, jobs)
+	}
+	return printJobs(out, jobs, opts.pageSize)
 }
 
-func printJobs(jobs *client.JobsResponse) {
-	if jobs.Count == 0 {
-		fmt.Println("没有找到作业")
-		return
+// printJobsJSON 以 json 数组格式逐个输出作业信息
+func printJobsJSON(out io.Writer, jobs iter.Seq2[client.Job, error]) error {
+	count := 0
+	for job, err := range jobs {
+		if err != nil {
+			// 已输出部分作业时先结束数组，保证输出仍是完整的 json
+			if count > 0 {
+				fmt.Fprintln(out, "\n]")
+			}
+			return fmt.Errorf("查询作业失败: %w", err)
+		}
+
+		data, err := json.MarshalIndent(job, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			fmt.Fprint(out, "[\n  ")
+		} else {
+			fmt.Fprint(out, ",\n  ")
+		}
+		out.Write(data)
+		count++
 	}
 
-	// 打印表头
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "JOBID\tUSER\tSTATUS\tQUEUE\tCOMMAND")
+	if count == 0 {
+		fmt.Fprintln(out, "[]")
+	} else {
+		fmt.Fprintln(out, "\n]")
+	}
+	return nil
+}
 
-	// 打印作业信息
-	for _, job := range jobs.Data {
-		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
-			job.JobID,
+// printJobs 以表格格式输出作业信息，每收到一页即输出，列宽由第一页确定
+func printJobs(out io.Writer, jobs iter.Seq2[client.Job, error], pageSize int) error {
+	w := table.NewStreamWriter(out, pageSize, "JOBID", "USER", "STATUS", "QUEUE", "COMMAND")
+	for job, err := range jobs {
+		if err != nil {
+			if w.Rows() > 0 {
+				w.Flush()
+			}
+			return fmt.Errorf("查询作业失败: %w", err)
+		}
+		if err := w.Append(
+			strconv.FormatInt(job.JobID, 10),
 			job.User,
 			job.Status,
 			job.Queue,
-			job.Command)
+			job.Command); err != nil {
+			return err
+		}
+	}
+
+	if w.Rows() == 0 {
+		fmt.Fprintln(out, "没有找到作业")
+		return nil
 	}
-	w.Flush()
+	return w.Flush()
 }
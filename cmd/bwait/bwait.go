@@ -0,0 +1,99 @@
+package bwait
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/wait"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewBWaitCmd 创建等待作业命令
+func NewBWaitCmd(configManager *config.ConfigManager) *cobra.Command {
+	var opts bwaitOptions
+
+	cmd := &cobra.Command{
+		Use:   "bwait",
+		Short: "等待作业满足指定条件",
+		Long: `等待作业满足指定条件，条件满足时退出码为 0。
+示例:
+  cli bwait -w "done(123)"                          # 等待作业 123 成功结束
+  cli bwait -w "done(123) && done(124)" -t 2h       # 最多等待 2 小时
+  cli bwait -w "ended(123) || exit(124)"            # 作业 123 结束或作业 124 失败
+条件由 done(作业ID)、exit(作业ID)、ended(作业ID) 通过 &&、|| 和 ! 组合而成，可以使用括号，&& 的优先级高于 ||:
+  done   作业状态为 DONE
+  exit   作业状态为 EXIT
+  ended  作业状态为 DONE 或 EXIT
+作业状态的变化输出到标准错误。条件已不可能满足 (如等待 done 的作业以 EXIT 结束) 时退出码为 8，超时时退出码为 124。
+作业状态没有变化时轮询间隔从 --interval 逐渐增加到 --max-interval。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBWait(cmd.Context(), cmd.ErrOrStderr(), configManager, opts, client.CommandOptions(cmd))
+		},
+	}
+
+	// 添加命令行参数
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.condition, "wait", "w", "", "等待条件，如 \"done(123) && done(124)\"")
+	flags.DurationVarP(&opts.timeout, "timeout", "t", 0, "最长等待时间，如 30m、2h，0 表示不限制")
+	flags.DurationVar(&opts.interval, "interval", wait.DefaultInterval, "轮询作业状态的初始间隔")
+	flags.DurationVar(&opts.maxInterval, "max-interval", wait.DefaultMaxInterval, "轮询作业状态的最大间隔")
+	flags.BoolVar(&opts.quiet, "quiet", false, "不输出作业状态的变化")
+	// 设置必需参数
+	cmd.MarkFlagRequired("wait")
+
+	return cmd
+}
+
+// bwaitOptions 定义等待作业命令参数
+type bwaitOptions struct {
+	condition   string
+	timeout     time.Duration
+	interval    time.Duration
+	maxInterval time.Duration
+	quiet       bool
+}
+
+func runBWait(ctx context.Context, errOut io.Writer, cm *config.ConfigManager, opts bwaitOptions, clientOpts []client.Option) error {
+	cond, err := wait.Parse(opts.condition)
+	if err != nil {
+		return err
+	}
+	if opts.timeout < 0 {
+		return fmt.Errorf("--timeout 不能为负数")
+	}
+	if opts.interval <= 0 {
+		return fmt.Errorf("--interval 必须大于 0")
+	}
+	if opts.maxInterval < opts.interval {
+		return fmt.Errorf("--max-interval 不能小于 --interval")
+	}
+
+	// 获取当前服务器信息，使用上下文时为上下文中的服务器
+	serverInfo, err := cm.CurrentServer()
+	if err != nil {
+		return err
+	}
+	if serverInfo.Token == "" {
+		return fmt.Errorf("未登录到服务器，请先登录")
+	}
+
+	apiClient, err := client.NewServerClient(serverInfo, clientOpts...)
+	if err != nil {
+		return err
+	}
+
+	waitOpts := wait.Options{
+		Timeout:     opts.timeout,
+		Interval:    opts.interval,
+		MaxInterval: opts.maxInterval,
+	}
+	if !opts.quiet {
+		waitOpts.OnChange = wait.StatusPrinter(errOut)
+	}
+	return wait.Until(ctx, cond, wait.JobStatus(apiClient, serverInfo.Token), waitOpts)
+}
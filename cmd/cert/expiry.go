@@ -0,0 +1,105 @@
+package cert
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewExpiryCmd 创建证书过期检查命令
+func NewExpiryCmd(configManager *config.ConfigManager) *cobra.Command {
+	var (
+		server string
+		days   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "expiry",
+		Short: "检查已保存证书的过期时间",
+		Long: `检查每个 APIserver 已保存证书的过期时间，剩余天数不足 --days 时给出警告。
+存在即将过期或已过期的证书时命令返回非零状态，便于在定时任务中使用。
+示例:
+  cli cert expiry
+  cli cert expiry --days 60`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExpiry(cmd.OutOrStdout(), configManager, server, days)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&server, "server", "", "APIserver 名称或 URL，默认检查所有服务器")
+	flags.IntVar(&days, "days", 30, "剩余天数少于该值时给出警告")
+
+	return cmd
+}
+
+func runExpiry(out io.Writer, cm *config.ConfigManager, nameOrURL string, days int) error {
+	cfg, err := cm.GetConfig()
+	if err != nil {
+		return fmt.Errorf("获取配置失败: %v", err)
+	}
+
+	servers, err := selectServers(cfg, nameOrURL)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	warnings := 0
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSUBJECT\tNOT_AFTER\tDAYS_LEFT\tSTATUS")
+	for _, server := range servers {
+		if server.CACert == "" {
+			continue
+		}
+
+		certs, err := cert.LoadCertFile(server.CACert)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t%v\n", server.Name, err)
+			warnings++
+			continue
+		}
+
+		for _, c := range certs {
+			left := cert.DaysUntilExpiry(c, now)
+			status := "OK"
+			switch {
+			case now.After(c.NotAfter):
+				status = "EXPIRED"
+				warnings++
+			case left < days:
+				status = "WARN"
+				warnings++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n",
+				server.Name,
+				subjectName(c),
+				c.NotAfter.Format(time.DateOnly),
+				left,
+				status)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if warnings > 0 {
+		return fmt.Errorf("%d 个证书将在 %d 天内过期或已不可用", warnings, days)
+	}
+	return nil
+}
+
+// subjectName 返回证书的通用名称，没有时返回完整主题
+func subjectName(c *x509.Certificate) string {
+	if c.Subject.CommonName != "" {
+		return c.Subject.CommonName
+	}
+	return c.Subject.String()
+}
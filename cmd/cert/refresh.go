@@ -0,0 +1,111 @@
+package cert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/internal/transport"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewRefreshCmd 创建证书刷新命令
+func NewRefreshCmd(configManager *config.ConfigManager) *cobra.Command {
+	var (
+		server            string
+		acceptFingerprint string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "重新获取服务器证书",
+		Long: `服务器更换证书后重新获取并保存证书。证书发生变化时会显示新旧指纹并要求确认。
+示例:
+  cli cert refresh --server apiserver1
+  cli cert refresh --server apiserver1 --accept-fingerprint AB:CD:...`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRefresh(cmd, configManager, server, acceptFingerprint)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&server, "server", "", "APIserver 名称或 URL，默认刷新所有 HTTPS 服务器")
+	flags.StringVar(&acceptFingerprint, "accept-fingerprint", "", "预先确认的新证书 SHA-256 指纹，跳过交互确认")
+
+	return cmd
+}
+
+func runRefresh(cmd *cobra.Command, cm *config.ConfigManager, nameOrURL, acceptFingerprint string) error {
+	cfg, err := cm.GetConfig()
+	if err != nil {
+		return fmt.Errorf("获取配置失败: %v", err)
+	}
+
+	servers, err := selectServers(cfg, nameOrURL)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	refreshed := make(map[string]refreshedCert)
+	for _, server := range servers {
+		// 按 Ctrl-C 后不再连接剩余的服务器
+		if err := cmd.Context().Err(); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(server.URL, "https://") {
+			continue
+		}
+
+		certPath, err := cert.GetCertPath(cm.CertDir(), server.URL)
+		if err != nil {
+			return fmt.Errorf("获取证书路径失败: %v", err)
+		}
+		netOpts, err := transport.ServerOptions(server)
+		if err != nil {
+			return fmt.Errorf("服务器 %s 的网络配置无效: %v", server.Name, err)
+		}
+		fingerprint, err := cert.GeneratorCert(cmd.Context(), server.URL, certPath, cert.TrustOptions{
+			Pinned:            server.Fingerprint,
+			AcceptFingerprint: acceptFingerprint,
+			AllowChange:       true,
+			In:                cmd.InOrStdin(),
+			Out:               out,
+			ServerName:        server.TLSServerName,
+			Transport:         netOpts,
+		})
+		if err != nil {
+			return fmt.Errorf("刷新服务器 %s 的证书失败: %v", server.Name, err)
+		}
+
+		if fingerprint == server.Fingerprint {
+			fmt.Fprintf(out, "服务器 %s 的证书未变化\n", server.Name)
+		} else {
+			fmt.Fprintf(out, "服务器 %s 的证书已更新，新指纹: %s\n", server.Name, fingerprint)
+		}
+		refreshed[server.URL] = refreshedCert{path: certPath, fingerprint: fingerprint}
+	}
+
+	return cm.Update(func(cfg *config.Config) error {
+		for url, refreshed := range refreshed {
+			// 刷新期间已被删除的服务器不再保存
+			server := cfg.FindServer(url)
+			if server == nil {
+				continue
+			}
+			// 用户指定的 CA 证书优先，不替换为固定的服务器证书
+			if server.CACert == "" {
+				server.CACert = refreshed.path
+			}
+			server.Fingerprint = refreshed.fingerprint
+		}
+		return nil
+	})
+}
+
+// refreshedCert 刷新后的服务器证书路径和指纹
+type refreshedCert struct {
+	path        string
+	fingerprint string
+}
@@ -0,0 +1,27 @@
+package cert
+
+import (
+	"fmt"
+
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// selectServers 返回要处理的服务器，未指定时返回所有已配置的服务器
+func selectServers(cfg *config.Config, nameOrURL string) ([]*config.APIServerInfo, error) {
+	if nameOrURL != "" {
+		server := cfg.LookupServer(nameOrURL)
+		if server == nil {
+			return nil, fmt.Errorf("未找到指定的 APIserver: %s", nameOrURL)
+		}
+		return []*config.APIServerInfo{server}, nil
+	}
+
+	if len(cfg.APIServerInfo) == 0 {
+		return nil, fmt.Errorf("尚未配置任何 APIserver，请先登录")
+	}
+	servers := make([]*config.APIServerInfo, 0, len(cfg.APIServerInfo))
+	for i := range cfg.APIServerInfo {
+		servers = append(servers, &cfg.APIServerInfo[i])
+	}
+	return servers, nil
+}
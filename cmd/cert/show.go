@@ -0,0 +1,64 @@
+package cert
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewShowCmd 创建证书查看命令
+func NewShowCmd(configManager *config.ConfigManager) *cobra.Command {
+	var server string
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "查看已保存的服务器证书",
+		Long: `查看每个 APIserver 已保存的证书，包括主题、备用名称、颁发者、有效期和指纹。
+示例:
+  cli cert show                      # 查看所有服务器的证书
+  cli cert show --server apiserver1  # 查看指定服务器的证书`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runShow(cmd.OutOrStdout(), configManager, server)
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "", "APIserver 名称或 URL，默认查看所有服务器")
+	return cmd
+}
+
+func runShow(out io.Writer, cm *config.ConfigManager, nameOrURL string) error {
+	cfg, err := cm.GetConfig()
+	if err != nil {
+		return fmt.Errorf("获取配置失败: %v", err)
+	}
+
+	servers, err := selectServers(cfg, nameOrURL)
+	if err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		fmt.Fprintf(out, "%s (%s)\n", server.Name, server.URL)
+		if server.Fingerprint != "" {
+			fmt.Fprintf(out, "  固定指纹:    %s\n", server.Fingerprint)
+		}
+		if server.CACert == "" {
+			fmt.Fprintln(out, "  未保存证书")
+			continue
+		}
+
+		fmt.Fprintf(out, "  证书文件:    %s\n", server.CACert)
+		certs, err := cert.LoadCertFile(server.CACert)
+		if err != nil {
+			fmt.Fprintf(out, "  %v\n", err)
+			continue
+		}
+		for _, c := range certs {
+			cert.PrintCertDetails(out, c)
+		}
+	}
+	return nil
+}
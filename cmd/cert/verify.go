@@ -0,0 +1,87 @@
+package cert
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/transport"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewVerifyCmd 创建证书校验命令
+func NewVerifyCmd(configManager *config.ConfigManager) *cobra.Command {
+	var server string
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "使用当前证书池校验服务器证书",
+		Long: `使用每个 APIserver 当前配置的证书池、客户端证书和固定指纹与服务器握手，
+并报告校验失败的具体原因。
+示例:
+  cli cert verify
+  cli cert verify --server https://tt1.test.com:8443`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerify(cmd.Context(), cmd.OutOrStdout(), configManager, server)
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "", "APIserver 名称或 URL，默认校验所有服务器")
+	return cmd
+}
+
+func runVerify(ctx context.Context, out io.Writer, cm *config.ConfigManager, nameOrURL string) error {
+	cfg, err := cm.GetConfig()
+	if err != nil {
+		return fmt.Errorf("获取配置失败: %v", err)
+	}
+
+	servers, err := selectServers(cfg, nameOrURL)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, server := range servers {
+		// 按 Ctrl-C 后不再连接剩余的服务器
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(server.URL, "https://") {
+			fmt.Fprintf(out, "[SKIP] %s (%s): 非 HTTPS 服务器\n", server.Name, server.URL)
+			continue
+		}
+		if err := verifyServer(ctx, server); err != nil {
+			failed++
+			fmt.Fprintf(out, "[FAIL] %s (%s): %s\n", server.Name, server.URL, err)
+			continue
+		}
+		fmt.Fprintf(out, "[ OK ] %s (%s)\n", server.Name, server.URL)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d 个服务器的证书校验失败", failed)
+	}
+	return nil
+}
+
+// verifyServer 与服务器握手并返回可读的失败原因
+func verifyServer(ctx context.Context, server *config.APIServerInfo) error {
+	tlsConfig, err := client.NewTLSConfig(client.ServerTLSOptions(server))
+	if err != nil {
+		return fmt.Errorf("加载证书失败: %v", err)
+	}
+	netOpts, err := transport.ServerOptions(server)
+	if err != nil {
+		return err
+	}
+
+	if _, err := cert.VerifyServer(ctx, server.URL, tlsConfig, netOpts); err != nil {
+		return fmt.Errorf("%s", cert.ExplainVerifyError(err))
+	}
+	return nil
+}
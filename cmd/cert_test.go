@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+)
+
+func TestCertCommands(t *testing.T) {
+	s := newTestServer(t)
+	plain := xcetest.NewServer()
+	t.Cleanup(plain.Close)
+	c := newTestCLI(t)
+	c.logon(s)
+	c.mustRun("apiserver", "add", "--url", plain.URL(), "--name", "plain")
+	fp := fingerprint(t, s)
+
+	stdout := c.mustRun("cert", "show", "--server", "apiserver1")
+	assertContains(t, "show", stdout,
+		fmt.Sprintf("apiserver1 (%s)", s.URL()),
+		"固定指纹:    "+fp,
+		"证书文件:    "+filepath.Join(filepath.Dir(c.config), "certs"),
+		"SHA-256:     "+fp)
+	assertContains(t, "show", c.mustRun("cert", "show"), "plain ("+plain.URL()+")\n  未保存证书")
+	if _, _, err := c.run("cert", "show", "--server", "missing"); err == nil {
+		t.Error("show --server missing: want error")
+	}
+
+	stdout = c.mustRun("cert", "verify")
+	assertContains(t, "verify", stdout,
+		fmt.Sprintf("[ OK ] apiserver1 (%s)", s.URL()),
+		fmt.Sprintf("[SKIP] plain (%s): 非 HTTPS 服务器", plain.URL()))
+
+	stdout = c.mustRun("cert", "expiry", "--server", "apiserver1")
+	if got := tableRow(t, stdout, "apiserver1"); got[len(got)-1] != "OK" {
+		t.Errorf("expiry row = %v, want OK", got)
+	}
+	stdout, _, err := c.run("cert", "expiry", "--days", "1000000")
+	if err == nil {
+		t.Error("expiry --days 1000000: want error")
+	}
+	if got := tableRow(t, stdout, "apiserver1"); got[len(got)-1] != "WARN" {
+		t.Errorf("expiry row = %v, want WARN", got)
+	}
+
+	stdout = c.mustRun("cert", "refresh")
+	assertContains(t, "refresh", stdout, "服务器 apiserver1 的证书未变化")
+
+	// 固定的指纹与服务器证书不一致时确认后更新
+	wrong := strings.TrimSuffix(strings.Repeat("00:", 32), ":")
+	c.mustRun("config", "set", "servers.apiserver1.fingerprint", wrong)
+	stdout, _, err = c.runWithInput("n\n", "cert", "refresh", "--server", "apiserver1")
+	if err == nil {
+		t.Error("refresh rejecting the new certificate: want error")
+	}
+	assertContains(t, "refresh", stdout, "的证书已变更", "已固定:      "+wrong)
+	stdout, _, err = c.runWithInput("y\n", "cert", "refresh", "--server", "apiserver1")
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	assertContains(t, "refresh", stdout, "服务器 apiserver1 的证书已更新，新指纹: "+fp)
+	if got := c.loadConfig().FindServer(s.URL()).Fingerprint; got != fp {
+		t.Errorf("fingerprint after refresh = %s, want %s", got, fp)
+	}
+
+	// 使用其他 CA 时校验失败
+	other, err := xcetest.SelfSignedCert("other.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "other-ca.crt")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: other.Certificate[0]}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c.mustRun("config", "set", "--server", "apiserver1", "--cacert", caFile)
+	stdout, _, err = c.run("cert", "verify", "--server", "apiserver1")
+	if err == nil {
+		t.Error("verify with another CA: want error")
+	}
+	assertContains(t, "verify", stdout, fmt.Sprintf("[FAIL] apiserver1 (%s)", s.URL()))
+}
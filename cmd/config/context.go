@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewSetContextCmd 创建上下文设置命令
+func NewSetContextCmd(configManager *config.ConfigManager) *cobra.Command {
+	var ctx config.Context
+
+	cmd := &cobra.Command{
+		Use:   "set-context <name>",
+		Short: "添加或修改上下文",
+		Long: `添加或修改上下文。上下文包含 APIserver、账号、默认队列、默认资源需求和输出格式，
+修改已有上下文时只更新指定的字段。
+示例:
+  cli config set-context prod --server prod-cluster --account svc_account --queue normal
+  cli config set-context test --server test-cluster --account self --queue debug --output json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.Flags()
+			if flags.Changed("output") && ctx.Output != "" && ctx.Output != "table" && ctx.Output != "json" {
+				return fmt.Errorf("无效的输出格式: %s，必须是 table 或 json", ctx.Output)
+			}
+
+			updated := config.Context{Name: args[0]}
+			err := configManager.Update(func(cfg *config.Config) error {
+				// 修改已有上下文时保留未指定的字段
+				if existing := cfg.LookupContext(args[0]); existing != nil {
+					updated = *existing
+				}
+				if flags.Changed("server") {
+					updated.Server = ctx.Server
+				}
+				if flags.Changed("account") {
+					updated.Account = ctx.Account
+				}
+				if flags.Changed("queue") {
+					updated.Queue = ctx.Queue
+				}
+				if flags.Changed("resreq") {
+					updated.ResReq = ctx.ResReq
+				}
+				if flags.Changed("output") {
+					updated.Output = ctx.Output
+				}
+				return cfg.SetContext(updated)
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "上下文 %s 已更新\n", updated.Name)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&ctx.Server, "server", "", "APIserver 名称或 URL")
+	flags.StringVar(&ctx.Account, "account", "", "账号")
+	flags.StringVar(&ctx.Queue, "queue", "", "默认作业队列")
+	flags.StringVar(&ctx.ResReq, "resreq", "", "默认资源需求")
+	flags.StringVar(&ctx.Output, "output", "", "默认输出格式 (table/json)")
+
+	return cmd
+}
+
+// NewUseContextCmd 创建上下文切换命令
+func NewUseContextCmd(configManager *config.ConfigManager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use-context <name>",
+		Short: "切换当前上下文",
+		Long: `切换当前上下文。单条命令也可以通过 --context 参数或 CLI_CONTEXT 环境变量临时指定上下文。
+示例:
+  cli config use-context prod`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := configManager.Update(func(cfg *config.Config) error {
+				return cfg.UseContext(args[0])
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已切换到上下文 %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+// NewGetContextsCmd 创建上下文列表命令
+func NewGetContextsCmd(configManager *config.ConfigManager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get-contexts",
+		Short: "列出所有上下文",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetContexts(cmd.OutOrStdout(), configManager)
+		},
+	}
+}
+
+func runGetContexts(out io.Writer, cm *config.ConfigManager) error {
+	cfg, err := cm.GetConfig()
+	if err != nil {
+		return fmt.Errorf("获取配置失败: %v", err)
+	}
+
+	current, err := cm.CurrentContext()
+	if err != nil {
+		return err
+	}
+
+	// 使用 tabwriter 格式化输出
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Current\tName\tServer\tAccount\tQueue\tResReq\tOutput")
+	for _, ctx := range cfg.Contexts {
+		isCurrent := " "
+		if current != nil && ctx.Name == current.Name {
+			isCurrent = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			isCurrent,
+			ctx.Name,
+			ctx.Server,
+			ctx.Account,
+			ctx.Queue,
+			ctx.ResReq,
+			ctx.Output)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewDoctorCmd 创建配置检查命令
+func NewDoctorCmd(configManager *config.ConfigManager) *cobra.Command {
+	var fix bool
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "检查配置文件",
+		Long: `检查配置文件能否解析、格式版本、配置一致性、文件权限以及引用的证书文件。
+使用 --fix 时会升级旧版本的配置、收紧文件权限；配置文件损坏时会将其改名备份，
+并从上一次保存前的备份 (config.json.bak) 恢复，没有可用备份时使用空配置。
+示例:
+  cli config doctor
+  cli config doctor --fix`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(cmd.OutOrStdout(), configManager, fix)
+		},
+	}
+
+	cmd.Flags().BoolVar(&fix, "fix", false, "尝试修复发现的问题")
+	return cmd
+}
+
+func runDoctor(out io.Writer, cm *config.ConfigManager, fix bool) error {
+	if fix {
+		if err := fixConfig(out, cm); err != nil {
+			return err
+		}
+	}
+
+	checks := cm.Diagnose()
+	errCount := 0
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tMESSAGE")
+	for _, check := range checks {
+		if check.Status == config.CheckError {
+			errCount++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", check.Name, check.Status, check.Message)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if errCount > 0 {
+		return fmt.Errorf("配置文件存在 %d 个问题", errCount)
+	}
+	return nil
+}
+
+// fixConfig 恢复损坏的配置文件，升级旧版本配置并收紧文件权限
+func fixConfig(out io.Writer, cm *config.ConfigManager) error {
+	_, err := cm.GetConfig()
+	if errors.Is(err, config.ErrCorruptConfig) {
+		corruptPath, restoredFrom, recoverErr := cm.Recover()
+		if recoverErr != nil {
+			return fmt.Errorf("恢复配置文件失败: %v", recoverErr)
+		}
+		fmt.Fprintf(out, "损坏的配置文件已备份为 %s\n", corruptPath)
+		if restoredFrom != "" {
+			fmt.Fprintf(out, "已从 %s 恢复配置\n", restoredFrom)
+		} else {
+			fmt.Fprintln(out, "没有可用的备份，已重置为空配置")
+		}
+		_, err = cm.GetConfig()
+	}
+	if err != nil {
+		return fmt.Errorf("获取配置失败: %v", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(cm.ConfigPath())
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(cm.ConfigPath(), 0600); err != nil {
+			return fmt.Errorf("修改配置文件权限失败: %v", err)
+		}
+		fmt.Fprintf(out, "配置文件权限已修改为 0600\n")
+	}
+	return nil
+}
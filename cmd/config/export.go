@@ -0,0 +1,65 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewExportCmd 创建配置导出命令
+func NewExportCmd(configManager *config.ConfigManager) *cobra.Command {
+	var (
+		file    string
+		servers []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "导出可分享的配置包",
+		Long: `导出可以分享给其他用户的配置包，包含 APIserver 地址和名称、CA 证书、固定的证书指纹以及上下文。
+配置包不包含 token、账号和客户端证书等个人信息。
+示例:
+  cli config export -f team.json
+  cli config export --server prod --server test > team.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd.OutOrStdout(), configManager, file, servers)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&file, "file", "f", "", "保存配置包的文件，默认输出到标准输出")
+	flags.StringArrayVar(&servers, "server", nil, "只导出指定的 APIserver (名称或 URL)，可以多次指定")
+
+	return cmd
+}
+
+func runExport(out io.Writer, cm *config.ConfigManager, file string, servers []string) error {
+	cfg, err := cm.GetConfig()
+	if err != nil {
+		return fmt.Errorf("获取配置失败: %v", err)
+	}
+
+	bundle, err := cfg.Export(servers...)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if file == "" {
+		fmt.Fprintln(out, string(data))
+		return nil
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("保存配置包失败: %v", err)
+	}
+	fmt.Fprintf(out, "已导出 %d 个 APIserver 和 %d 个上下文到 %s\n", len(bundle.Servers), len(bundle.Contexts), file)
+	return nil
+}
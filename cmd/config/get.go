@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewGetCmd 创建配置读取命令
+func NewGetCmd(configManager *config.ConfigManager) *cobra.Command {
+	var (
+		raw    bool
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "读取配置项的值",
+		Long: `按点分隔的键路径读取配置项，键名与配置文件中的字段名一致，列表元素可以用下标或名称访问。
+示例:
+  cli config get defaultAPIserver
+  cli config get servers.apiserver1.url
+  cli config get servers.0 -o yaml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := configManager.GetConfig()
+			if err != nil {
+				return fmt.Errorf("获取配置失败: %v", err)
+			}
+
+			if !raw {
+				if cfg, err = cfg.Redacted(); err != nil {
+					return fmt.Errorf("脱敏配置失败: %v", err)
+				}
+			}
+			value, err := cfg.GetValue(args[0])
+			if err != nil {
+				return err
+			}
+			return writeValue(cmd.OutOrStdout(), value, output)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&raw, "raw", false, "显示 token 等敏感信息")
+	flags.StringVarP(&output, "output", "o", "json", "对象和列表的输出格式 (json/yaml)")
+
+	return cmd
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewImportCmd 创建配置导入命令
+func NewImportCmd(configManager *config.ConfigManager) *cobra.Command {
+	var conflict string
+
+	cmd := &cobra.Command{
+		Use:   "import <file|->",
+		Short: "导入配置包",
+		Long: `将 cli config export 导出的配置包合并到本地配置，- 表示从标准输入读取。
+同一 URL 的 APIserver 或同名上下文与本地配置不同时按 --conflict 处理:
+  error      不做任何修改并列出冲突 (默认)
+  skip       保留本地配置
+  overwrite  使用配置包覆盖本地配置，本地的 token、账号和客户端证书会保留
+名称与本地其他 APIserver 重复的新服务器会被自动重命名。
+示例:
+  cli config import team.json
+  cli config import --conflict skip - < team.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd.InOrStdin(), cmd.OutOrStdout(), configManager, args[0], conflict)
+		},
+	}
+
+	cmd.Flags().StringVar(&conflict, "conflict", config.ConflictError, "冲突处理方式 (error/skip/overwrite)")
+	return cmd
+}
+
+func runImport(in io.Reader, out io.Writer, cm *config.ConfigManager, file, conflict string) error {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(in)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return fmt.Errorf("读取配置包失败: %v", err)
+	}
+
+	var bundle config.Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("解析配置包失败: %v", err)
+	}
+
+	// 没有变化时保存的内容与原文件相同
+	var changes []string
+	err = cm.Update(func(cfg *config.Config) error {
+		changes, err = cfg.Import(&bundle, func(serverURL string) (string, error) {
+			return cert.GetCertPath(cm.CertDir(), serverURL)
+		}, conflict)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "配置已是最新，无需修改")
+		return nil
+	}
+
+	for _, change := range changes {
+		fmt.Fprintln(out, change)
+	}
+	fmt.Fprintln(out, "配置已导入")
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeValue 按格式输出配置值，字符串、数字等标量直接输出，对象和列表按 json 或 yaml 输出
+func writeValue(out io.Writer, v interface{}, format string) error {
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Map:
+	default:
+		_, err := fmt.Fprintln(out, v)
+		return err
+	}
+
+	switch format {
+	case "json", "":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case "yaml":
+		// 先转换为通用结构，使 yaml 字段名与配置文件中的 json 字段名一致
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+		yamlData, err := yaml.Marshal(generic)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(yamlData)
+		return err
+	default:
+		return fmt.Errorf("不支持的输出格式: %s，必须是 json 或 yaml", format)
+	}
+}
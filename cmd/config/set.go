@@ -2,11 +2,11 @@ package config
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"regexp"
 
 	"github.com/spf13/cobra"
-	"github.com/xx/pkg/config"
+	"github.com/zhengyansheng/xcli/pkg/config"
 )
 
 // NewSetCmd 创建配置设置命令
@@ -14,88 +14,197 @@ func NewSetCmd(configManager *config.ConfigManager) *cobra.Command {
 	var (
 		defaultAPIServer string
 		defaultQueryAll  string
+		server           string
 		caCert           string
+		caBundle         string
+		systemCA         string
+		clientCert       string
+		clientKey        string
+		pkcs12           string
 	)
 
 	cmd := &cobra.Command{
-		Use:   "set",
+		Use:   "set [<key> <value>]",
 		Short: "设置 APIserver 配置文件中的值",
 		Long: `设置 APIserver 配置文件中的个别值。
 示例:
   cli config set --defaultapiserver http://tt1.test.com:8080  # 设置默认 APIserver
   cli config set --defaultqueryall y                          # 设置默认查询所有
-  cli config set --cacert /usr/cacert.pem                     # 设置证书路径`,
+  cli config set --cacert /usr/cacert.pem                     # 设置默认 APIserver 的证书路径
+  cli config set --server apiserver2 --cabundle /etc/ssl/extra.pem --systemca y  # 信任系统证书库及额外证书包
+  cli config set --server apiserver2 --client-cert user.crt --client-key user.key  # 设置双向 TLS 客户端证书
+  cli config set servers.apiserver2.system_ca true            # 按键路径设置任意配置项
+  cli config set servers.apiserver2.retries 5                 # 查询和提交作业失败时最多重试 5 次，-1 表示不重试
+  cli config set servers.apiserver2.retry_wait 1s             # 第一次重试前等待 1 秒，之后每次翻倍，最长 retry_max_wait
+  cli config set servers.apiserver2.proxy socks5://127.0.0.1:1080  # 连接该服务器使用的代理，默认使用 HTTPS_PROXY 等环境变量
+  cli config set servers.apiserver2.no_proxy .internal,10.0.0.0/8   # 不使用代理的主机，与 NO_PROXY 环境变量合并
+  cli config set servers.apiserver2.hosts tt1.test.com=10.0.0.5     # 静态解析，多个以逗号分隔，地址可带端口
+  cli config set servers.apiserver2.unix_socket /var/run/xce.sock   # 通过 Unix 域套接字连接
+  cli config set servers.apiserver2.connect_timeout 5s              # 建立连接的超时时间
+  cli config set servers.apiserver2.api_version v1                  # 使用 /xce/v1 接口，下次登录时重新协商`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 && len(args) != 2 {
+				return fmt.Errorf("需要同时提供 <key> 和 <value>")
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSet(configManager, defaultAPIServer, defaultQueryAll, caCert)
+			if len(args) == 2 {
+				return runSetKey(cmd.OutOrStdout(), configManager, args[0], args[1])
+			}
+			return runSet(cmd.OutOrStdout(), configManager, defaultAPIServer, defaultQueryAll, tlsSettings{
+				server:     server,
+				caCert:     caCert,
+				caBundle:   caBundle,
+				systemCA:   systemCA,
+				clientCert: clientCert,
+				clientKey:  clientKey,
+				pkcs12:     pkcs12,
+			})
 		},
 	}
 
 	flags := cmd.Flags()
 	flags.StringVar(&defaultAPIServer, "defaultapiserver", "", "设置默认的 APIserver")
 	flags.StringVar(&defaultQueryAll, "defaultqueryall", "", "设置是否默认查询所有 (y/n)")
+	flags.StringVar(&server, "server", "", "证书设置作用的 APIserver 名称或 URL，默认为默认 APIserver")
 	flags.StringVar(&caCert, "cacert", "", "设置用于验证 APIserver 的 CA 证书路径")
+	flags.StringVar(&caBundle, "cabundle", "", "设置额外信任的 CA 证书包路径")
+	flags.StringVar(&systemCA, "systemca", "", "设置是否同时信任系统证书库 (y/n)")
+	flags.StringVar(&clientCert, "client-cert", "", "设置双向 TLS 客户端证书路径 (PEM)")
+	flags.StringVar(&clientKey, "client-key", "", "设置双向 TLS 客户端私钥路径 (PEM，可加密)")
+	flags.StringVar(&pkcs12, "pkcs12", "", "设置包含客户端证书和私钥的 PKCS#12 文件路径")
+	cmd.MarkFlagsMutuallyExclusive("client-cert", "pkcs12")
 
 	return cmd
 }
 
-func runSet(cm *config.ConfigManager, defaultAPIServer, defaultQueryAll, caCert string) error {
-	cfg, err := cm.GetConfig()
-	if err != nil {
-		return fmt.Errorf("获取配置失败: %v", err)
-	}
+// tlsSettings 定义单个服务器的证书设置
+type tlsSettings struct {
+	server     string
+	caCert     string
+	caBundle   string
+	systemCA   string
+	clientCert string
+	clientKey  string
+	pkcs12     string
+}
+
+// isSet 是否设置了任一证书选项
+func (t tlsSettings) isSet() bool {
+	return t.caCert != "" || t.caBundle != "" || t.systemCA != "" ||
+		t.clientCert != "" || t.clientKey != "" || t.pkcs12 != ""
+}
 
-	// 设置默认 APIserver
+func runSet(out io.Writer, cm *config.ConfigManager, defaultAPIServer, defaultQueryAll string, tls tlsSettings) error {
+	// 验证 URL 格式
 	if defaultAPIServer != "" {
-		// 验证 URL 格式
-		if !isValidURL(defaultAPIServer) {
-			return fmt.Errorf("无效的 APIserver URL: %s", defaultAPIServer)
+		if _, err := config.NormalizeURL(defaultAPIServer); err != nil {
+			return err
 		}
+	}
+	switch defaultQueryAll {
+	case "", "y", "Y", "n", "N":
+	default:
+		return fmt.Errorf("defaultqueryall 参数无效，请使用 y 或 n")
+	}
 
-		found := false
-		for _, server := range cfg.APIServerInfo {
-			if server.URL == defaultAPIServer {
-				found = true
-				cfg.DefaultAPIServer = defaultAPIServer
-				break
+	err := cm.Update(func(cfg *config.Config) error {
+		// 设置默认 APIserver
+		if defaultAPIServer != "" {
+			server := cfg.FindServer(defaultAPIServer)
+			if server == nil {
+				return fmt.Errorf("未找到指定的 APIserver: %s", defaultAPIServer)
 			}
+			cfg.DefaultAPIServer = server.URL
 		}
-		if !found {
-			return fmt.Errorf("未找到指定的 APIserver: %s", defaultAPIServer)
-		}
-	}
 
-	// 设置默认查询所有
-	if defaultQueryAll != "" {
-		switch defaultQueryAll {
-		case "y", "Y":
-			cfg.DefaultQueryAll = true
-		case "n", "N":
-			cfg.DefaultQueryAll = false
-		default:
-			return fmt.Errorf("defaultqueryall 参数无效，请使用 y 或 n")
+		// 设置默认查询所有
+		if defaultQueryAll != "" {
+			queryAll := defaultQueryAll == "y" || defaultQueryAll == "Y"
+			cfg.DefaultQueryAll = &queryAll
 		}
-	}
 
-	// 设置 CA 证书
-	if caCert != "" {
-		// 检查证书文件是否存在
-		if _, err := os.Stat(caCert); err != nil {
-			return fmt.Errorf("证书文件不存在: %s", caCert)
+		// 设置服务器证书
+		if tls.isSet() {
+			return setServerTLS(cfg, tls)
 		}
-		cfg.CACert = caCert
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// 保存配置
-	if err := cm.SaveConfig(cfg); err != nil {
-		return fmt.Errorf("保存配置失败: %v", err)
+	fmt.Fprintln(out, "配置已更新")
+	return nil
+}
+
+// runSetKey 按键路径设置配置项
+func runSetKey(out io.Writer, cm *config.ConfigManager, key, value string) error {
+	err := cm.Update(func(cfg *config.Config) error {
+		if err := cfg.SetValue(key, value); err != nil {
+			return err
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("设置后的配置无效: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("配置已更新")
+	fmt.Fprintln(out, "配置已更新")
 	return nil
 }
 
-// isValidURL 验证 URL 格式
-func isValidURL(url string) bool {
-	re := regexp.MustCompile(`^http[s]?://[a-zA-Z0-9.-]+(:[0-9]+)?(/.*)?$`)
-	return re.MatchString(url)
+// setServerTLS 设置指定服务器的 CA 证书、证书包和系统证书库选项
+func setServerTLS(cfg *config.Config, tls tlsSettings) error {
+	target := tls.server
+	if target == "" {
+		target = cfg.DefaultAPIServer
+	}
+	server := cfg.LookupServer(target)
+	if server == nil {
+		return fmt.Errorf("未找到指定的 APIserver: %s", target)
+	}
+
+	// 检查证书文件是否存在
+	for _, certPath := range []string{tls.caCert, tls.caBundle, tls.clientCert, tls.clientKey, tls.pkcs12} {
+		if certPath == "" {
+			continue
+		}
+		if _, err := os.Stat(certPath); err != nil {
+			return fmt.Errorf("证书文件不存在: %s", certPath)
+		}
+	}
+	if tls.caCert != "" {
+		server.CACert = tls.caCert
+	}
+	if tls.caBundle != "" {
+		server.CABundle = tls.caBundle
+	}
+	if tls.clientCert != "" {
+		server.ClientCert = tls.clientCert
+		server.PKCS12 = ""
+	}
+	if tls.clientKey != "" {
+		server.ClientKey = tls.clientKey
+	}
+	if tls.pkcs12 != "" {
+		server.PKCS12 = tls.pkcs12
+		server.ClientCert = ""
+		server.ClientKey = ""
+	}
+
+	switch tls.systemCA {
+	case "":
+	case "y", "Y":
+		server.SystemCA = true
+	case "n", "N":
+		server.SystemCA = false
+	default:
+		return fmt.Errorf("systemca 参数无效，请使用 y 或 n")
+	}
+	return nil
 }
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewUnsetCmd 创建配置清除命令
+func NewUnsetCmd(configManager *config.ConfigManager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <key>",
+		Short: "清除配置项",
+		Long: `按点分隔的键路径清除配置项，列表元素会从列表中删除。
+示例:
+  cli config unset servers.apiserver1.ca_bundle
+  cli config unset defaultqueryall`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUnset(cmd.OutOrStdout(), configManager, args[0])
+		},
+	}
+}
+
+func runUnset(out io.Writer, cm *config.ConfigManager, key string) error {
+	err := cm.Update(func(cfg *config.Config) error {
+		if err := cfg.UnsetValue(key); err != nil {
+			return err
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("清除后的配置无效: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "已清除配置项 %s\n", key)
+	return nil
+}
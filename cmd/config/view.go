@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// NewViewCmd 创建配置查看命令
+func NewViewCmd(configManager *config.ConfigManager) *cobra.Command {
+	var (
+		raw    bool
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "查看当前生效的配置",
+		Long: `查看当前生效的配置，token 等敏感信息默认脱敏显示。
+示例:
+  cli config view            # 以 json 格式查看配置
+  cli config view -o yaml    # 以 yaml 格式查看配置
+  cli config view --raw      # 显示 token 等敏感信息`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := configManager.GetConfig()
+			if err != nil {
+				return fmt.Errorf("获取配置失败: %v", err)
+			}
+
+			if !raw {
+				if cfg, err = cfg.Redacted(); err != nil {
+					return fmt.Errorf("脱敏配置失败: %v", err)
+				}
+			}
+			return writeValue(cmd.OutOrStdout(), cfg, output)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&raw, "raw", false, "显示 token 等敏感信息")
+	flags.StringVarP(&output, "output", "o", "json", "输出格式 (json/yaml)")
+
+	return cmd
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+func TestConfigCommands(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestCLI(t)
+	c.logon(s)
+	token := c.loadConfig().FindServer(s.URL()).Token
+
+	stdout := c.mustRun("config", "set", "--defaultqueryall", "y")
+	assertContains(t, "set", stdout, "配置已更新")
+	if got := c.mustRun("config", "get", "defaultqueryall"); got != "true\n" {
+		t.Errorf("get defaultqueryall = %q, want true", got)
+	}
+	if _, _, err := c.run("config", "set", "--defaultqueryall", "maybe"); err == nil {
+		t.Error("set --defaultqueryall maybe: want error")
+	}
+
+	c.mustRun("config", "set", "servers.apiserver1.retries", "3")
+	if got := c.mustRun("config", "get", "servers.apiserver1.retries"); got != "3\n" {
+		t.Errorf("get servers.apiserver1.retries = %q, want 3", got)
+	}
+	if _, _, err := c.run("config", "set", "servers.apiserver1.url", "ftp://example.com"); err == nil {
+		t.Error("set an invalid server url: want error")
+	}
+
+	// view 和 get 默认隐藏 token
+	stdout = c.mustRun("config", "view")
+	assertContains(t, "view", stdout, s.URL(), `"retries": 3`)
+	if strings.Contains(stdout, token) {
+		t.Errorf("view shows the token:\n%s", stdout)
+	}
+	assertContains(t, "view --raw", c.mustRun("config", "view", "--raw"), token)
+	assertContains(t, "get -o yaml", c.mustRun("config", "get", "servers.apiserver1", "-o", "yaml"), "retries: 3")
+
+	stdout = c.mustRun("config", "unset", "defaultqueryall")
+	assertContains(t, "unset", stdout, "已清除配置项 defaultqueryall")
+	if got := c.mustRun("config", "get", "defaultqueryall"); got != "false\n" {
+		t.Errorf("get defaultqueryall after unset = %q, want false", got)
+	}
+
+	stdout = c.mustRun("config", "set-context", "prod", "--server", "apiserver1", "--account", "alice", "--queue", "normal", "--output", "json")
+	assertContains(t, "set-context", stdout, "上下文 prod 已更新")
+	c.mustRun("config", "set-context", "dev", "--server", s.URL(), "--queue", "debug")
+	if _, _, err := c.run("config", "set-context", "bad", "--server", "apiserver1", "--output", "xml"); err == nil {
+		t.Error("set-context --output xml: want error")
+	}
+	stdout = c.mustRun("config", "use-context", "prod")
+	assertContains(t, "use-context", stdout, "已切换到上下文 prod")
+	if _, _, err := c.run("config", "use-context", "missing"); err == nil {
+		t.Error("use-context missing: want error")
+	}
+
+	stdout = c.mustRun("config", "get-contexts")
+	if got, want := tableRow(t, stdout, "prod"), []string{"*", "prod", "apiserver1", "alice", "normal", "json"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("get-contexts row = %v, want %v", got, want)
+	}
+	// --context 只影响本次命令
+	stdout = c.mustRun("config", "get-contexts", "--context", "dev")
+	if got := tableRow(t, stdout, "dev"); got[0] != "*" {
+		t.Errorf("get-contexts --context dev row = %v, want dev to be current", got)
+	}
+
+	stdout = c.mustRun("config", "doctor")
+	if got := tableRow(t, stdout, "版本"); got[1] != config.CheckOK {
+		t.Errorf("doctor row = %v, want OK", got)
+	}
+
+	// 导出的配置包不包含 token 和账号，导入到另一份配置中
+	bundleFile := filepath.Join(t.TempDir(), "bundle.json")
+	stdout = c.mustRun("config", "export", "-f", bundleFile)
+	assertContains(t, "export", stdout, "已导出 1 个 APIserver 和 2 个上下文到 "+bundleFile)
+	stdout = c.mustRun("config", "export", "--server", "apiserver1")
+	var bundle config.Bundle
+	if err := json.Unmarshal([]byte(stdout), &bundle); err != nil {
+		t.Fatalf("export output is not a bundle: %v\n%s", err, stdout)
+	}
+	if strings.Contains(stdout, token) || strings.Contains(stdout, "alice") {
+		t.Errorf("exported bundle contains personal information:\n%s", stdout)
+	}
+	if _, _, err := c.run("config", "export", "--server", "missing"); err == nil {
+		t.Error("export --server missing: want error")
+	}
+
+	other := newTestCLI(t)
+	stdout = other.mustRun("config", "import", bundleFile)
+	assertContains(t, "import", stdout,
+		fmt.Sprintf("添加 APIserver apiserver1 (%s)", s.URL()),
+		"添加上下文 prod",
+		"添加上下文 dev",
+		"配置已导入")
+	data, err := os.ReadFile(bundleFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, err = other.runWithInput(string(data), "config", "import", "-")
+	if err != nil {
+		t.Fatalf("import -: %v", err)
+	}
+	assertContains(t, "import -", stdout, "配置已是最新，无需修改")
+
+	// 导入的服务器沿用固定的证书，登录时无需再次确认
+	imported := other.loadConfig().FindServer(s.URL())
+	if imported == nil || imported.Token != "" || imported.Fingerprint != fingerprint(t, s) {
+		t.Fatalf("imported server = %+v, want the pinned fingerprint without a token", imported)
+	}
+	other.mustRun("apiserver", "logon", "-n", "alice", "-p", "secret", "--url", s.URL())
+}
+
+func TestConfigDoctorFix(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestCLI(t)
+	c.logon(s)
+	c.mustRun("config", "set", "servers.apiserver1.retries", "2")
+
+	if err := os.WriteFile(c.config, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, err := c.run("config", "doctor")
+	if err == nil {
+		t.Fatalf("doctor on a corrupt config: want error\n%s", stdout)
+	}
+	if got := tableRow(t, stdout, "格式"); got[1] != config.CheckError {
+		t.Errorf("doctor row = %v, want ERROR", got)
+	}
+
+	stdout = c.mustRun("config", "doctor", "--fix")
+	assertContains(t, "doctor --fix", stdout, "损坏的配置文件已备份为", "已从")
+	if server := c.loadConfig().FindServer(s.URL()); server == nil {
+		t.Error("server not restored from the backup")
+	}
+
+	// 其他用户可读的配置文件只给出警告，--fix 收紧权限
+	if err := os.Chmod(c.config, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := tableRow(t, c.mustRun("config", "doctor"), "权限"); got[1] != config.CheckWarn {
+		t.Errorf("doctor row = %v, want WARN", got)
+	}
+	assertContains(t, "doctor --fix", c.mustRun("config", "doctor", "--fix"), "配置文件权限已修改为 0600")
+}
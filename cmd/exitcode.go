@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/wait"
+)
+
+// 进程退出码，脚本可以据此区分失败原因
+const (
+	// ExitOK 执行成功
+	ExitOK = 0
+	// ExitError 其他错误
+	ExitError = 1
+	// ExitUnauthorized 未登录或登录已过期
+	ExitUnauthorized = 3
+	// ExitForbidden 没有权限
+	ExitForbidden = 4
+	// ExitNotFound 资源不存在
+	ExitNotFound = 5
+	// ExitConflict 资源冲突
+	ExitConflict = 6
+	// ExitUnsupported 服务器不支持请求的功能或 API 版本
+	ExitUnsupported = 7
+	// ExitConditionFailed bwait 等待的条件已不可能满足，如作业以 EXIT 结束
+	ExitConditionFailed = 8
+	// ExitTimeout 等待超时，与 timeout 命令的约定一致
+	ExitTimeout = 124
+	// ExitInterrupted 被 Ctrl-C 中断，与 shell 的约定一致
+	ExitInterrupted = 130
+)
+
+// ExitCode 返回错误对应的进程退出码
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, client.ErrUnauthorized):
+		return ExitUnauthorized
+	case errors.Is(err, client.ErrForbidden):
+		return ExitForbidden
+	case errors.Is(err, client.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, client.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, client.ErrUnsupported):
+		return ExitUnsupported
+	case errors.Is(err, wait.ErrConditionFailed):
+		return ExitConditionFailed
+	case errors.Is(err, wait.ErrTimeout):
+		return ExitTimeout
+	default:
+		return ExitError
+	}
+}
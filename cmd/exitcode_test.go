@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/wait"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"成功", nil, ExitOK},
+		{"其他错误", errors.New("boom"), ExitError},
+		{"中断", fmt.Errorf("查询作业失败: %w", context.Canceled), ExitInterrupted},
+		{"未登录", &client.APIError{StatusCode: http.StatusUnauthorized}, ExitUnauthorized},
+		{"没有权限", &client.APIError{StatusCode: http.StatusForbidden}, ExitForbidden},
+		{"作业不存在", fmt.Errorf("作业 1: %w", client.ErrNotFound), ExitNotFound},
+		{"业务码表示不存在", &client.APIError{StatusCode: http.StatusOK, Code: http.StatusNotFound}, ExitNotFound},
+		{"冲突", &client.APIError{StatusCode: http.StatusConflict}, ExitConflict},
+		{"不支持", &client.UnsupportedError{Feature: "分页查询"}, ExitUnsupported},
+		{"条件不可能满足", fmt.Errorf("%w: done(1) (1=EXIT)", wait.ErrConditionFailed), ExitConditionFailed},
+		{"等待超时", fmt.Errorf("%w (1m0s): done(1) (1=RUN)", wait.ErrTimeout), ExitTimeout},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: ExitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+)
+
+func TestJobCommands(t *testing.T) {
+	s := newTestServer(t, xcetest.WithQueues(xcetest.DefaultQueues...))
+	c := newTestCLI(t)
+	c.logon(s)
+
+	// 未登录时提示先登录
+	c.mustRun("apiserver", "logout", "--url", s.URL())
+	if _, _, err := c.run("bjobs"); err == nil || !strings.Contains(err.Error(), "请先登录") {
+		t.Errorf("bjobs after logout: err = %v, want a logon hint", err)
+	}
+	c.logon(s)
+
+	// xsub 要求提供位置参数，提交的命令由 -c 指定
+	stdout := c.mustRun("xsub", "-q", "normal", "-c", "sleep 60", "sleep", "60")
+	assertContains(t, "xsub", stdout, "作业提交成功，作业ID: 1")
+	stdout = c.mustRun("xsub", "-c", "sleep 30", "-R", "select(!mg)", "-o", "json", "sleep", "30")
+	var submitted client.JobSubmitData
+	if err := json.Unmarshal([]byte(stdout), &submitted); err != nil || submitted.JobID != 2 {
+		t.Errorf("xsub -o json = %q (%v), want job 2", stdout, err)
+	}
+	if job, _ := s.Job(2); job.Command != "sleep 30" || job.ResReq != "select(!mg)" {
+		t.Errorf("job 2 = %+v, want the -c command and -R resource requirement", job)
+	}
+	if _, _, err := c.run("xsub", "-q", "normal"); err == nil {
+		t.Error("xsub without a command: want error")
+	}
+
+	// 上下文中的队列和输出格式作为默认值
+	c.mustRun("config", "set-context", "short", "--server", "apiserver1", "--queue", "short", "--output", "json")
+	c.mustRun("xsub", "--context", "short", "-c", "hostname", "hostname")
+	if job, _ := s.Job(3); job.Queue != "short" {
+		t.Errorf("job 3 queue = %q, want the context queue short", job.Queue)
+	}
+
+	if err := s.SetJobState(1, xcetest.StatusDone, 0); err != nil {
+		t.Fatal(err)
+	}
+	stdout = c.mustRun("bjobs")
+	if got, want := tableRow(t, stdout, "1"), []string{"1", "alice", "DONE", "normal", "sleep", "60"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("bjobs row = %v, want %v", got, want)
+	}
+	assertContains(t, "bjobs", stdout, "JOBID", "sleep 30", "hostname")
+	if got := strings.Count(c.mustRun("bjobs", "--limit", "2", "--page-size", "1"), "alice"); got != 2 {
+		t.Errorf("bjobs --limit 2 printed %d jobs, want 2", got)
+	}
+	assertContains(t, "bjobs -q", c.mustRun("bjobs", "-q", "nosuchqueue"), "没有找到作业")
+
+	var jobs []client.Job
+	stdout = c.mustRun("bjobs", "--context", "short")
+	if err := json.Unmarshal([]byte(stdout), &jobs); err != nil || len(jobs) != 3 {
+		t.Fatalf("bjobs with json output = %q (%v), want 3 jobs", stdout, err)
+	}
+	if jobs[0].JobID != 1 || jobs[0].Status != xcetest.StatusDone {
+		t.Errorf("first job = %+v, want job 1 DONE", jobs[0])
+	}
+	if _, _, err := c.run("bjobs", "-o", "xml"); err == nil {
+		t.Error("bjobs -o xml: want error")
+	}
+
+	// 服务器注销 token 后退出码为 3
+	s.RevokeTokens()
+	_, _, err := c.run("bjobs")
+	if code := ExitCode(err); code != ExitUnauthorized {
+		t.Errorf("bjobs with a revoked token: exit code %d (%v), want %d", code, err, ExitUnauthorized)
+	}
+}
+
+func TestBHosts(t *testing.T) {
+	hosts := xcetest.GenerateHosts(4, rand.New(rand.NewPCG(1, 1)))
+	s := newTestServer(t, xcetest.WithHosts(hosts...))
+	c := newTestCLI(t)
+	c.logon(s)
+
+	// 每种主机类型只列出该类型的主机
+	for _, hostType := range []string{"X86_64", "ARM"} {
+		stdout := c.mustRun("bhosts", "--host-type", strings.ToLower(hostType))
+		assertContains(t, "bhosts", stdout, "HOST_NAME")
+		for _, host := range hosts {
+			if host.HostType != hostType {
+				if strings.Contains(stdout, host.HostName+" ") {
+					t.Errorf("bhosts --host-type %s lists %s (%s)", hostType, host.HostName, host.HostType)
+				}
+				continue
+			}
+			if got := tableRow(t, stdout, host.HostName); got[1] != hostType {
+				t.Errorf("bhosts row = %v, want type %s", got, hostType)
+			}
+		}
+	}
+
+	if _, _, err := c.run("bhosts", "--host-type", "X86_64", "--type", "brief"); err == nil {
+		t.Error("bhosts --type brief: want error")
+	}
+	if _, _, err := c.run("bhosts", "--host-type", "sparc"); err == nil {
+		t.Error("bhosts --host-type sparc: want error")
+	}
+
+	empty := newTestServer(t)
+	other := newTestCLI(t)
+	other.logon(empty)
+	assertContains(t, "bhosts", other.mustRun("bhosts", "--host-type", "ARM"), "没有找到主机")
+}
+
+func TestBWait(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestCLI(t)
+	c.logon(s)
+	for range 3 {
+		c.mustRun("xsub", "-c", "sleep 60", "sleep", "60")
+	}
+	s.SetJobState(1, xcetest.StatusDone, 0)
+	s.SetJobState(2, xcetest.StatusExit, 1)
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "已满足", args: []string{"-w", "done(1) && exit(2)"}, want: ExitOK},
+		{name: "不可能满足", args: []string{"-w", "done(1) && done(2)"}, want: ExitConditionFailed},
+		{name: "超时", args: []string{"-w", "ended(3)", "-t", "50ms", "--interval", "10ms"}, want: ExitTimeout},
+		{name: "作业不存在", args: []string{"-w", "done(99)"}, want: ExitNotFound},
+		{name: "条件无效", args: []string{"-w", "done(1) &&"}, want: ExitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := c.run(append([]string{"bwait"}, tt.args...)...)
+			if code := ExitCode(err); code != tt.want {
+				t.Errorf("bwait %s: exit code %d (%v), want %d", strings.Join(tt.args, " "), code, err, tt.want)
+			}
+		})
+	}
+
+	// 作业状态的变化输出到标准错误，--quiet 不输出
+	_, stderr, err := c.run("bwait", "-w", "ended(1) && ended(2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`作业 1: DONE\n.*作业 2: EXIT\n`).MatchString(stderr) {
+		t.Errorf("bwait stderr = %q, want status lines for jobs 1 and 2", stderr)
+	}
+	if _, stderr, _ := c.run("bwait", "-w", "done(1)", "--quiet"); stderr != "" {
+		t.Errorf("bwait --quiet stderr = %q, want nothing", stderr)
+	}
+}
+
+func TestXSubWait(t *testing.T) {
+	// 作业提交后立即结束
+	done := newTestServer(t, xcetest.WithScheduler(xcetest.Lifecycle{}))
+	failed := newTestServer(t, xcetest.WithScheduler(xcetest.Lifecycle{ExitCode: 1}))
+	c := newTestCLI(t)
+	// 第一个登录的服务器成为默认服务器
+	c.logon(failed)
+	c.logon(done)
+
+	stdout, stderr, err := c.run("xsub", "--wait", "-c", "sleep 1", "sleep", "1")
+	if code := ExitCode(err); code != ExitConditionFailed {
+		t.Errorf("xsub --wait on a failing job: exit code %d (%v), want %d", code, err, ExitConditionFailed)
+	}
+	assertContains(t, "xsub --wait", stdout, "作业提交成功，作业ID: 1")
+	assertContains(t, "xsub --wait", stderr, "作业 1: EXIT")
+
+	c.mustRun("apiserver", "use", done.URL())
+	stdout, stderr, err = c.run("xsub", "--wait", "-c", "sleep 1", "sleep", "1")
+	if err != nil {
+		t.Fatalf("xsub --wait: %v", err)
+	}
+	assertContains(t, "xsub --wait", stdout, "作业提交成功，作业ID: 1")
+	assertContains(t, "xsub --wait", stderr, "作业 1: DONE")
+}
+
+// syncBuffer 可以在命令运行时并发读取的输出缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestMockServer(t *testing.T) {
+	c := newTestCLI(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var stdout syncBuffer
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.execute(ctx, strings.NewReader(""), &stdout, &stdout,
+			"mock-server", "--listen", "127.0.0.1:0", "--http", "--hosts", "3", "--user", "alice", "--password", "secret")
+	}()
+
+	started := regexp.MustCompile(`XCE 模拟服务器已启动: (http://\S+)`)
+	var url string
+	for deadline := time.Now().Add(5 * time.Second); url == ""; time.Sleep(10 * time.Millisecond) {
+		if m := started.FindStringSubmatch(stdout.String()); m != nil {
+			url = m[1]
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("mock-server did not start:\n%s", stdout.String())
+		}
+	}
+	assertContains(t, "mock-server", stdout.String(), "主机: 3 台")
+
+	// 通过命令行使用模拟服务器
+	assertContains(t, "logon", c.mustRun("apiserver", "logon", "-n", "alice", "-p", "secret", "--url", url), "登录成功")
+	assertContains(t, "xsub", c.mustRun("xsub", "-q", "normal", "-c", "sleep 600", "sleep", "600"), "作业提交成功，作业ID: 1")
+	assertContains(t, "bjobs", c.mustRun("bjobs"), "sleep 600")
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("mock-server: %v", err)
+	}
+	assertContains(t, "mock-server", stdout.String(), "模拟服务器已停止")
+}
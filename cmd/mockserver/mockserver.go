@@ -0,0 +1,246 @@
+package mockserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+	"k8s.io/klog/v2"
+)
+
+// mockServerOptions 定义模拟服务器命令参数
+type mockServerOptions struct {
+	listen   string
+	hosts    int
+	seed     uint64
+	plain    bool
+	certFile string
+	keyFile  string
+	user     string
+	password string
+	failRate float64
+	minRun   time.Duration
+	maxRun   time.Duration
+	apis     []string
+	legacy   bool
+}
+
+// NewMockServerCmd 创建本地 XCE 模拟服务器命令
+func NewMockServerCmd() *cobra.Command {
+	opts := mockServerOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "mock-server",
+		Short: "运行本地 XCE 模拟服务器",
+		Long: `在本地运行 XCE 模拟服务器，用于新用户培训和离线开发脚本，提供与 APIserver 相同的 /xce/v1 和 /xce/v2 接口。
+模拟调度器将排队的作业按作业槽派发到模拟主机上运行，运行到请求的时长后结束并释放作业槽。
+作业的作业槽数和运行时长由资源需求中的 slots= 和 duration= 指定，例如 -R "slots=4 duration=10m"；
+未指定运行时长时，sleep N 命令运行 N 秒，其他命令在 --min-run 与 --max-run 之间随机运行。
+相同的 --seed 生成相同的主机和作业运行结果。未设置 --user 时任意用户名和非空密码均可登录。
+--api-versions 限制提供的 API 版本，--legacy 模拟没有版本接口的旧服务器，用于验证 cli 的版本协商。
+示例:
+  cli mock-server --listen :8443 --hosts 50 --seed 1
+  cli mock-server --listen 127.0.0.1:8080 --http --user alice --password secret
+  cli mock-server --listen 127.0.0.1:8444 --api-versions v1`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMockServer(cmd.Context(), cmd.OutOrStdout(), opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.listen, "listen", ":8443", "监听地址")
+	flags.IntVar(&opts.hosts, "hosts", 50, "模拟主机的数量")
+	flags.Uint64Var(&opts.seed, "seed", 1, "随机数种子")
+	flags.BoolVar(&opts.plain, "http", false, "使用 HTTP 而不是 HTTPS")
+	flags.StringVar(&opts.certFile, "cert", "", "服务器证书 (PEM)，默认生成自签名证书")
+	flags.StringVar(&opts.keyFile, "key", "", "服务器私钥 (PEM)")
+	flags.StringVar(&opts.user, "user", "", "允许登录的用户名")
+	flags.StringVar(&opts.password, "password", "", "允许登录的用户的密码")
+	flags.Float64Var(&opts.failRate, "fail-rate", 0, "作业以非 0 退出码结束的概率 (0-1)")
+	flags.DurationVar(&opts.minRun, "min-run", 30*time.Second, "未指定运行时长的作业的最短运行时间")
+	flags.DurationVar(&opts.maxRun, "max-run", 5*time.Minute, "未指定运行时长的作业的最长运行时间")
+	flags.StringSliceVar(&opts.apis, "api-versions", xcetest.DefaultVersion.APIVersions, "提供的 API 版本")
+	flags.BoolVar(&opts.legacy, "legacy", false, "模拟没有版本接口、只提供 /xce/v1 接口的旧服务器")
+	cmd.MarkFlagsRequiredTogether("cert", "key")
+	cmd.MarkFlagsMutuallyExclusive("api-versions", "legacy")
+	cmd.MarkFlagsRequiredTogether("user", "password")
+
+	return cmd
+}
+
+func runMockServer(ctx context.Context, out io.Writer, opts mockServerOptions) error {
+	if opts.hosts <= 0 {
+		return fmt.Errorf("主机数量必须大于 0")
+	}
+	if opts.failRate < 0 || opts.failRate > 1 {
+		return fmt.Errorf("无效的失败概率: %v，必须在 0 到 1 之间", opts.failRate)
+	}
+	if opts.minRun <= 0 || opts.maxRun < opts.minRun {
+		return fmt.Errorf("无效的运行时间范围: %s - %s", opts.minRun, opts.maxRun)
+	}
+	if len(opts.apis) == 0 {
+		return fmt.Errorf("至少需要提供一个 API 版本")
+	}
+
+	// 主机和调度使用不同的随机数序列，调整调度参数不影响生成的主机
+	hosts := xcetest.GenerateHosts(opts.hosts, rand.New(rand.NewPCG(opts.seed, 1)))
+	scheduler := xcetest.NewSlotScheduler(hosts, rand.New(rand.NewPCG(opts.seed, 2)))
+	scheduler.MinRun = opts.minRun
+	scheduler.MaxRun = opts.maxRun
+	scheduler.FailRate = opts.failRate
+	priorities := make(map[string]int)
+	for _, q := range xcetest.DefaultQueues {
+		priorities[q.Name] = q.Priority
+	}
+	scheduler.Priority = func(queue string) int { return priorities[queue] }
+
+	serverOpts := []xcetest.Option{
+		xcetest.WithClock(xcetest.RealClock{}),
+		xcetest.WithScheduler(scheduler),
+		xcetest.WithHosts(hosts...),
+		xcetest.WithQueues(xcetest.DefaultQueues...),
+	}
+	if opts.user != "" {
+		serverOpts = append(serverOpts, xcetest.WithUser(opts.user, opts.password))
+	}
+	if opts.legacy {
+		serverOpts = append(serverOpts, xcetest.WithLegacyAPI())
+	} else {
+		version := xcetest.DefaultVersion
+		version.APIVersions = opts.apis
+		serverOpts = append(serverOpts, xcetest.WithVersion(version))
+	}
+	mock := xcetest.New(serverOpts...)
+
+	ln, err := net.Listen("tcp", opts.listen)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %v", opts.listen, err)
+	}
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	if listenHost, _, _ := net.SplitHostPort(opts.listen); listenHost != "" {
+		host = listenHost
+	} else {
+		host = "localhost"
+	}
+
+	server := &http.Server{Handler: logRequests(mock)}
+	scheme := "http"
+	var fingerprint string
+	if !opts.plain {
+		serverCert, err := loadCert(opts, host)
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		server.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientAuth:   tls.RequestClientCert,
+		}
+		ln = tls.NewListener(ln, server.TLSConfig)
+		scheme = "https"
+		fingerprint = cert.Fingerprint(serverCert.Certificate[0])
+	}
+
+	url := fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, port))
+	printBanner(out, url, fingerprint, hosts, opts)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("模拟服务器异常退出: %v", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("停止模拟服务器失败: %v", err)
+	}
+	fmt.Fprintln(out, "模拟服务器已停止")
+	return nil
+}
+
+// loadCert 加载指定的服务器证书，未指定时为 host、localhost 和本机主机名生成自签名证书
+func loadCert(opts mockServerOptions, host string) (tls.Certificate, error) {
+	if opts.certFile != "" {
+		serverCert, err := tls.LoadX509KeyPair(opts.certFile, opts.keyFile)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("加载服务器证书失败: %v", err)
+		}
+		return serverCert, nil
+	}
+
+	names := []string{"localhost", "127.0.0.1", "::1"}
+	if hostname, err := os.Hostname(); err == nil {
+		names = append(names, hostname)
+	}
+	if host != "localhost" {
+		names = append(names, host)
+	}
+	serverCert, err := xcetest.SelfSignedCert(names...)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("生成服务器证书失败: %v", err)
+	}
+	return serverCert, nil
+}
+
+// printBanner 输出服务器地址、集群规模和登录方法
+func printBanner(out io.Writer, url, fingerprint string, hosts []client.Host, opts mockServerOptions) {
+	slots := 0
+	for _, h := range hosts {
+		slots += h.MaxCpus
+	}
+	queues := make([]string, 0, len(xcetest.DefaultQueues))
+	for _, q := range xcetest.DefaultQueues {
+		queues = append(queues, q.Name)
+	}
+
+	fmt.Fprintf(out, "XCE 模拟服务器已启动: %s\n", url)
+	fmt.Fprintf(out, "  主机: %d 台，共 %d 个作业槽\n", len(hosts), slots)
+	fmt.Fprintf(out, "  队列: %s\n", strings.Join(queues, ", "))
+	if opts.legacy {
+		fmt.Fprintln(out, "  API: v1 (旧服务器，没有版本接口)")
+	} else {
+		fmt.Fprintf(out, "  API: %s\n", strings.Join(opts.apis, ", "))
+	}
+	if fingerprint != "" {
+		fmt.Fprintf(out, "  证书指纹: %s\n", fingerprint)
+	}
+
+	user, password := opts.user, opts.password
+	if user == "" {
+		user, password = "user1", "any"
+	}
+	fmt.Fprintln(out, "登录示例:")
+	logon := fmt.Sprintf("  cli apiserver logon --url %s -n %s -p %s", url, user, password)
+	if fingerprint != "" {
+		logon += " --accept-fingerprint " + fingerprint
+	}
+	fmt.Fprintln(out, logon)
+	fmt.Fprintln(out, "按 Ctrl-C 停止")
+}
+
+// logRequests 在 -v=2 及以上时输出收到的请求
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		klog.V(2).Infof("%s %s", r.Method, r.URL)
+		next.ServeHTTP(w, r)
+	})
+}
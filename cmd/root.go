@@ -1,66 +1,162 @@
 package cmd
 
 import (
+	"context"
+	"flag"
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/xx/cmd/apiserver"
-	"github.com/xx/cmd/bhosts"
-	"github.com/xx/cmd/bjobs"
-	setConfig "github.com/xx/cmd/config"
-	"github.com/xx/cmd/xsub"
-	"github.com/xx/pkg/config"
+	"github.com/zhengyansheng/xcli/cmd/apiserver"
+	"github.com/zhengyansheng/xcli/cmd/bhosts"
+	"github.com/zhengyansheng/xcli/cmd/bjobs"
+	"github.com/zhengyansheng/xcli/cmd/bwait"
+	"github.com/zhengyansheng/xcli/cmd/cert"
+	setConfig "github.com/zhengyansheng/xcli/cmd/config"
+	"github.com/zhengyansheng/xcli/cmd/mockserver"
+	"github.com/zhengyansheng/xcli/cmd/xsub"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/pkg/config"
+	"k8s.io/klog/v2"
 )
 
-var (
-	rootCmd = &cobra.Command{
-		Use:   "cli",
-		Short: "CLI tool for APIserver operations",
-	}
-	configManager *config.ConfigManager
-)
-
-// Execute 执行根命令
-func Execute(cm *config.ConfigManager) error {
+// Execute 执行根命令，ctx 被取消 (如按下 Ctrl-C) 时正在进行的请求会被中止
+func Execute(ctx context.Context, cm *config.ConfigManager) error {
 	if cm == nil {
 		return fmt.Errorf("配置管理器未初始化")
 	}
-	configManager = cm
 	cobra.EnableCommandSorting = false
+	rootCmd := newRootCmd(cm)
+
+	err := rootCmd.ExecuteContext(ctx)
+
+	// 命令失败时同样保存请求记录，便于排查问题
+	if traceFile, _ := rootCmd.PersistentFlags().GetString("trace-file"); traceFile != "" {
+		if traceErr := client.WriteHARTrace(traceFile); traceErr != nil {
+			klog.Error(traceErr)
+		}
+	}
+	return err
+}
+
+// newRootCmd 创建包含全局参数和所有子命令的根命令，每次调用返回新的命令树
+func newRootCmd(configManager *config.ConfigManager) *cobra.Command {
+	var configPath, contextName, traceFile string
+
+	rootCmd := &cobra.Command{
+		Use:   "cli",
+		Short: "CLI tool for APIserver operations",
+		Long: `CLI tool for APIserver operations.
+
+配置文件位置的优先级: --config 参数 > CLI_CONFIG 环境变量 > $XDG_CONFIG_HOME/cli/config.json > ~/.cli/config.json，
+用户配置叠加在系统级配置 /etc/cli/config.json (可通过 CLI_SYSTEM_CONFIG 修改) 之上，
+服务器证书保存在配置文件所在目录的 certs 子目录中。
+以下环境变量可以覆盖配置文件和上下文中的设置，命令行参数的优先级最高:
+  CLI_CONTEXT   使用的上下文
+  CLI_SERVER    使用的 APIserver 名称或 URL
+  CLI_TOKEN     访问 APIserver 使用的 token
+  CLI_ACCOUNT   默认账号
+  CLI_QUEUE     默认队列
+  CLI_RESREQ    默认资源需求
+  CLI_OUTPUT    默认输出格式
+
+调试:
+  -v=6  输出每个请求的方法、地址、状态码和耗时
+  -v=7  同时输出 DNS、建立连接、TLS 握手和首字节的耗时
+  -v=8  同时输出请求头和响应头
+  -v=9  同时输出请求和响应的内容
+  --trace-file  将本次命令的所有请求以 HAR 格式保存到文件，可以附在问题报告中
+Authorization 头、密码和 token 在日志和 HAR 文件中均已脱敏。
+
+退出码:
+  0  成功
+  1  其他错误
+  3  未登录或登录已过期
+  4  没有权限
+  5  资源不存在
+  6  资源冲突
+  7  服务器不支持请求的功能或 API 版本
+  8  bwait 等待的条件已不可能满足
+  124  等待超时
+  130  被 Ctrl-C 中断`,
+	}
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
+	// 全局参数，--config 优先于 CLI_CONFIG 环境变量，
+	// --context 优先于 CLI_CONTEXT 环境变量和配置文件中的当前上下文
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "配置文件路径")
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "本次命令使用的上下文")
+	// --request-timeout 由各命令通过 client.CommandOptions 在创建客户端时读取
+	rootCmd.PersistentFlags().Duration(client.RequestTimeoutFlag, client.DefaultRequestTimeout, "单次请求 APIserver 的超时时间，0 表示不限制")
+	rootCmd.PersistentFlags().StringVar(&traceFile, "trace-file", "", "将所有请求以 HAR 格式保存到该文件")
+	addKlogFlags(rootCmd)
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if traceFile != "" {
+			client.StartHARTrace()
+		}
+		if configPath != "" {
+			configManager.SetConfigPath(configPath)
+		}
+		configManager.SetContextOverride(contextName)
+	}
+
 	// 初始化子命令
-	initCommands()
+	initCommands(rootCmd, configManager)
+	return rootCmd
+}
 
-	return rootCmd.Execute()
+// addKlogFlags 将 klog 的日志级别参数添加到根命令，-v 同时支持 --v
+func addKlogFlags(cmd *cobra.Command) {
+	klogFlags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(klogFlags)
+	usages := map[string]string{
+		"v":       "日志级别，6 及以上时输出 HTTP 请求的详细信息",
+		"vmodule": "按文件设置日志级别，如 request=6,trace=9",
+	}
+	for name, usage := range usages {
+		f := klogFlags.Lookup(name)
+		f.Usage = usage
+		cmd.PersistentFlags().AddGoFlag(f)
+	}
 }
 
 // initCommands 初始化所有子命令
-func initCommands() {
+func initCommands(rootCmd *cobra.Command, configManager *config.ConfigManager) {
 
 	// 添加子命令
-	rootCmd.AddCommand(getAPIServerCmd())
-	rootCmd.AddCommand(getConfigCmd())
+	rootCmd.AddCommand(getAPIServerCmd(configManager))
+	rootCmd.AddCommand(getConfigCmd(configManager))
+	rootCmd.AddCommand(getCertCmd(configManager))
 	rootCmd.AddCommand(bjobs.NewBJobsCmd(configManager))
 	rootCmd.AddCommand(bhosts.NewBHostsCmd(configManager))
 	rootCmd.AddCommand(xsub.NewXSubCmd(configManager))
+	rootCmd.AddCommand(bwait.NewBWaitCmd(configManager))
+	rootCmd.AddCommand(mockserver.NewMockServerCmd())
 
 }
 
 // getConfigCmd 返回配置子命令
-func getConfigCmd() *cobra.Command {
+func getConfigCmd(configManager *config.ConfigManager) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "配置管理命令",
 	}
 
 	// 添加配置相关子命令
+	cmd.AddCommand(setConfig.NewViewCmd(configManager))
+	cmd.AddCommand(setConfig.NewGetCmd(configManager))
 	cmd.AddCommand(setConfig.NewSetCmd(configManager))
+	cmd.AddCommand(setConfig.NewUnsetCmd(configManager))
+	cmd.AddCommand(setConfig.NewSetContextCmd(configManager))
+	cmd.AddCommand(setConfig.NewUseContextCmd(configManager))
+	cmd.AddCommand(setConfig.NewGetContextsCmd(configManager))
+	cmd.AddCommand(setConfig.NewDoctorCmd(configManager))
+	cmd.AddCommand(setConfig.NewExportCmd(configManager))
+	cmd.AddCommand(setConfig.NewImportCmd(configManager))
 	return cmd
 }
 
 // getAPIServerCmd 返回 apiserver 子命令
-func getAPIServerCmd() *cobra.Command {
+func getAPIServerCmd(configManager *config.ConfigManager) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "apiserver",
 		Short: "APIserver 相关操作",
@@ -73,6 +169,24 @@ func getAPIServerCmd() *cobra.Command {
 	cmd.AddCommand(apiserver.NewLogonCmd(configManager))
 	cmd.AddCommand(apiserver.NewLogoutCmd(configManager))
 	cmd.AddCommand(apiserver.NewListCmd(configManager))
+	cmd.AddCommand(apiserver.NewAddCmd(configManager))
+	cmd.AddCommand(apiserver.NewUseCmd(configManager))
+	cmd.AddCommand(apiserver.NewRenameCmd(configManager))
+	cmd.AddCommand(apiserver.NewRemoveCmd(configManager))
+
+	return cmd
+}
+
+// getCertCmd 返回证书管理子命令
+func getCertCmd(configManager *config.ConfigManager) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cert",
+		Short: "服务器证书管理",
+	}
 
+	cmd.AddCommand(cert.NewShowCmd(configManager))
+	cmd.AddCommand(cert.NewVerifyCmd(configManager))
+	cmd.AddCommand(cert.NewRefreshCmd(configManager))
+	cmd.AddCommand(cert.NewExpiryCmd(configManager))
 	return cmd
 }
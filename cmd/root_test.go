@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// testCLI 在临时目录中的配置文件上执行命令，每次执行都使用新的命令树和配置管理器，相当于单独运行一次 cli
+type testCLI struct {
+	t      *testing.T
+	config string
+}
+
+// newTestCLI 创建使用临时配置文件的 testCLI，不读取系统级配置和环境变量中的覆盖
+func newTestCLI(t *testing.T) *testCLI {
+	t.Helper()
+	dir := t.TempDir()
+	c := &testCLI{t: t, config: filepath.Join(dir, "config.json")}
+	t.Setenv(config.ConfigEnv, c.config)
+	t.Setenv(config.SystemConfigEnv, filepath.Join(dir, "system.json"))
+	for _, env := range []string{config.ContextEnv, config.ServerEnv, config.TokenEnv,
+		config.AccountEnv, config.QueueEnv, config.ResReqEnv, config.OutputEnv} {
+		t.Setenv(env, "")
+	}
+	return c
+}
+
+// run 执行命令，返回标准输出、标准错误和命令返回的错误
+func (c *testCLI) run(args ...string) (string, string, error) {
+	return c.runWithInput("", args...)
+}
+
+// runWithInput 以 in 作为标准输入执行命令
+func (c *testCLI) runWithInput(in string, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	err := c.execute(context.Background(), strings.NewReader(in), &stdout, &stderr, args...)
+	return stdout.String(), stderr.String(), err
+}
+
+// execute 使用指定的 ctx 和标准输入输出执行命令
+func (c *testCLI) execute(ctx context.Context, in io.Reader, stdout, stderr io.Writer, args ...string) error {
+	root := newRootCmd(config.NewConfigManager())
+	root.SilenceUsage = true
+	root.SetIn(in)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+	root.SetArgs(args)
+	return root.ExecuteContext(ctx)
+}
+
+// mustRun 执行必须成功的命令，返回标准输出
+func (c *testCLI) mustRun(args ...string) string {
+	c.t.Helper()
+	stdout, stderr, err := c.run(args...)
+	if err != nil {
+		c.t.Fatalf("cli %s: %v\nstdout:\n%s\nstderr:\n%s", strings.Join(args, " "), err, stdout, stderr)
+	}
+	return stdout
+}
+
+// logon 以 alice 的身份登录假服务器，预先确认服务器证书的指纹
+func (c *testCLI) logon(s *xcetest.Server) string {
+	c.t.Helper()
+	return c.mustRun("apiserver", "logon", "-n", "alice", "-p", "secret", "--url", s.URL(),
+		"--accept-fingerprint", fingerprint(c.t, s))
+}
+
+// loadConfig 读取命令保存的配置
+func (c *testCLI) loadConfig() *config.Config {
+	c.t.Helper()
+	cm := config.NewConfigManager()
+	cm.SetConfigPath(c.config)
+	cfg, err := cm.GetConfig()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return cfg
+}
+
+// newTestServer 创建只允许 alice 使用密码 secret 登录的 HTTPS 假服务器
+func newTestServer(t *testing.T, opts ...xcetest.Option) *xcetest.Server {
+	t.Helper()
+	s := xcetest.NewTLSServer(append([]xcetest.Option{xcetest.WithUser("alice", "secret")}, opts...)...)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// fingerprint 返回假服务器证书的 SHA-256 指纹
+func fingerprint(t *testing.T, s *xcetest.Server) string {
+	t.Helper()
+	block, _ := pem.Decode(s.CertPEM())
+	if block == nil {
+		t.Fatal("server has no certificate")
+	}
+	return cert.Fingerprint(block.Bytes)
+}
+
+// assertContains 检查输出中包含所有 want
+func assertContains(t *testing.T, name, output string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(output, w) {
+			t.Errorf("%s output does not contain %q:\n%s", name, w, output)
+		}
+	}
+}
@@ -1,72 +1,100 @@
 package xsub
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/xx/internal/client"
-	"github.com/xx/pkg/config"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/wait"
+	"github.com/zhengyansheng/xcli/pkg/config"
 )
 
 // NewXSubCmd 创建作业提交命令
 func NewXSubCmd(configManager *config.ConfigManager) *cobra.Command {
-	var (
-		queue   string
-		resReq  string
-		command string
-	)
+	var opts xsubOptions
 
 	cmd := &cobra.Command{
 		Use:   "xsub",
 		Short: "提交作业到 APIserver",
 		Long: `提交作业到 APIserver。
 示例: 
-  cli xsub -q q1 -R "select(!mg)" sleep 10`,
+  cli xsub -q q1 -R "select(!mg)" sleep 10
+  cli xsub --wait --wait-timeout 2h ./build.sh   # 提交后等待作业结束
+未指定队列、资源需求和输出格式时使用当前上下文中的默认值。
+使用 --wait 时提交后等待作业结束并将状态变化输出到标准错误，作业以 EXIT 结束时退出码为 8，超时时退出码为 124。`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return fmt.Errorf("请提供要执行的命令")
 			}
 			// 将参数组合成命令字符串
 			// command := strings.Join(args, " ")
-			return runXSub(configManager, queue, resReq, command)
+			return runXSub(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), configManager, opts, client.CommandOptions(cmd))
 		},
 	}
 
 	// 添加命令行参数
 	flags := cmd.Flags()
-	flags.StringVarP(&queue, "queue", "q", "", "指定作业队列")
-	flags.StringVarP(&resReq, "resreq", "R", "", "指定资源需求")
-	flags.StringVarP(&command, "command", "c", "", "指定要执行的命令")
+	flags.StringVarP(&opts.queue, "queue", "q", "", "指定作业队列")
+	flags.StringVarP(&opts.resReq, "resreq", "R", "", "指定资源需求")
+	flags.StringVarP(&opts.command, "command", "c", "", "指定要执行的命令")
+	flags.StringVarP(&opts.output, "output", "o", "", "输出格式 (table/json)")
+	flags.BoolVar(&opts.wait, "wait", false, "提交后等待作业结束，相当于 bwait -w \"done(作业ID)\"")
+	flags.DurationVar(&opts.waitTimeout, "wait-timeout", 0, "使用 --wait 时的最长等待时间，0 表示不限制")
 	// 设置必需参数
 	//cmd.MarkFlagRequired("queue")
 
 	return cmd
 }
 
-func runXSub(cm *config.ConfigManager, queue, resReq, command string) error {
-	// 获取配置
-	cfg, err := cm.GetConfig()
-	if err != nil {
-		return fmt.Errorf("获取配置失败: %v", err)
+// xsubOptions 定义作业提交命令参数
+type xsubOptions struct {
+	queue   string
+	resReq  string
+	command string
+	output  string
+	// wait 提交后等待作业结束
+	wait        bool
+	waitTimeout time.Duration
+}
+
+// applyContext 未指定的参数使用上下文中的默认值
+func (o *xsubOptions) applyContext(ctx *config.Context) {
+	if ctx == nil {
+		return
+	}
+	if o.queue == "" {
+		o.queue = ctx.Queue
+	}
+	if o.resReq == "" {
+		o.resReq = ctx.ResReq
 	}
+	if o.output == "" {
+		o.output = ctx.Output
+	}
+}
 
-	// 检查是否有默认服务器
-	if cfg.DefaultAPIServer == "" {
-		return fmt.Errorf("未设置默认 APIserver，请先设置默认服务器或指定服务器")
+func runXSub(ctx context.Context, out, errOut io.Writer, cm *config.ConfigManager, opts xsubOptions, clientOpts []client.Option) error {
+	// 获取当前服务器信息，使用上下文时为上下文中的服务器
+	serverInfo, err := cm.CurrentServer()
+	if err != nil {
+		return err
 	}
 
-	// 获取服务器信息
-	var serverInfo *config.APIServerInfo
-	for _, server := range cfg.APIServerInfo {
-		if server.URL == cfg.DefaultAPIServer {
-			serverInfo = &server
-			break
-		}
+	cliContext, err := cm.CurrentContext()
+	if err != nil {
+		return err
 	}
-	// fmt.Println(serverInfo)
+	opts.applyContext(cliContext)
 
-	if serverInfo == nil {
-		return fmt.Errorf("未找到默认服务器信息")
+	if opts.output != "" && opts.output != "table" && opts.output != "json" {
+		return fmt.Errorf("无效的输出格式: %s，必须是 table 或 json", opts.output)
+	}
+	if opts.waitTimeout < 0 {
+		return fmt.Errorf("--wait-timeout 不能为负数")
 	}
 
 	if serverInfo.Token == "" {
@@ -75,22 +103,40 @@ func runXSub(cm *config.ConfigManager, queue, resReq, command string) error {
 
 	// 创建作业提交请求
 	jobReq := &client.JobSubmitRequest{
-		Queue:   queue,
-		ResReq:  resReq,
-		Command: command,
+		Queue:   opts.queue,
+		ResReq:  opts.resReq,
+		Command: opts.command,
 	}
 	// fmt.Println(jobReq)
 	// fmt.Println(serverInfo.Token)
 	// fmt.Println(cfg.DefaultAPIServer)
 
 	// 创建 API 客户端并提交作业
-	apiClient := client.NewAPIClient(cfg.DefaultAPIServer)
-	apiClient.SetRootCAs(cfg.CACert)
-	jobResp, err := apiClient.SubmitJob(serverInfo.Token, jobReq)
+	apiClient, err := client.NewServerClient(serverInfo, clientOpts...)
+	if err != nil {
+		return err
+	}
+	jobResp, err := apiClient.SubmitJob(ctx, serverInfo.Token, jobReq)
 	if err != nil {
-		return fmt.Errorf("提交作业失败: %v", err)
+		return fmt.Errorf("提交作业失败: %w", err)
 	}
 
-	fmt.Printf("作业提交成功，作业ID: %d\n%s\n", jobResp.Data.JobID, jobResp.Data.Message)
-	return nil
+	switch opts.output {
+	case "json":
+		data, err := json.MarshalIndent(jobResp.Data, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(data))
+	default:
+		fmt.Fprintf(out, "作业提交成功，作业ID: %d\n%s\n", jobResp.Data.JobID, jobResp.Data.Message)
+	}
+
+	if !opts.wait {
+		return nil
+	}
+	return wait.Until(ctx, wait.Done(jobResp.Data.JobID), wait.JobStatus(apiClient, serverInfo.Token), wait.Options{
+		Timeout:  opts.waitTimeout,
+		OnChange: wait.StatusPrinter(errOut),
+	})
 }
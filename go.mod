@@ -1,17 +1,20 @@
-module github.com/xx
+module github.com/zhengyansheng/xcli
 
 go 1.23.4
 
 require (
+	github.com/go-logr/logr v1.4.1
 	github.com/spf13/cobra v1.8.1
-	k8s.io/klog v1.0.0
+	golang.org/x/crypto v0.31.0
+	golang.org/x/net v0.33.0
+	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/klog/v2 v2.130.1
 	resty.dev/v3 v3.0.0-beta.1
+	software.sslmate.com/src/go-pkcs12 v0.7.3
 )
 
 require (
-	github.com/go-logr/logr v1.4.1 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
 )
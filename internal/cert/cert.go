@@ -1,55 +1,126 @@
 package cert
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/pem"
 	"fmt"
-	"log"
+	"io"
 	"os"
-	"os/user"
-	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/transport"
 )
 
-func GeneratorCert(serverAddress, configPath string) error {
-	serverAddress, err := extractHostAndPort(serverAddress)
+// TrustOptions 定义首次使用即信任 (TOFU) 的确认选项
+type TrustOptions struct {
+	// Pinned 已固定的证书指纹，为空表示首次连接该服务器
+	Pinned string
+	// AcceptFingerprint 预先确认的证书指纹，非空时不再交互询问
+	AcceptFingerprint string
+	// AllowChange 证书与已固定的指纹不一致时询问是否更新，而不是直接失败
+	AllowChange bool
+	// In 交互确认时读取用户输入
+	In io.Reader
+	// Out 输出证书信息和提示
+	Out io.Writer
+	// ServerName TLS 握手时使用的 SNI 名称，为空时使用 URL 中的主机名
+	ServerName string
+	// Timeout 连接超时时间，为 0 时使用 Transport.ConnectTimeout，均未设置时使用 DefaultDialTimeout
+	Timeout time.Duration
+	// Transport 服务器的代理、静态解析和 Unix 域套接字等网络选项
+	Transport transport.Options
+}
+
+// GeneratorCert 获取服务器证书，校验或经用户确认后保存到 configPath，返回证书指纹
+func GeneratorCert(ctx context.Context, serverURL, configPath string, opts TrustOptions) (string, error) {
+	if !strings.HasPrefix(serverURL, "https://") {
+		return "", fmt.Errorf("%s 不是 HTTPS 地址，无需获取证书", serverURL)
+	}
+	serverAddress, err := extractHostAndPort(serverURL)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if opts.Out == nil {
+		opts.Out = os.Stdout
+	}
+	if opts.In == nil {
+		opts.In = os.Stdin
+	}
+
+	serverCert, err := fetchServerCert(ctx, serverURL, opts)
+	if err != nil {
+		return "", err
 	}
-	// The HTTPS server's address (replace with the server you want to check)
-	// serverAddress := "47.96.96.106:8443"
+	fingerprint := Fingerprint(serverCert.Raw)
 
-	// Create a tls.Config with InsecureSkipVerify set to true
+	switch {
+	case opts.Pinned != "" && NormalizeFingerprint(opts.Pinned) == fingerprint:
+		// 与已固定的指纹一致，直接信任
+	case opts.AcceptFingerprint != "":
+		if NormalizeFingerprint(opts.AcceptFingerprint) != fingerprint {
+			return "", fmt.Errorf("%w\n  指定的指纹: %s\n  服务器指纹: %s",
+				ErrFingerprintMismatch, NormalizeFingerprint(opts.AcceptFingerprint), fingerprint)
+		}
+	case opts.Pinned != "" && opts.AllowChange:
+		ok, err := confirmChange(opts.In, opts.Out, serverAddress, opts.Pinned, serverCert)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", ErrNotTrusted
+		}
+	case opts.Pinned != "":
+		if err := VerifyPin(serverCert.Raw, opts.Pinned); err != nil {
+			return "", fmt.Errorf("%v\n如确认服务器证书已正常轮换，请执行 cli cert refresh 或使用 --accept-fingerprint 指定新指纹重新登录", err)
+		}
+	default:
+		ok, err := confirmTrust(opts.In, opts.Out, serverAddress, serverCert)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", ErrNotTrusted
+		}
+	}
+
+	if err := saveCert(configPath, serverCert); err != nil {
+		return "", err
+	}
+	return fingerprint, nil
+}
+
+// fetchServerCert 连接服务器并返回其证书，不校验证书链
+func fetchServerCert(ctx context.Context, serverURL string, opts TrustOptions) (*x509.Certificate, error) {
+	// 仅获取证书，由调用方通过指纹确认是否可信
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: true,
+		ServerName:         opts.ServerName,
 	}
 
-	// Dial the server using TLS with the custom tls.Config
-	conn, err := tls.Dial("tcp", serverAddress, tlsConfig)
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = opts.Transport.ConnectTimeout
+	}
+	conn, err := dialTLS(ctx, serverURL, tlsConfig, timeout, opts.Transport)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer conn.Close()
 
-	// Retrieve the certificate chain
+	// 服务器证书为证书链中的第一个
 	certs := conn.ConnectionState().PeerCertificates
-
-	// Print the certificate chain
-	for i, cert := range certs {
-		fmt.Printf("Certificate %d:\n", i)
-		fmt.Printf("  Subject: %s\n", cert.Subject)
-		fmt.Printf("  Issuer: %s\n", cert.Issuer)
-		fmt.Printf("  Not Before: %s\n", cert.NotBefore)
-		fmt.Printf("  Not After: %s\n", cert.NotAfter)
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("服务器 %s 未提供证书", serverURL)
 	}
+	return certs[0], nil
+}
 
-	// The root CA is usually the last certificate in the chain
-	rootCert := certs[len(certs)-1]
-	fmt.Println("\nRoot CA Certificate:")
-	fmt.Printf("  Subject: %s\n", rootCert.Subject)
-	fmt.Printf("  Issuer: %s\n", rootCert.Issuer)
-	fmt.Printf("  Not Before: %s\n", rootCert.NotBefore)
-	fmt.Printf("  Not After: %s\n", rootCert.NotAfter)
-
+// saveCert 以 PEM 格式保存证书
+func saveCert(configPath string, cert *x509.Certificate) error {
 	// Save the server certificate to a file
 	certOut, err := os.Create(configPath)
 	if err != nil {
@@ -57,23 +128,23 @@ func GeneratorCert(serverAddress, configPath string) error {
 	}
 	defer certOut.Close()
 
-	pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw})
-	fmt.Println("Server certificate saved to server.crt")
+	if err := pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+		return fmt.Errorf("保存证书失败: %v", err)
+	}
 	return nil
 }
 
-func GetCertPath() (string, error) {
-	usr, err := user.Current()
+// GetCertPath 返回服务器证书在 certDir 目录下的保存路径，每个服务器单独一个文件
+func GetCertPath(certDir, serverURL string) (string, error) {
+	hostPort, err := extractHostAndPort(serverURL)
 	if err != nil {
 		return "", err
 	}
+	fileName := strings.NewReplacer(":", "_", "/", "_", "[", "", "]", "").Replace(hostPort) + ".crt"
 
-	configDir := path.Join(usr.HomeDir, ".cli")
-	configPath := path.Join(configDir, "server.crt")
-
-	// 确保配置目录存在
-	if err := os.MkdirAll(configDir, 0700); err != nil {
+	// 确保证书目录存在
+	if err := os.MkdirAll(certDir, 0700); err != nil {
 		return "", err
 	}
-	return configPath, nil
+	return filepath.Join(certDir, fileName), nil
 }
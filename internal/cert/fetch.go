@@ -0,0 +1,156 @@
+package cert
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/transport"
+	"golang.org/x/net/proxy"
+)
+
+// DefaultDialTimeout 连接服务器获取证书的默认超时时间
+const DefaultDialTimeout = 10 * time.Second
+
+// dialTLS 与服务器建立 TLS 连接，支持超时、取消、SNI 以及服务器或环境变量中配置的代理
+func dialTLS(ctx context.Context, serverURL string, tlsConfig *tls.Config, timeout time.Duration, netOpts transport.Options) (*tls.Conn, error) {
+	parsedURL, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析 URL 失败: %v", err)
+	}
+	serverAddress, err := extractHostAndPort(serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rawConn, err := dialTCP(ctx, parsedURL, serverAddress, netOpts)
+	if err != nil {
+		return nil, describeDialError(serverAddress, timeout, err)
+	}
+
+	tlsConfig = tlsConfig.Clone()
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = parsedURL.Hostname()
+	}
+	conn := tls.Client(rawConn, tlsConfig)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		rawConn.Close()
+		return nil, describeDialError(serverAddress, timeout, err)
+	}
+	return conn, nil
+}
+
+// dialTCP 建立到服务器的连接，配置了代理时通过代理建立隧道，静态解析和 Unix 域套接字由 netOpts 处理
+func dialTCP(ctx context.Context, serverURL *url.URL, serverAddress string, netOpts transport.Options) (net.Conn, error) {
+	proxyURL, err := netOpts.ProxyFunc()(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("代理配置无效: %v", err)
+	}
+	if proxyURL == nil {
+		return netOpts.DialContext(ctx, "tcp", serverAddress)
+	}
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		socksDialer, err := proxy.FromURL(proxyURL, netOpts)
+		if err != nil {
+			return nil, fmt.Errorf("代理配置无效: %v", err)
+		}
+		return socksDialer.(proxy.ContextDialer).DialContext(ctx, "tcp", serverAddress)
+	case "http", "https", "":
+		return dialHTTPProxy(ctx, netOpts, proxyURL, serverAddress)
+	default:
+		return nil, fmt.Errorf("不支持的代理协议: %s", proxyURL.Scheme)
+	}
+}
+
+// dialHTTPProxy 通过 HTTP CONNECT 代理建立到服务器的隧道
+func dialHTTPProxy(ctx context.Context, netOpts transport.Options, proxyURL *url.URL, serverAddress string) (net.Conn, error) {
+	proxyAddress := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddress = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+
+	conn, err := netOpts.DialContext(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("连接代理 %s 失败: %w", proxyAddress, err)
+	}
+	if proxyURL.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+	}
+
+	// 握手期间的超时和取消
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: serverAddress},
+		Host:   serverAddress,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("向代理 %s 发送 CONNECT 请求失败: %w", proxyAddress, err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("读取代理 %s 响应失败: %w", proxyAddress, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("代理 %s 拒绝建立隧道: %s", proxyAddress, resp.Status)
+	}
+	return conn, nil
+}
+
+// describeDialError 将连接错误转换为用户可以据此处理的提示
+func describeDialError(serverAddress string, timeout time.Duration, err error) error {
+	var (
+		dnsErr    *net.DNSError
+		headerErr tls.RecordHeaderError
+		netErr    net.Error
+	)
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return fmt.Errorf("无法解析主机名 %s，请检查 URL 是否正确或 DNS 配置: %v", dnsErr.Name, err)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return fmt.Errorf("连接 %s 被拒绝，请确认地址和端口正确且 APIserver 已启动: %v", serverAddress, err)
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return fmt.Errorf("连接 %s 超时 (%s)，请检查网络、防火墙或代理设置: %v", serverAddress, timeout, err)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("连接 %s 已取消", serverAddress)
+	case errors.As(err, &headerErr):
+		return fmt.Errorf("%s 未使用 TLS，请确认 URL 应使用 http:// 还是 https://", serverAddress)
+	case strings.Contains(err.Error(), "connection reset"):
+		return fmt.Errorf("连接 %s 被重置，服务器可能不支持 TLS 或被防火墙拦截: %v", serverAddress, err)
+	default:
+		return fmt.Errorf("连接 %s 失败: %w", serverAddress, err)
+	}
+}
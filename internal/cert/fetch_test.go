@@ -0,0 +1,108 @@
+package cert
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/transport"
+)
+
+// newConnectProxy 启动要求 Basic 认证的 HTTP CONNECT 代理，所有隧道都连接到 target，收到的请求通过 connects 返回
+func newConnectProxy(t *testing.T, target string) (*httptest.Server, <-chan *http.Request) {
+	t.Helper()
+	connects := make(chan *http.Request, 10)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connects <- r
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		// alice:secret 的 Basic 认证
+		if r.Header.Get("Proxy-Authorization") != "Basic YWxpY2U6c2VjcmV0" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		upstream, err := net.Dial("tcp", target)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		// 与常见的代理一样直接在连接上返回 200，不带响应内容
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go func() {
+			io.Copy(upstream, buf)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, connects
+}
+
+func TestDialTLSThroughProxy(t *testing.T) {
+	for _, env := range []string{"HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"} {
+		t.Setenv(env, "")
+	}
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	proxy, connects := newConnectProxy(t, server.Listener.Addr().String())
+	proxyAddress := strings.TrimPrefix(proxy.URL, "http://")
+
+	// 代理会跳过回环地址，服务器地址使用主机名
+	netOpts := transport.Options{Proxy: "http://alice:secret@" + proxyAddress}
+	conn, err := dialTLS(context.Background(), "https://xce.example.com:8443", &tls.Config{InsecureSkipVerify: true}, time.Second, netOpts)
+	if err != nil {
+		t.Fatalf("dialTLS through the proxy: %v", err)
+	}
+	defer conn.Close()
+	if got := conn.ConnectionState().PeerCertificates[0]; !got.Equal(server.Certificate()) {
+		t.Errorf("certificate = %s, want the server's certificate", got.Subject)
+	}
+	if r := <-connects; r.Host != "xce.example.com:8443" {
+		t.Errorf("CONNECT target = %s, want xce.example.com:8443", r.Host)
+	}
+
+	// 代理拒绝认证时提示代理拒绝建立隧道
+	netOpts.Proxy = "http://alice:wrong@" + proxyAddress
+	_, err = dialTLS(context.Background(), "https://xce.example.com:8443", &tls.Config{InsecureSkipVerify: true}, time.Second, netOpts)
+	if err == nil || !strings.Contains(err.Error(), "拒绝建立隧道") {
+		t.Errorf("dialTLS with wrong proxy credentials = %v, want a refused tunnel", err)
+	}
+}
+
+func TestDialTLSUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "xce.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets not available: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.Listener = listener
+	server.StartTLS()
+	defer server.Close()
+
+	// 设置 Unix 域套接字后不使用代理
+	netOpts := transport.Options{UnixSocket: socket, Proxy: "http://unreachable.invalid:3128"}
+	conn, err := dialTLS(context.Background(), "https://xce.example.com", &tls.Config{InsecureSkipVerify: true}, time.Second, netOpts)
+	if err != nil {
+		t.Fatalf("dialTLS over the unix socket: %v", err)
+	}
+	defer conn.Close()
+	if got := conn.ConnectionState().PeerCertificates[0]; !got.Equal(server.Certificate()) {
+		t.Errorf("certificate = %s, want the server's certificate", got.Subject)
+	}
+}
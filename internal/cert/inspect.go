@@ -0,0 +1,128 @@
+package cert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/transport"
+)
+
+// LoadCertFile 读取 PEM 文件中的所有证书
+func LoadCertFile(certPath string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取证书文件: %v", err)
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析证书失败: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("证书文件 %s 中没有有效的 PEM 证书", certPath)
+	}
+	return certs, nil
+}
+
+// PrintCertDetails 输出证书的主题、备用名称、颁发者、有效期和指纹
+func PrintCertDetails(out io.Writer, cert *x509.Certificate) {
+	fmt.Fprintf(out, "  Subject:     %s\n", cert.Subject)
+	fmt.Fprintf(out, "  SANs:        %s\n", strings.Join(SubjectAltNames(cert), ", "))
+	fmt.Fprintf(out, "  Issuer:      %s\n", cert.Issuer)
+	fmt.Fprintf(out, "  Not Before:  %s\n", cert.NotBefore)
+	fmt.Fprintf(out, "  Not After:   %s\n", cert.NotAfter)
+	fmt.Fprintf(out, "  Is CA:       %t\n", cert.IsCA)
+	fmt.Fprintf(out, "  SHA-256:     %s\n", Fingerprint(cert.Raw))
+}
+
+// SubjectAltNames 返回证书中的所有备用名称
+func SubjectAltNames(cert *x509.Certificate) []string {
+	var names []string
+	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+// DaysUntilExpiry 返回证书距离过期的天数，已过期时为负数
+func DaysUntilExpiry(cert *x509.Certificate, now time.Time) int {
+	return int(cert.NotAfter.Sub(now).Hours() / 24)
+}
+
+// VerifyServer 使用给定的 TLS 配置和网络选项与服务器握手，返回握手成功后的连接状态
+func VerifyServer(ctx context.Context, serverURL string, tlsConfig *tls.Config, netOpts transport.Options) (*tls.ConnectionState, error) {
+	conn, err := dialTLS(ctx, serverURL, tlsConfig, netOpts.ConnectTimeout, netOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	return &state, nil
+}
+
+// ExplainVerifyError 将证书校验错误转换为用户可以理解的原因说明
+func ExplainVerifyError(err error) string {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostnameErr      x509.HostnameError
+		invalidErr       x509.CertificateInvalidError
+		verifyErr        *tls.CertificateVerificationError
+	)
+
+	switch {
+	case errors.Is(err, ErrFingerprintMismatch):
+		return fmt.Sprintf("服务器证书与固定的指纹不一致，服务器可能更换了证书或存在中间人攻击: %v", err)
+	case errors.As(err, &hostnameErr) && hostnameErr.Certificate != nil:
+		return fmt.Sprintf("证书中的名称与服务器地址 %s 不匹配，证书备用名称: [%s]",
+			hostnameErr.Host, strings.Join(SubjectAltNames(hostnameErr.Certificate), ", "))
+	case errors.As(err, &unknownAuthority):
+		msg := "证书不是由受信任的 CA 签发，当前证书池中没有对应的根证书"
+		if unknownAuthority.Cert != nil {
+			msg += fmt.Sprintf("，证书颁发者: %s", unknownAuthority.Cert.Issuer)
+		}
+		return msg
+	case errors.As(err, &invalidErr) && invalidErr.Cert != nil:
+		switch invalidErr.Reason {
+		case x509.Expired:
+			return fmt.Sprintf("证书已过期或尚未生效，有效期 %s 至 %s",
+				invalidErr.Cert.NotBefore.Format(time.DateTime), invalidErr.Cert.NotAfter.Format(time.DateTime))
+		case x509.NotAuthorizedToSign:
+			return "证书链中的证书无权签发下级证书"
+		case x509.IncompatibleUsage:
+			return "证书的用途不允许用于服务器认证"
+		default:
+			return fmt.Sprintf("证书无效: %v", invalidErr)
+		}
+	case errors.As(err, &verifyErr):
+		return fmt.Sprintf("证书校验失败: %v", verifyErr.Err)
+	default:
+		return err.Error()
+	}
+}
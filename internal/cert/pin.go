@@ -0,0 +1,96 @@
+package cert
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrFingerprintMismatch 表示服务器证书与已固定的指纹不一致
+var ErrFingerprintMismatch = errors.New("服务器证书指纹与已固定的指纹不一致")
+
+// ErrNotTrusted 表示用户拒绝信任服务器证书
+var ErrNotTrusted = errors.New("用户拒绝信任服务器证书")
+
+// Fingerprint 计算 DER 编码证书的 SHA-256 指纹，格式为冒号分隔的大写十六进制
+func Fingerprint(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// NormalizeFingerprint 规范化用户输入的指纹，忽略大小写、冒号、空格及 sha256: 前缀
+func NormalizeFingerprint(fingerprint string) string {
+	fp := strings.ToUpper(strings.TrimSpace(fingerprint))
+	fp = strings.TrimPrefix(fp, "SHA256:")
+	fp = strings.NewReplacer(":", "", " ", "", "-", "").Replace(fp)
+	if len(fp) != sha256.Size*2 {
+		return fp
+	}
+
+	parts := make([]string, 0, sha256.Size)
+	for i := 0; i < len(fp); i += 2 {
+		parts = append(parts, fp[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
+
+// VerifyPin 校验 DER 编码证书是否与固定指纹一致
+func VerifyPin(raw []byte, pinned string) error {
+	actual := Fingerprint(raw)
+	if actual != NormalizeFingerprint(pinned) {
+		return fmt.Errorf("%w\n  已固定: %s\n  当前:   %s\n这可能是中间人攻击，也可能是服务器更换了证书",
+			ErrFingerprintMismatch, NormalizeFingerprint(pinned), actual)
+	}
+	return nil
+}
+
+// PrintCertSummary 输出证书的主题、颁发者、有效期和指纹
+func PrintCertSummary(out io.Writer, cert *x509.Certificate) {
+	fmt.Fprintf(out, "  Subject:     %s\n", cert.Subject)
+	fmt.Fprintf(out, "  Issuer:      %s\n", cert.Issuer)
+	fmt.Fprintf(out, "  Not Before:  %s\n", cert.NotBefore)
+	fmt.Fprintf(out, "  Not After:   %s\n", cert.NotAfter)
+	fmt.Fprintf(out, "  SHA-256:     %s\n", Fingerprint(cert.Raw))
+}
+
+// confirmTrust 交互式询问用户是否信任证书
+func confirmTrust(in io.Reader, out io.Writer, serverAddress string, cert *x509.Certificate) (bool, error) {
+	fmt.Fprintf(out, "首次连接服务器 %s，无法确认其证书是否可信:\n", serverAddress)
+	PrintCertSummary(out, cert)
+	fmt.Fprint(out, "请通过可信渠道核对以上指纹，是否信任该证书? [y/N]: ")
+
+	return askYesNo(in)
+}
+
+// confirmChange 证书与已固定的指纹不一致时，交互式询问用户是否信任新证书
+func confirmChange(in io.Reader, out io.Writer, serverAddress, pinned string, cert *x509.Certificate) (bool, error) {
+	fmt.Fprintf(out, "服务器 %s 的证书已变更:\n", serverAddress)
+	fmt.Fprintf(out, "  已固定:      %s\n", NormalizeFingerprint(pinned))
+	PrintCertSummary(out, cert)
+	fmt.Fprint(out, "请通过可信渠道核对新证书的指纹，是否信任新证书? [y/N]: ")
+
+	return askYesNo(in)
+}
+
+// askYesNo 读取用户的 y/N 回答，默认为否
+func askYesNo(in io.Reader) (bool, error) {
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("读取用户输入失败: %v", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
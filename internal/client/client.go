@@ -1,23 +1,26 @@
 package client
 
 import (
+	"context"
 	"crypto/tls"
-	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
-	"strings"
+	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/zhengyansheng/xcli/internal/transport"
+	"github.com/zhengyansheng/xcli/pkg/config"
 	"k8s.io/klog/v2"
 	"resty.dev/v3"
 )
 
-// APIResponse 定义通用的API响应结构
-type APIResponse struct {
-	Code  int         `json:"code"`
-	Msg   string      `json:"msg"`
-	Data  interface{} `json:"data"`
-	Count int         `json:"count"`
+// APIResponse 定义通用的API响应结构，Data 为各接口的响应数据
+type APIResponse[T any] struct {
+	Code  int    `json:"code"`
+	Msg   string `json:"msg"`
+	Data  T      `json:"data"`
+	Count int    `json:"count"`
 }
 
 // LogonData 定义登录响应中的数据结构
@@ -28,12 +31,7 @@ type LogonData struct {
 }
 
 // LogonResponse 定义登录响应的结构
-type LogonResponse struct {
-	Code  int       `json:"code"`
-	Msg   string    `json:"msg"`
-	Data  LogonData `json:"data"`
-	Count int       `json:"count"`
-}
+type LogonResponse = APIResponse[LogonData]
 
 // JobSubmitData 定义作业提交响应中的数据结构
 type JobSubmitData struct {
@@ -42,12 +40,7 @@ type JobSubmitData struct {
 }
 
 // JobSubmitResponse 定义作业提交响应
-type JobSubmitResponse struct {
-	Code  int           `json:"code"`
-	Msg   string        `json:"msg"`
-	Data  JobSubmitData `json:"data"`
-	Count int           `json:"count"`
-}
+type JobSubmitResponse = APIResponse[JobSubmitData]
 
 // JobSubmitRequest 定义作业提交请求
 type JobSubmitRequest struct {
@@ -83,12 +76,7 @@ type Host struct {
 }
 
 // HostsResponse 定义主机查询响应
-type HostsResponse struct {
-	Code  int    `json:"code"`
-	Msg   string `json:"msg"`
-	Data  []Host `json:"data"`
-	Count int    `json:"count"`
-}
+type HostsResponse = APIResponse[[]Host]
 
 // Job 定义作业信息结构
 type Job struct {
@@ -105,197 +93,228 @@ type Job struct {
 }
 
 // JobsResponse 定义作业查询响应
-type JobsResponse struct {
-	Code  int    `json:"code"`
-	Msg   string `json:"msg"`
-	Data  []Job  `json:"data"`
-	Count int    `json:"count"`
-}
+type JobsResponse = APIResponse[[]Job]
 
 // APIClient 定义API客户端
 type APIClient struct {
-	client  *resty.Client
+	client *resty.Client
+	// baseURL 规范化后的服务器地址，不包含接口路径
 	baseURL string
+	retry   RetryPolicy
+	timeout time.Duration
+	log     logr.Logger
+	// apiVersion 接口路径使用的 API 版本
+	apiVersion string
+	// capabilities 服务器支持的能力，negotiated 为 false 时未知
+	capabilities map[string]bool
+	// negotiated 是否已通过版本接口或配置得知服务器的能力，已知但为空表示服务器不支持任何可选能力
+	negotiated bool
 }
 
-// NewAPIClient 创建新的API客户端
-func NewAPIClient(baseURL string) *APIClient {
-	c := &APIClient{
-		client:  resty.New(),
-		baseURL: baseURL,
-	}
+// DefaultRequestTimeout 单次请求的默认超时时间
+const DefaultRequestTimeout = 30 * time.Second
 
-	return c
-}
+// Option 创建客户端时的可选设置
+type Option func(*APIClient)
 
-func (c *APIClient) SetRootCAs(certPath string) {
-	// 如果是 HTTPS，设置根证书
-	// logon
-	if strings.Contains(c.baseURL, "logon") {
-		c.client.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
-		return
-	}
-
-	if strings.HasPrefix(c.baseURL, "https://") {
-		c.client.SetTLSClientConfig(&tls.Config{
-			RootCAs: loadRootCAs(certPath),
-		})
+// WithTimeout 设置单次请求的超时时间，重试时每次请求单独计时，0 表示不限制
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *APIClient) {
+		c.timeout = timeout
 	}
 }
 
-// loadRootCAs 加载根证书
-func loadRootCAs(certPath string) *x509.CertPool {
-	certPool := x509.NewCertPool()
-	cert, err := os.ReadFile(certPath)
-	if err != nil {
-		klog.Errorf("无法读取证书文件: %v\n", err)
-		return nil
+// NewAPIClient 创建新的API客户端，baseURL 为服务器地址，其中的 /xce/v1/logon 等接口路径会被去掉
+func NewAPIClient(baseURL string, opts ...Option) *APIClient {
+	if normalized, err := config.NormalizeURL(baseURL); err == nil {
+		baseURL = normalized
 	}
-	if ok := certPool.AppendCertsFromPEM(cert); !ok {
-		klog.Error("无法附加证书")
-		return nil
-	}
-	return certPool
-}
-
-// Logon 执行登录操作
-func (c *APIClient) Logon(username, password string) (*LogonResponse, error) {
-	var loginResp LogonResponse
-	resp, err := c.client.R().
-		SetBody(map[string]interface{}{
-			"username": username,
-			"password": password,
-		}).
-		SetHeader("Content-Type", "application/json").
-		SetResult(&loginResp).
-		Post(c.baseURL)
-
-	if err != nil {
-		return nil, fmt.Errorf("登录失败: %v", err)
+	c := &APIClient{
+		// 保留响应内容，以便在出错时记录到 APIError 中
+		client:     resty.New().SetResponseBodyUnlimitedReads(true),
+		baseURL:    baseURL,
+		retry:      DefaultRetryPolicy,
+		timeout:    DefaultRequestTimeout,
+		log:        klog.Background(),
+		apiVersion: DefaultAPIVersion,
 	}
-
-	if resp.StatusCode() != 200 || loginResp.Code != 200 {
-		return nil, fmt.Errorf("登录失败: %s", loginResp.Msg)
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
 
-	return &loginResp, nil
+// SetTransport 设置发送请求使用的 HTTP 传输层，需在设置 TLS 之前调用
+func (c *APIClient) SetTransport(transport http.RoundTripper) {
+	c.client.SetTransport(transport)
 }
 
-// Logout 执行登出操作
-func (c *APIClient) Logout(token string) error {
-	resp, err := c.client.R().
-		SetHeader("Authorization", "Bearer "+token).
-		Post(c.baseURL + "/xce/v1/auth/logout")
+// SetTransportOptions 使用代理、静态解析、Unix 域套接字等网络选项创建传输层，需在设置 TLS 之前调用
+func (c *APIClient) SetTransportOptions(opts transport.Options) {
+	c.SetTransport(transport.New(opts))
+}
 
-	if err != nil {
-		return fmt.Errorf("登出请求失败: %v", err)
+// SetTLSConfig 直接设置 TLS 配置，传输层须为 *http.Transport 或实现 resty.TLSClientConfiger
+func (c *APIClient) SetTLSConfig(tlsConfig *tls.Config) error {
+	switch t := c.client.Transport().(type) {
+	case resty.TLSClientConfiger:
+		return t.SetTLSClientConfig(tlsConfig)
+	case *http.Transport:
+		t.TLSClientConfig = tlsConfig
+		return nil
+	default:
+		return fmt.Errorf("传输层 %T 不支持设置 TLS 配置", t)
 	}
+}
 
-	if resp.StatusCode() != 200 {
-		return fmt.Errorf("登出失败: HTTP %d - %s", resp.StatusCode(), resp.String())
-	}
+// SetLogger 设置客户端日志，默认使用 klog
+func (c *APIClient) SetLogger(log logr.Logger) {
+	c.log = log
+}
 
-	return nil
+// SetTimeout 设置单次请求的超时时间，重试时每次请求单独计时，0 表示不限制
+func (c *APIClient) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
 }
 
-// SubmitJob 提交作业
-func (c *APIClient) SubmitJob(token string, req *JobSubmitRequest) (*JobSubmitResponse, error) {
-	var resp JobSubmitResponse
+// Logon 执行登录操作
+func (c *APIClient) Logon(ctx context.Context, username, password string) (*LogonResponse, error) {
+	return c.logon(ctx, map[string]interface{}{
+		"username": username,
+		"password": password,
+	})
+}
 
-	// 从 baseURL 中提取 ip:port 部分
-	baseURL := c.baseURL
-	// 如果 URL 包含路径，去掉路径部分
-	if idx := strings.Index(baseURL, "/xce/v1"); idx != -1 {
-		baseURL = baseURL[:idx]
+// LogonWithCert 使用客户端证书登录，不交换密码
+func (c *APIClient) LogonWithCert(ctx context.Context, username string) (*LogonResponse, error) {
+	if err := c.require(CapCertLogon); err != nil {
+		return nil, err
 	}
-	// 构建完整的作业提交 URL
-	submitURL := baseURL + "/xce/v1/jobs"
+	return c.logon(ctx, map[string]interface{}{
+		"username":  username,
+		"auth_type": "cert",
+	})
+}
 
-	httpResp, err := c.client.R().
-		SetHeader("Authorization", "Bearer "+token).
-		SetHeader("Content-Type", "application/json").
-		SetBody(req).
-		SetResult(&resp).
-		Post(submitURL)
+func (c *APIClient) logon(ctx context.Context, body map[string]interface{}) (*LogonResponse, error) {
+	return do[LogonData](ctx, c, request{
+		op:   "登录",
+		verb: http.MethodPost,
+		path: "/logon",
+		body: body,
+	})
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("提交作业请求失败: %v", err)
-	}
+// Logout 执行登出操作
+func (c *APIClient) Logout(ctx context.Context, token string) error {
+	_, err := do[json.RawMessage](ctx, c, request{
+		op:         "登出",
+		verb:       http.MethodPost,
+		path:       "/auth/logout",
+		token:      token,
+		noEnvelope: true,
+	})
+	return err
+}
 
-	if httpResp.StatusCode() != http.StatusOK || resp.Code != http.StatusCreated {
-		return nil, fmt.Errorf("提交作业失败: %s", resp.Msg)
+// SubmitJob 提交作业，服务器声明支持幂等提交时遇到暂时性错误会重试。
+// 能力未知时不发送幂等键也不重试，避免不识别 Idempotency-Key 的服务器重复创建作业
+func (c *APIClient) SubmitJob(ctx context.Context, token string, req *JobSubmitRequest) (*JobSubmitResponse, error) {
+	var idempotencyKey string
+	if c.advertises(CapIdempotency) {
+		idempotencyKey = newIdempotencyKey()
 	}
-
-	return &resp, nil
+	return do[JobSubmitData](ctx, c, request{
+		op:             "提交作业",
+		verb:           http.MethodPost,
+		path:           "/jobs",
+		token:          token,
+		body:           req,
+		idempotencyKey: idempotencyKey,
+	})
 }
 
-// GetHosts 查询主机信息
-func (c *APIClient) GetHosts(token string, params map[string]string) (*HostsResponse, error) {
-	var resp HostsResponse
+// GetHosts 查询一页主机信息，page 为零值时返回全部主机
+func (c *APIClient) GetHosts(ctx context.Context, token string, params map[string]string, page Page) (*HostsResponse, error) {
+	return do[[]Host](ctx, c, request{
+		op:    "查询主机",
+		verb:  http.MethodGet,
+		path:  "/hosts",
+		token: token,
+		query: page.params(params),
+	})
+}
 
-	// 从 baseURL 中提取 ip:port 部分
-	baseURL := c.baseURL
-	if idx := strings.Index(baseURL, "/xce/v1"); idx != -1 {
-		baseURL = baseURL[:idx]
+// GetJobs 查询一页作业信息，params 支持 filter 和 fields，page 为零值时返回全部作业
+func (c *APIClient) GetJobs(ctx context.Context, token string, params map[string]string, page Page) (*JobsResponse, error) {
+	if err := c.checkJobParams(params); err != nil {
+		return nil, err
 	}
-	// 构建完整的主机查询 URL
-	hostsURL := baseURL + "/xce/v1/hosts"
-
-	req := c.client.R().
-		SetHeader("Authorization", "Bearer "+token).
-		SetResult(&resp)
+	return do[[]Job](ctx, c, request{
+		op:    "查询作业",
+		verb:  http.MethodGet,
+		path:  "/jobs",
+		token: token,
+		query: page.params(params),
+	})
+}
 
-	// 添加查询参数
-	for k, v := range params {
-		req.SetQueryParam(k, v)
+// GetJob 查询单个作业，服务器支持时只返回作业 ID 和状态等 fields 中的字段，作业不存在时返回 ErrNotFound
+func (c *APIClient) GetJob(ctx context.Context, token string, id int64, fields ...string) (*Job, error) {
+	query := JobQuery{JobID: id}
+	if c.Supports(CapJobFields) {
+		query.Fields = fields
 	}
-
-	httpResp, err := req.Get(hostsURL)
+	resp, err := c.GetJobs(ctx, token, query.Params(), Page{})
 	if err != nil {
-		return nil, fmt.Errorf("查询主机请求失败: %v", err)
+		return nil, err
 	}
-
-	if httpResp.StatusCode() != 200 || resp.Code != 200 {
-		return nil, fmt.Errorf("查询主机失败: %s", resp.Msg)
+	for i := range resp.Data {
+		if resp.Data[i].JobID == id {
+			return &resp.Data[i], nil
+		}
 	}
-
-	return &resp, nil
+	return nil, fmt.Errorf("作业 %d: %w", id, ErrNotFound)
 }
 
-// GetJobs 查询作业信息
-func (c *APIClient) GetJobs(token string, params map[string]string) (*JobsResponse, error) {
-	var resp JobsResponse
-
-	// 从 baseURL 中提取 ip:port 部分
-	baseURL := c.baseURL
-	if idx := strings.Index(baseURL, "/xce/v1"); idx != -1 {
-		baseURL = baseURL[:idx]
-	}
-	// 构建完整的作业查询 URL
-	jobsURL := baseURL + "/xce/v1/jobs"
-
-	req := c.client.R().
-		SetHeader("Authorization", "Bearer "+token).
-		SetResult(&resp)
-
-	// 处理过滤条件
-	if filter, ok := params["filter"]; ok {
-		req.SetQueryParam("filter", filter)
+// GetJobsByID 查询多个作业，返回作业 ID 到作业的映射，任一作业不存在时返回 ErrNotFound。
+// 服务器声明支持 CapJobIDFilter 时通过一次请求查询所有作业，否则逐个查询
+func (c *APIClient) GetJobsByID(ctx context.Context, token string, ids []int64, fields ...string) (map[int64]*Job, error) {
+	jobs := make(map[int64]*Job, len(ids))
+	if !c.advertises(CapJobIDFilter) {
+		for _, id := range ids {
+			job, err := c.GetJob(ctx, token, id, fields...)
+			if err != nil {
+				return nil, err
+			}
+			jobs[id] = job
+		}
+		return jobs, nil
 	}
 
-	// 处理字段选择
-	if fields, ok := params["fields"]; ok {
-		req.SetQueryParam("fields", fields)
+	query := JobQuery{JobIDs: ids}
+	if c.Supports(CapJobFields) {
+		query.Fields = fields
 	}
-
-	httpResp, err := req.Get(jobsURL)
+	resp, err := c.GetJobs(ctx, token, query.Params(), Page{})
 	if err != nil {
-		return nil, fmt.Errorf("查询作业请求失败: %v", err)
+		return nil, err
 	}
-
-	if httpResp.StatusCode() != 200 || resp.Code != 200 {
-		return nil, fmt.Errorf("查询作业失败: %s", resp.Msg)
+	for i := range resp.Data {
+		jobs[resp.Data[i].JobID] = &resp.Data[i]
 	}
+	for _, id := range ids {
+		if jobs[id] == nil {
+			return nil, fmt.Errorf("作业 %d: %w", id, ErrNotFound)
+		}
+	}
+	return jobs, nil
+}
 
-	return &resp, nil
+// checkJobParams 检查服务器是否支持作业查询参数中用到的功能
+func (c *APIClient) checkJobParams(params map[string]string) error {
+	if params["fields"] != "" {
+		return c.require(CapJobFields)
+	}
+	return nil
 }
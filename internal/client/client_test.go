@@ -0,0 +1,210 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+)
+
+func TestSubmitJobRetriesOnlyWhenIdempotencyAdvertised(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities []string
+		negotiate    bool
+		wantRetry    bool
+	}{
+		{name: "能力未知"},
+		{name: "未声明幂等提交", capabilities: []string{client.CapPagination}},
+		{name: "配置中声明了幂等提交", capabilities: []string{client.CapIdempotency}, wantRetry: true},
+		{name: "协商得到幂等提交", negotiate: true, wantRetry: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := xcetest.NewServer()
+			defer s.Close()
+			s.InjectFault(xcetest.Fault{Method: http.MethodPost, Path: "/xce/v2/jobs", Times: 1, Status: http.StatusServiceUnavailable})
+
+			c := client.NewAPIClient(s.URL())
+			c.SetRetryPolicy(client.RetryPolicy{MaxRetries: 2, WaitMin: time.Millisecond, WaitMax: time.Millisecond})
+			c.SetCapabilities(tt.capabilities)
+			if tt.negotiate {
+				if _, err := c.Negotiate(context.Background(), ""); err != nil {
+					t.Fatalf("Negotiate: %v", err)
+				}
+			} else if err := c.SetAPIVersion("v2"); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := c.SubmitJob(context.Background(), s.IssueToken("alice"), &client.JobSubmitRequest{Command: "sleep 1"})
+			if tt.wantRetry && err != nil {
+				t.Errorf("SubmitJob: %v, want success after retry", err)
+			}
+			if !tt.wantRetry && err == nil {
+				t.Error("SubmitJob succeeded, want the 503 without retrying")
+			}
+			if got, want := len(s.Jobs()), map[bool]int{true: 1, false: 0}[tt.wantRetry]; got != want {
+				t.Errorf("server has %d jobs, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestCommandOptionsRequestTimeout(t *testing.T) {
+	s := xcetest.NewServer()
+	defer s.Close()
+	s.InjectFault(xcetest.Fault{Path: "/xce/v2/jobs", Delay: 200 * time.Millisecond})
+
+	root := &cobra.Command{Use: "cli"}
+	root.PersistentFlags().Duration(client.RequestTimeoutFlag, client.DefaultRequestTimeout, "")
+	var opts []client.Option
+	root.AddCommand(&cobra.Command{
+		Use: "bjobs",
+		Run: func(cmd *cobra.Command, args []string) { opts = client.CommandOptions(cmd) },
+	})
+	root.SetArgs([]string{"bjobs", "--" + client.RequestTimeoutFlag, "20ms"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := client.NewAPIClient(s.URL(), opts...)
+	c.SetRetryPolicy(client.RetryPolicy{})
+	if err := c.SetAPIVersion("v2"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.GetJobs(context.Background(), s.IssueToken("alice"), nil, client.Page{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetJobs with --request-timeout 20ms: err = %v, want deadline exceeded", err)
+	}
+
+	// 没有继承 --request-timeout 时使用默认超时
+	if opts := client.CommandOptions(&cobra.Command{Use: "standalone"}); opts != nil {
+		t.Errorf("CommandOptions without the flag = %d options, want none", len(opts))
+	}
+}
+
+// jobQueries 返回服务器收到的查询作业请求数
+func jobQueries(s *xcetest.Server) int {
+	n := 0
+	for _, r := range s.Requests() {
+		if r.Method == http.MethodGet && r.Path == "/xce/v2/jobs" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestGetRetries(t *testing.T) {
+	policy := client.RetryPolicy{MaxRetries: 2, WaitMin: time.Millisecond, WaitMax: time.Millisecond}
+	tests := []struct {
+		name  string
+		fault xcetest.Fault
+		// retryAfterDate 非零时通过 HTTP 日期格式的 Retry-After 要求等待
+		retryAfterDate time.Duration
+		wantTries      int
+		wantWait       time.Duration
+		wantErr        bool
+	}{
+		{name: "502", fault: xcetest.Fault{Times: 2, Status: http.StatusBadGateway}, wantTries: 3},
+		{name: "503", fault: xcetest.Fault{Times: 2, Status: http.StatusServiceUnavailable}, wantTries: 3},
+		{name: "504", fault: xcetest.Fault{Times: 2, Status: http.StatusGatewayTimeout}, wantTries: 3},
+		{name: "500 不重试", fault: xcetest.Fault{Times: 1, Status: http.StatusInternalServerError}, wantTries: 1, wantErr: true},
+		{name: "达到最多重试次数", fault: xcetest.Fault{Status: http.StatusServiceUnavailable}, wantTries: 3, wantErr: true},
+		{
+			name:      "429 Retry-After 秒数",
+			fault:     xcetest.Fault{Times: 1, Status: http.StatusTooManyRequests, RetryAfter: time.Second},
+			wantTries: 2,
+			wantWait:  time.Second,
+		},
+		{
+			// HTTP 日期精确到秒，2 秒后的日期至少等待 1 秒
+			name:           "429 Retry-After HTTP 日期",
+			fault:          xcetest.Fault{Times: 1, Status: http.StatusTooManyRequests},
+			retryAfterDate: 2 * time.Second,
+			wantTries:      2,
+			wantWait:       time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := xcetest.NewServer()
+			defer s.Close()
+			tt.fault.Method, tt.fault.Path = http.MethodGet, "/xce/v2/jobs"
+			if tt.retryAfterDate > 0 {
+				tt.fault.Header = http.Header{"Retry-After": {time.Now().Add(tt.retryAfterDate).UTC().Format(http.TimeFormat)}}
+			}
+			s.InjectFault(tt.fault)
+
+			c := client.NewAPIClient(s.URL())
+			c.SetRetryPolicy(policy)
+			if err := c.SetAPIVersion("v2"); err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			_, err := c.GetJobs(context.Background(), s.IssueToken("alice"), nil, client.Page{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetJobs() = %v, want error %v", err, tt.wantErr)
+			}
+			if got := jobQueries(s); got != tt.wantTries {
+				t.Errorf("GetJobs sent %d requests, want %d", got, tt.wantTries)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantWait {
+				t.Errorf("GetJobs returned after %s, want to wait at least %s for Retry-After", elapsed, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestNegotiateCapabilities(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []xcetest.Option
+		wantKnown  bool
+		wantPaging bool
+	}{
+		{name: "声明了能力", wantKnown: true, wantPaging: true},
+		{
+			name:      "不支持任何可选能力",
+			opts:      []xcetest.Option{xcetest.WithVersion(client.VersionInfo{APIVersions: []string{client.APIVersionV2}, Capabilities: []string{}})},
+			wantKnown: true,
+		},
+		{name: "旧服务器", opts: []xcetest.Option{xcetest.WithLegacyAPI()}, wantPaging: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := xcetest.NewServer(tt.opts...)
+			defer s.Close()
+			c := client.NewAPIClient(s.URL())
+			info, err := c.Negotiate(context.Background(), "")
+			if err != nil {
+				t.Fatalf("Negotiate: %v", err)
+			}
+			if known := info.Capabilities != nil; known != tt.wantKnown {
+				t.Errorf("capabilities = %#v, want known %v", info.Capabilities, tt.wantKnown)
+			}
+			if got := c.Supports(client.CapPagination); got != tt.wantPaging {
+				t.Errorf("Supports(pagination) = %v, want %v", got, tt.wantPaging)
+			}
+			// 能力未知时不限制功能，已知但未声明时直接报错
+			if _, err := c.LogonWithCert(context.Background(), "alice"); errors.Is(err, client.ErrUnsupported) != (tt.wantKnown && !tt.wantPaging) {
+				t.Errorf("LogonWithCert() = %v", err)
+			}
+		})
+	}
+
+	// 配置中记录的空能力列表同样表示已知
+	c := client.NewAPIClient("http://127.0.0.1")
+	c.SetCapabilities(client.ParseCapabilities(""))
+	if c.Supports(client.CapPagination) {
+		t.Error("Supports(pagination) with an empty capability list = true, want false")
+	}
+	c.SetCapabilities(nil)
+	if !c.Supports(client.CapPagination) {
+		t.Error("Supports(pagination) with unknown capabilities = false, want true")
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"resty.dev/v3"
+)
+
+// 可以通过 errors.Is 判断的 APIserver 错误类型
+var (
+	ErrUnauthorized = errors.New("未登录或登录已过期")
+	ErrForbidden    = errors.New("没有权限")
+	ErrNotFound     = errors.New("资源不存在")
+	ErrConflict     = errors.New("资源冲突")
+)
+
+// maxErrorBody 错误信息中显示的响应内容的最大长度
+const maxErrorBody = 200
+
+// requestIDHeaders 服务器可能返回请求 ID 的响应头
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "Request-Id"}
+
+// APIError APIserver 返回的错误，包括 HTTP 状态码非 200 和响应中的业务码表示失败两种情况
+type APIError struct {
+	// StatusCode HTTP 状态码
+	StatusCode int
+	// Code 响应中的业务码
+	Code int
+	// Msg 响应中的错误信息
+	Msg string
+	// Body 原始响应内容
+	Body string
+	// RequestID 服务器返回的请求 ID，用于在服务器日志中定位请求
+	RequestID string
+}
+
+// Error 返回错误信息，包含 HTTP 状态码、业务码和请求 ID
+func (e *APIError) Error() string {
+	msg := e.Msg
+	if msg == "" {
+		// 非 json 响应时使用响应内容，内容过长时截断
+		msg = e.Body
+		if len(msg) > maxErrorBody {
+			msg = msg[:maxErrorBody] + "..."
+		}
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if msg == "" {
+		msg = "未知错误"
+	}
+
+	details := []string{fmt.Sprintf("HTTP %d", e.StatusCode)}
+	if e.Code != 0 && e.Code != e.StatusCode {
+		details = append(details, fmt.Sprintf("code %d", e.Code))
+	}
+	if e.RequestID != "" {
+		details = append(details, "request id "+e.RequestID)
+	}
+	return fmt.Sprintf("%s (%s)", msg, strings.Join(details, ", "))
+}
+
+// Is 按 HTTP 状态码匹配 ErrUnauthorized 等错误，HTTP 状态码为 200 时使用业务码
+func (e *APIError) Is(target error) bool {
+	status := e.StatusCode
+	if status == http.StatusOK {
+		status = e.Code
+	}
+	switch target {
+	case ErrUnauthorized:
+		return status == http.StatusUnauthorized
+	case ErrForbidden:
+		return status == http.StatusForbidden
+	case ErrNotFound:
+		return status == http.StatusNotFound
+	case ErrConflict:
+		return status == http.StatusConflict
+	}
+	return false
+}
+
+// newAPIError 根据响应创建 *APIError，errResp 为通过 SetError 解析的错误响应，
+// HTTP 状态码正常时使用 code 和 msg
+func newAPIError(resp *resty.Response, errResp *APIResponse[json.RawMessage], code int, msg string) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode(),
+		Code:       code,
+		Msg:        msg,
+		Body:       resp.String(),
+	}
+	if resp.IsError() {
+		e.Code = errResp.Code
+		e.Msg = errResp.Msg
+	}
+	for _, header := range requestIDHeaders {
+		if id := resp.Header().Get(header); id != "" {
+			e.RequestID = id
+			break
+		}
+	}
+	return e
+}
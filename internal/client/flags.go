@@ -0,0 +1,21 @@
+package client
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// RequestTimeoutFlag 根命令中设置单次请求超时时间的全局参数
+const RequestTimeoutFlag = "request-timeout"
+
+// CommandOptions 返回按命令行全局参数创建客户端的选项，cmd 没有继承根命令的这些参数时返回 nil
+func CommandOptions(cmd *cobra.Command) []Option {
+	var opts []Option
+	if flag := cmd.Flag(RequestTimeoutFlag); flag != nil {
+		if timeout, err := time.ParseDuration(flag.Value.String()); err == nil {
+			opts = append(opts, WithTimeout(timeout))
+		}
+	}
+	return opts
+}
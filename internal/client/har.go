@@ -0,0 +1,237 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// harRecorder 以 HAR 1.2 格式记录本进程发出的所有请求
+var harRecorder struct {
+	mu      sync.Mutex
+	enabled bool
+	entries []harEntry
+}
+
+// StartHARTrace 开始记录本进程中所有客户端发出的请求，密码和令牌等敏感信息会被脱敏。
+// 之前记录但尚未写入文件的请求会被丢弃
+func StartHARTrace() {
+	harRecorder.mu.Lock()
+	defer harRecorder.mu.Unlock()
+	harRecorder.enabled = true
+	harRecorder.entries = nil
+}
+
+// WriteHARTrace 将已记录的请求以 HAR 格式写入文件，可以附在问题报告中。写入后停止记录并清空已记录的请求，
+// 同一进程中的下一次记录从 StartHARTrace 重新开始
+func WriteHARTrace(path string) error {
+	harRecorder.mu.Lock()
+	defer harRecorder.mu.Unlock()
+	defer func() {
+		harRecorder.enabled = false
+		harRecorder.entries = nil
+	}()
+
+	har := harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "cli", Version: "1.0"},
+		Entries: harRecorder.entries,
+	}}
+	if har.Log.Entries == nil {
+		har.Log.Entries = []harEntry{}
+	}
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("写入请求记录文件失败: %v", err)
+	}
+	return nil
+}
+
+func harTracing() bool {
+	harRecorder.mu.Lock()
+	defer harRecorder.mu.Unlock()
+	return harRecorder.enabled
+}
+
+// recordHAR 保存一次请求
+func recordHAR(ex *exchange) {
+	entry := newHAREntry(ex)
+	harRecorder.mu.Lock()
+	defer harRecorder.mu.Unlock()
+	harRecorder.entries = append(harRecorder.entries, entry)
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// harTimings 各阶段耗时，单位为毫秒，-1 表示不适用
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func newHAREntry(ex *exchange) harEntry {
+	t := ex.timings
+	entry := harEntry{
+		StartedDateTime: t.start.Format(time.RFC3339Nano),
+		Time:            millis(ex.total()),
+		Request: harRequest{
+			Method:      ex.method,
+			URL:         ex.url,
+			HTTPVersion: ex.proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(ex.requestHeader),
+			QueryString: harQuery(ex.url),
+			HeadersSize: -1,
+			BodySize:    len(ex.requestBody),
+		},
+		Response: harResponse{
+			Status:      ex.status,
+			StatusText:  ex.statusText,
+			HTTPVersion: ex.responseProto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(ex.responseHeader),
+			Content: harContent{
+				Size:     len(ex.responseBody),
+				MimeType: ex.responseHeader.Get("Content-Type"),
+				Text:     ex.responseBody,
+			},
+			HeadersSize: -1,
+			BodySize:    len(ex.responseBody),
+		},
+		Timings: harTimings{
+			Blocked: -1,
+			DNS:     millis(between(t.dnsStart, t.dnsDone)),
+			// HAR 中的 connect 包含 TLS 握手
+			Connect: millis(between(t.connectStart, later(t.connectDone, t.tlsDone))),
+			SSL:     millis(between(t.tlsStart, t.tlsDone)),
+			Send:    millis(between(t.gotConn, t.wroteRequest)),
+			Wait:    millis(between(t.wroteRequest, t.firstByte)),
+			Receive: millis(between(t.firstByte, t.end)),
+		},
+	}
+	if ex.requestBody != "" {
+		entry.Request.PostData = &harPostData{
+			MimeType: ex.requestHeader.Get("Content-Type"),
+			Text:     ex.requestBody,
+		}
+	}
+	if ex.err != nil {
+		entry.Error = ex.err.Error()
+	}
+	return entry
+}
+
+// millis 将耗时转换为毫秒，-1 保持不变
+func millis(d time.Duration) float64 {
+	if d < 0 {
+		return -1
+	}
+	return float64(d) / float64(time.Millisecond)
+}
+
+// later 返回两个时间点中较晚的一个
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+func harHeaders(header map[string][]string) []harNameValue {
+	pairs := []harNameValue{}
+	for _, name := range sortedKeys(header) {
+		for _, v := range header[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: v})
+		}
+	}
+	return pairs
+}
+
+func harQuery(rawURL string) []harNameValue {
+	pairs := []harNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+	query := u.Query()
+	for _, name := range sortedKeys(query) {
+		for _, v := range query[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: v})
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"strconv"
+)
+
+// DefaultPageSize 分页查询时每页的默认条数
+const DefaultPageSize = 500
+
+// Page 定义分页查询的范围，Limit 为 0 时不分页，由服务器返回全部结果
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// params 返回包含分页参数的查询参数，不修改 params
+func (p Page) params(params map[string]string) map[string]string {
+	query := make(map[string]string, len(params)+2)
+	for k, v := range params {
+		query[k] = v
+	}
+	if p.Limit > 0 {
+		query["limit"] = strconv.Itoa(p.Limit)
+		query["offset"] = strconv.Itoa(p.Offset)
+	}
+	return query
+}
+
+// ListOptions 定义逐条遍历查询结果时的选项
+type ListOptions struct {
+	// PageSize 每次请求的条数，0 表示使用 DefaultPageSize
+	PageSize int
+	// Limit 最多返回的条数，0 表示不限制
+	Limit int
+}
+
+// pageSize 返回本次请求的条数，不超过剩余的 Limit
+func (o ListOptions) pageSize(fetched int) int {
+	size := o.PageSize
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	if o.Limit > 0 && o.Limit-fetched < size {
+		size = o.Limit - fetched
+	}
+	return size
+}
+
+// paginate 按 limit/offset 逐页请求并逐条返回结果，出错时返回错误并结束遍历。
+// 响应中的 count 为符合条件的总数；不支持分页的服务器会一次返回全部结果，此时不再请求下一页。
+// 只有服务器明确声明支持分页时才分页，能力未知的旧服务器可能只识别 limit 而忽略 offset，
+// 逐页请求会反复得到第一页，因此只请求一次，由客户端截取前 Limit 条
+func paginate[T any](ctx context.Context, c *APIClient, req request, opts ListOptions) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		params := req.query
+		paged := c.advertises(CapPagination)
+		fetched := 0
+		for opts.Limit == 0 || fetched < opts.Limit {
+			size := opts.pageSize(fetched)
+			req.query = params
+			if paged {
+				req.query = Page{Limit: size, Offset: fetched}.params(params)
+			}
+			resp, err := do[[]T](ctx, c, req)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range resp.Data {
+				if opts.Limit > 0 && fetched >= opts.Limit {
+					return
+				}
+				fetched++
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if !paged || len(resp.Data) != size || (resp.Count > 0 && fetched >= resp.Count) {
+				return
+			}
+		}
+	}
+}
+
+// Jobs 逐条遍历符合条件的作业，按页向服务器请求，params 支持 filter 和 fields
+func (c *APIClient) Jobs(ctx context.Context, token string, params map[string]string, opts ListOptions) iter.Seq2[Job, error] {
+	if err := c.checkJobParams(params); err != nil {
+		return func(yield func(Job, error) bool) {
+			yield(Job{}, err)
+		}
+	}
+	return paginate[Job](ctx, c, request{
+		op:    "查询作业",
+		verb:  http.MethodGet,
+		path:  "/jobs",
+		token: token,
+		query: params,
+	}, opts)
+}
+
+// Hosts 逐条遍历符合条件的主机，按页向服务器请求
+func (c *APIClient) Hosts(ctx context.Context, token string, params map[string]string, opts ListOptions) iter.Seq2[Host, error] {
+	return paginate[Host](ctx, c, request{
+		op:    "查询主机",
+		verb:  http.MethodGet,
+		path:  "/hosts",
+		token: token,
+		query: params,
+	}, opts)
+}
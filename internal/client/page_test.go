@@ -0,0 +1,71 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+)
+
+func TestJobsPagination(t *testing.T) {
+	tests := []struct {
+		name      string
+		negotiate bool
+		opts      client.ListOptions
+		wantJobs  string
+		wantPages int
+	}{
+		{name: "声明了分页", negotiate: true, opts: client.ListOptions{PageSize: 2}, wantJobs: "[1 2 3 4 5]", wantPages: 3},
+		{name: "声明了分页并限制条数", negotiate: true, opts: client.ListOptions{PageSize: 2, Limit: 3}, wantJobs: "[1 2 3]", wantPages: 2},
+		{name: "能力未知时只请求一次", opts: client.ListOptions{PageSize: 2}, wantJobs: "[1 2 3 4 5]", wantPages: 1},
+		{name: "能力未知时由客户端截取", opts: client.ListOptions{PageSize: 2, Limit: 3}, wantJobs: "[1 2 3]", wantPages: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := xcetest.New()
+			for range 5 {
+				if _, err := s.Submit("alice", client.JobSubmitRequest{Command: "sleep 60"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// 能力未知的旧服务器只识别 limit，忽略 offset
+			pages := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/xce/v1/jobs" {
+					pages++
+					if !tt.negotiate {
+						query := r.URL.Query()
+						query.Del("offset")
+						r.URL.RawQuery = query.Encode()
+					}
+				}
+				s.ServeHTTP(w, r)
+			}))
+			defer ts.Close()
+
+			c := client.NewAPIClient(ts.URL)
+			if tt.negotiate {
+				if _, err := c.Negotiate(context.Background(), client.APIVersionV1); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var ids []int64
+			for job, err := range c.Jobs(context.Background(), s.IssueToken("alice"), nil, tt.opts) {
+				if err != nil {
+					t.Fatalf("Jobs: %v", err)
+				}
+				ids = append(ids, job.JobID)
+			}
+			if got := fmt.Sprint(ids); got != tt.wantJobs {
+				t.Errorf("jobs = %s, want %s", got, tt.wantJobs)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("requested %d pages, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JobQuery 定义作业查询条件
+type JobQuery struct {
+	// JobID 只查询该作业，为 0 时不限制
+	JobID int64
+	// JobIDs 只查询这些作业，需要服务器支持 CapJobIDFilter
+	JobIDs []int64
+	// User 只查询该用户的作业
+	User string
+	// Queue 只查询该队列中的作业
+	Queue string
+	// Fields 只返回这些字段，为空时返回全部字段
+	Fields []string
+}
+
+// Params 返回作业查询的请求参数
+func (q JobQuery) Params() map[string]string {
+	params := make(map[string]string)
+
+	var filters []string
+	if q.JobID != 0 {
+		filters = append(filters, fmt.Sprintf("jobid:eq:%d", q.JobID))
+	}
+	if len(q.JobIDs) > 0 {
+		ids := make([]string, len(q.JobIDs))
+		for i, id := range q.JobIDs {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		filters = append(filters, "jobid:in:"+strings.Join(ids, "|"))
+	}
+	if q.User != "" {
+		filters = append(filters, fmt.Sprintf("user:eq:%s", q.User))
+	}
+	if q.Queue != "" {
+		filters = append(filters, fmt.Sprintf("queue:eq:%s", q.Queue))
+	}
+	if len(filters) > 0 {
+		params["filter"] = fmt.Sprintf("[%s]", strings.Join(filters, ","))
+	}
+
+	if len(q.Fields) > 0 {
+		params["fields"] = strings.Join(q.Fields, ",")
+	}
+	return params
+}
+
+// HostQuery 定义主机查询条件
+type HostQuery struct {
+	// Type 信息类型，basic 或 full，为空时为 basic
+	Type string
+	// HostType 只查询该类型的主机，如 X86_64、ARM
+	HostType string
+}
+
+// Params 返回主机查询的请求参数
+func (q HostQuery) Params() map[string]string {
+	params := map[string]string{"type": "basic"}
+	if q.Type != "" {
+		params["type"] = strings.ToLower(q.Type)
+	}
+	if q.HostType != "" {
+		params["filter"] = fmt.Sprintf("hostType:eq:%s", strings.ToUpper(q.HostType))
+	}
+	return params
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	"resty.dev/v3"
+)
+
+// apiRoot APIserver 接口的路径前缀，其后为 API 版本
+const apiRoot = "/xce"
+
+// request 定义一次 API 请求
+type request struct {
+	// op 操作名称，用于错误信息
+	op string
+	// verb HTTP 方法
+	verb string
+	// path 相对于 /xce/<API 版本> 的接口路径，如 /jobs
+	path string
+	// url 完整的请求地址，设置后忽略 path
+	url string
+	// token 非空时通过 Authorization 头发送
+	token string
+	query map[string]string
+	// body 非空时以 json 格式发送
+	body any
+	// noEnvelope 响应不是 APIResponse 格式，只检查 HTTP 状态码
+	noEnvelope bool
+	// idempotencyKey 非空时通过 Idempotency-Key 头发送，服务器据此识别重复的请求，
+	// 设置后非 GET 请求也会被重试
+	idempotencyKey string
+}
+
+// do 发送请求并将响应解析为 APIResponse[T]。幂等的请求遇到暂时性错误时按重试策略重试，
+// ctx 被取消时立即返回。HTTP 状态码或响应中的业务码不是 2xx 时返回 *APIError，
+// 请求未能完成时返回包装后的网络错误
+func do[T any](ctx context.Context, c *APIClient, req request) (*APIResponse[T], error) {
+	url := req.url
+	if url == "" {
+		url = c.endpoint(req.path)
+	}
+	retryable := req.verb == http.MethodGet || req.idempotencyKey != ""
+
+	for attempt := 0; ; attempt++ {
+		var result APIResponse[T]
+		var errResp APIResponse[json.RawMessage]
+
+		r := c.client.R().
+			SetError(&errResp).
+			SetQueryParams(req.query)
+		if !req.noEnvelope {
+			r.SetResult(&result)
+		}
+		if req.token != "" {
+			r.SetHeader("Authorization", "Bearer "+req.token)
+		}
+		if req.idempotencyKey != "" {
+			r.SetHeader("Idempotency-Key", req.idempotencyKey)
+		}
+		if req.body != nil {
+			r.SetHeader("Content-Type", "application/json").SetBody(req.body)
+		}
+
+		resp, err := c.execute(ctx, r, req.verb, url)
+		if retryable && ctx.Err() == nil {
+			if wait, ok := c.retry.retryWait(attempt, resp, err); ok {
+				c.log.V(2).Info(fmt.Sprintf("%s请求失败，%v 后进行第 %d 次重试", req.op, wait, attempt+1))
+				if err := sleep(ctx, wait); err != nil {
+					return nil, fmt.Errorf("%s请求已取消: %w", req.op, err)
+				}
+				continue
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s请求失败: %w", req.op, err)
+		}
+
+		if !resp.IsSuccess() || (!req.noEnvelope && !isSuccessCode(result.Code)) {
+			return nil, newAPIError(resp, &errResp, result.Code, result.Msg)
+		}
+		return &result, nil
+	}
+}
+
+// execute 发送单次请求，超时时间从发送时开始计算
+func (c *APIClient) execute(ctx context.Context, r *resty.Request, verb, url string) (*resty.Response, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	if !c.tracing() {
+		return r.SetContext(ctx).Execute(verb, url)
+	}
+
+	t := &timings{start: time.Now()}
+	resp, err := r.SetContext(httptrace.WithClientTrace(ctx, t.clientTrace())).Execute(verb, url)
+	t.mu.Lock()
+	t.end = time.Now()
+	t.mu.Unlock()
+	c.trace(r, resp, err, t)
+	return resp, err
+}
+
+// sleep 等待指定的时间，ctx 被取消时提前返回错误
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// endpoint 返回接口的完整地址，路径前缀为 /xce/<API 版本>
+func (c *APIClient) endpoint(path string) string {
+	return c.baseURL + apiRoot + "/" + c.apiVersion + path
+}
+
+// isSuccessCode 判断响应中的业务码是否表示成功，不同接口分别使用 200 和 201
+func isSuccessCode(code int) bool {
+	return code >= http.StatusOK && code < http.StatusMultipleChoices
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/pkg/config"
+	"resty.dev/v3"
+)
+
+// maxRetryAfter 服务器通过 Retry-After 要求等待的最长时间，超过时按该值等待
+const maxRetryAfter = time.Minute
+
+// RetryPolicy 定义请求失败后的重试策略。只有幂等的请求 (GET 以及带幂等键的作业提交) 会被重试
+type RetryPolicy struct {
+	// MaxRetries 最多重试次数，0 表示不重试
+	MaxRetries int
+	// WaitMin 第一次重试前的等待时间，之后每次翻倍
+	WaitMin time.Duration
+	// WaitMax 两次重试之间的最长等待时间
+	WaitMax time.Duration
+}
+
+// DefaultRetryPolicy 未单独配置时使用的重试策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	WaitMin:    500 * time.Millisecond,
+	WaitMax:    10 * time.Second,
+}
+
+// ServerRetryPolicy 返回服务器配置对应的重试策略，未设置的项使用 DefaultRetryPolicy 中的值，
+// retries 为负数时不重试
+func ServerRetryPolicy(server *config.APIServerInfo) (RetryPolicy, error) {
+	policy := DefaultRetryPolicy
+	switch {
+	case server.Retries < 0:
+		policy.MaxRetries = 0
+	case server.Retries > 0:
+		policy.MaxRetries = server.Retries
+	}
+
+	var err error
+	if server.RetryWait != "" {
+		if policy.WaitMin, err = time.ParseDuration(server.RetryWait); err != nil || policy.WaitMin <= 0 {
+			return policy, fmt.Errorf("无效的 retry_wait: %s", server.RetryWait)
+		}
+	}
+	if server.RetryMaxWait != "" {
+		if policy.WaitMax, err = time.ParseDuration(server.RetryMaxWait); err != nil || policy.WaitMax <= 0 {
+			return policy, fmt.Errorf("无效的 retry_max_wait: %s", server.RetryMaxWait)
+		}
+	}
+	if policy.WaitMax < policy.WaitMin {
+		policy.WaitMax = policy.WaitMin
+	}
+	return policy, nil
+}
+
+// SetRetryPolicy 设置重试策略
+func (c *APIClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// backoff 返回第 attempt 次重试 (从 0 开始) 前的等待时间: 指数退避，并在后一半区间内随机抖动，
+// 避免多个客户端在服务器恢复时同时重试
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.WaitMax
+	if attempt < 32 {
+		if d := p.WaitMin << attempt; d > 0 && d < p.WaitMax {
+			wait = d
+		}
+	}
+	half := wait / 2
+	if half <= 0 {
+		return wait
+	}
+	return half + mathrand.N(half+1)
+}
+
+// retryWait 判断请求是否可以重试，可以时返回等待时间
+func (p RetryPolicy) retryWait(attempt int, resp *resty.Response, err error) (time.Duration, bool) {
+	if attempt >= p.MaxRetries {
+		return 0, false
+	}
+	if err != nil {
+		return p.backoff(attempt), isRetryableError(err)
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if wait, ok := parseRetryAfter(resp.Header().Get("Retry-After")); ok {
+			return wait, true
+		}
+		return p.backoff(attempt), true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return p.backoff(attempt), true
+	}
+	return 0, false
+}
+
+// isRetryableError 判断网络错误是否是服务器重启等暂时性的连接错误，证书校验失败等错误重试也不会成功
+func isRetryableError(err error) bool {
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) || errors.Is(err, cert.ErrFingerprintMismatch) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// parseRetryAfter 解析 Retry-After 响应头，支持秒数和 HTTP 日期两种格式
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		wait = time.Until(at)
+	} else {
+		return 0, false
+	}
+	return min(max(wait, 0), maxRetryAfter), true
+}
+
+// newIdempotencyKey 生成随机的幂等键 (UUID v4)，同一次作业提交的所有重试使用相同的键，
+// 服务器据此识别重复的提交
+func newIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// 随机数生成失败时退化为时间戳，仍然能区分不同的提交
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"resty.dev/v3"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: ""},
+		{value: "soon"},
+		{value: "0", wantOK: true},
+		{value: "5", want: 5 * time.Second, wantOK: true},
+		{value: "-5", want: 0, wantOK: true},
+		{value: "3600", want: maxRetryAfter, wantOK: true},
+		{value: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), want: 0, wantOK: true},
+		{value: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), want: maxRetryAfter, wantOK: true},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	// HTTP 日期精确到秒，等待时间在 (9s, 10s] 之间
+	got, ok := parseRetryAfter(time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat))
+	if !ok || got <= 8*time.Second || got > 10*time.Second {
+		t.Errorf("parseRetryAfter(now+10s) = %s, %v, want about 10s", got, ok)
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 2, WaitMin: 100 * time.Millisecond, WaitMax: time.Second}
+	response := func(status int, retryAfter string) *resty.Response {
+		header := http.Header{}
+		if retryAfter != "" {
+			header.Set("Retry-After", retryAfter)
+		}
+		return &resty.Response{RawResponse: &http.Response{StatusCode: status, Header: header}}
+	}
+
+	tests := []struct {
+		name      string
+		attempt   int
+		resp      *resty.Response
+		err       error
+		wantRetry bool
+		wantMin   time.Duration
+		wantMax   time.Duration
+	}{
+		{name: "502", resp: response(http.StatusBadGateway, ""), wantRetry: true, wantMin: 50 * time.Millisecond, wantMax: 100 * time.Millisecond},
+		{name: "504 第二次重试", attempt: 1, resp: response(http.StatusGatewayTimeout, ""), wantRetry: true, wantMin: 100 * time.Millisecond, wantMax: 200 * time.Millisecond},
+		{name: "503 没有 Retry-After", resp: response(http.StatusServiceUnavailable, ""), wantRetry: true, wantMin: 50 * time.Millisecond, wantMax: 100 * time.Millisecond},
+		{name: "503 Retry-After", resp: response(http.StatusServiceUnavailable, "3"), wantRetry: true, wantMin: 3 * time.Second, wantMax: 3 * time.Second},
+		{name: "429 Retry-After", resp: response(http.StatusTooManyRequests, "2"), wantRetry: true, wantMin: 2 * time.Second, wantMax: 2 * time.Second},
+		{name: "502 忽略 Retry-After", resp: response(http.StatusBadGateway, "30"), wantRetry: true, wantMin: 50 * time.Millisecond, wantMax: 100 * time.Millisecond},
+		{name: "500 不重试", resp: response(http.StatusInternalServerError, "")},
+		{name: "404 不重试", resp: response(http.StatusNotFound, "")},
+		{name: "达到最多重试次数", attempt: 2, resp: response(http.StatusServiceUnavailable, "")},
+		{name: "连接被拒绝", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, wantRetry: true, wantMin: 50 * time.Millisecond, wantMax: 100 * time.Millisecond},
+		{name: "证书校验失败", err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, retry := policy.retryWait(tt.attempt, tt.resp, tt.err)
+			if retry != tt.wantRetry {
+				t.Fatalf("retryWait() retry = %v, want %v", retry, tt.wantRetry)
+			}
+			if retry && (wait < tt.wantMin || wait > tt.wantMax) {
+				t.Errorf("retryWait() wait = %s, want between %s and %s", wait, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &net.OpError{Op: "dial", Err: errors.New("no route to host")}, want: true},
+		{err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{err: fmt.Errorf("write: %w", syscall.EPIPE), want: true},
+		{err: fmt.Errorf("Get: %w", io.EOF), want: true},
+		{err: io.ErrUnexpectedEOF, want: true},
+		{err: &net.DNSError{Err: "timeout", IsTimeout: true}, want: true},
+		{err: &net.OpError{Op: "read", Err: errors.New("use of closed network connection")}},
+		{err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}},
+		{err: fmt.Errorf("握手失败: %w", cert.ErrFingerprintMismatch)},
+		{err: errors.New("请求内容无效")},
+	}
+	for _, tt := range tests {
+		if got := isRetryableError(tt.err); got != tt.want {
+			t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zhengyansheng/xcli/internal/cert"
+	"github.com/zhengyansheng/xcli/internal/transport"
+	"github.com/zhengyansheng/xcli/pkg/config"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ClientKeyPassphraseEnv 客户端私钥或 PKCS#12 文件密码的环境变量
+const ClientKeyPassphraseEnv = "CLI_CLIENT_KEY_PASSPHRASE"
+
+// TLSOptions 定义单个服务器的 TLS 校验选项
+type TLSOptions struct {
+	// CACert 服务器的 CA 证书或首次登录时固定的服务器证书
+	CACert string
+	// CABundle 额外信任的 CA 证书包
+	CABundle string
+	// SystemRoots 是否同时信任系统证书库
+	SystemRoots bool
+	// Fingerprint 固定的服务器证书指纹
+	Fingerprint string
+	// ServerName TLS 握手及证书校验使用的服务器名称 (SNI)
+	ServerName string
+	// ClientCert 客户端证书 (PEM)
+	ClientCert string
+	// ClientKey 客户端私钥 (PEM，可加密)
+	ClientKey string
+	// PKCS12 包含客户端证书和私钥的 PKCS#12 文件
+	PKCS12 string
+	// KeyPassphrase 加密私钥或 PKCS#12 文件的密码
+	KeyPassphrase string
+}
+
+// HasClientCert 是否配置了客户端证书
+func (o TLSOptions) HasClientCert() bool {
+	return o.ClientCert != "" || o.PKCS12 != ""
+}
+
+// ServerTLSOptions 返回服务器配置对应的 TLS 选项
+func ServerTLSOptions(server *config.APIServerInfo) TLSOptions {
+	return TLSOptions{
+		CACert:        server.CACert,
+		CABundle:      server.CABundle,
+		SystemRoots:   server.SystemCA,
+		Fingerprint:   server.Fingerprint,
+		ServerName:    server.TLSServerName,
+		ClientCert:    server.ClientCert,
+		ClientKey:     server.ClientKey,
+		PKCS12:        server.PKCS12,
+		KeyPassphrase: os.Getenv(ClientKeyPassphraseEnv),
+	}
+}
+
+// NewServerClient 根据服务器配置创建 API 客户端，并设置该服务器的 API 版本、能力、网络选项、TLS 校验和重试策略
+func NewServerClient(server *config.APIServerInfo, opts ...Option) (*APIClient, error) {
+	c := NewAPIClient(server.URL, opts...)
+	if err := c.SetAPIVersion(server.APIVersion); err != nil {
+		return nil, fmt.Errorf("服务器 %s 的 API 版本配置无效: %v", server.URL, err)
+	}
+	c.SetCapabilities(ServerCapabilities(server))
+	netOpts, err := transport.ServerOptions(server)
+	if err != nil {
+		return nil, fmt.Errorf("服务器 %s 的网络配置无效: %v", server.URL, err)
+	}
+	c.SetTransportOptions(netOpts)
+	if err := c.SetTLSOptions(ServerTLSOptions(server)); err != nil {
+		return nil, fmt.Errorf("设置服务器 %s 的 TLS 配置失败: %v", server.URL, err)
+	}
+	policy, err := ServerRetryPolicy(server)
+	if err != nil {
+		return nil, fmt.Errorf("服务器 %s 的重试配置无效: %v", server.URL, err)
+	}
+	c.SetRetryPolicy(policy)
+	return c, nil
+}
+
+// NewTLSConfig 根据 TLS 选项构建 tls.Config，包括证书池、客户端证书和指纹校验
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	rootCAs, err := loadRootCAs(opts)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		RootCAs:    rootCAs,
+		ServerName: opts.ServerName,
+	}
+
+	// 双向 TLS，设置客户端证书
+	if opts.HasClientCert() {
+		clientCert, err := loadClientCertificate(opts)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{*clientCert}
+	}
+
+	if opts.Fingerprint != "" {
+		tlsConfig.VerifyPeerCertificate = pinVerifier(opts.Fingerprint)
+	}
+	return tlsConfig, nil
+}
+
+// SetTLSOptions 设置客户端的 TLS 校验，每个客户端只使用其服务器自己的证书池
+func (c *APIClient) SetTLSOptions(opts TLSOptions) error {
+	if !strings.HasPrefix(c.baseURL, "https://") {
+		return nil
+	}
+
+	tlsConfig, err := NewTLSConfig(opts)
+	if err != nil {
+		return err
+	}
+	return c.SetTLSConfig(tlsConfig)
+}
+
+// SetPinnedFingerprint 固定服务器证书指纹，握手时证书与指纹不一致将直接失败
+func (c *APIClient) SetPinnedFingerprint(fingerprint string) {
+	if fingerprint == "" || !strings.HasPrefix(c.baseURL, "https://") {
+		return
+	}
+
+	tlsConfig := &tls.Config{}
+	if current := c.client.TLSClientConfig(); current != nil {
+		tlsConfig = current.Clone()
+	}
+	tlsConfig.VerifyPeerCertificate = pinVerifier(fingerprint)
+	c.client.SetTLSClientConfig(tlsConfig)
+}
+
+// pinVerifier 返回校验服务器证书指纹的回调
+func pinVerifier(fingerprint string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("服务器未提供证书")
+		}
+		return cert.VerifyPin(rawCerts[0], fingerprint)
+	}
+}
+
+// loadRootCAs 加载根证书，未配置任何证书时返回 nil 以使用系统证书库
+func loadRootCAs(opts TLSOptions) (*x509.CertPool, error) {
+	if opts.CACert == "" && opts.CABundle == "" {
+		return nil, nil
+	}
+
+	certPool := x509.NewCertPool()
+	if opts.SystemRoots {
+		systemPool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("加载系统证书库失败: %v", err)
+		}
+		certPool = systemPool
+	}
+
+	for _, certPath := range []string{opts.CACert, opts.CABundle} {
+		if certPath == "" {
+			continue
+		}
+		pemData, err := os.ReadFile(certPath)
+		if err != nil {
+			return nil, fmt.Errorf("无法读取证书文件: %v", err)
+		}
+		if ok := certPool.AppendCertsFromPEM(pemData); !ok {
+			return nil, fmt.Errorf("证书文件 %s 中没有有效的 PEM 证书", certPath)
+		}
+	}
+	return certPool, nil
+}
+
+// loadClientCertificate 加载客户端证书，支持 PEM 证书加私钥（私钥可加密）以及 PKCS#12 文件
+func loadClientCertificate(opts TLSOptions) (*tls.Certificate, error) {
+	if opts.PKCS12 != "" {
+		return loadPKCS12(opts.PKCS12, opts.KeyPassphrase)
+	}
+
+	if opts.ClientKey == "" {
+		return nil, fmt.Errorf("已设置客户端证书 %s，但未设置客户端私钥", opts.ClientCert)
+	}
+	certPEM, err := os.ReadFile(opts.ClientCert)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取客户端证书: %v", err)
+	}
+	keyPEM, err := os.ReadFile(opts.ClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取客户端私钥: %v", err)
+	}
+
+	keyPEM, err = decryptKeyPEM(keyPEM, opts.KeyPassphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("客户端证书与私钥无效: %v", err)
+	}
+	return &clientCert, nil
+}
+
+// decryptKeyPEM 解密使用密码保护的 PEM 私钥，支持加密的 PKCS#8 私钥 (ENCRYPTED PRIVATE KEY，OpenSSL 3 的默认格式)
+// 和 OpenSSL 传统格式 (Proc-Type: 4,ENCRYPTED)，未加密的私钥原样返回
+func decryptKeyPEM(keyPEM []byte, passphrase string) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("客户端私钥不是有效的 PEM 格式")
+	}
+	encryptedPKCS8 := block.Type == encryptedPKCS8Type
+	if !encryptedPKCS8 && !x509.IsEncryptedPEMBlock(block) {
+		return keyPEM, nil
+	}
+
+	if passphrase == "" {
+		return nil, fmt.Errorf("客户端私钥已加密，请通过环境变量 %s 提供密码", ClientKeyPassphraseEnv)
+	}
+	if encryptedPKCS8 {
+		der, err := decryptPKCS8(block.Bytes, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("解密客户端私钥失败: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+	der, err := x509.DecryptPEMBlock(block, []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("解密客户端私钥失败: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+}
+
+// loadPKCS12 从 PKCS#12 文件加载客户端证书、私钥及中间证书
+func loadPKCS12(pkcs12Path, passphrase string) (*tls.Certificate, error) {
+	pfxData, err := os.ReadFile(pkcs12Path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取 PKCS#12 文件: %v", err)
+	}
+
+	privateKey, leaf, caCerts, err := pkcs12.DecodeChain(pfxData, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("解析 PKCS#12 文件失败: %v", err)
+	}
+
+	clientCert := &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  privateKey,
+		Leaf:        leaf,
+	}
+	for _, ca := range caCerts {
+		clientCert.Certificate = append(clientCert.Certificate, ca.Raw)
+	}
+	return clientCert, nil
+}
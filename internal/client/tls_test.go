@@ -0,0 +1,169 @@
+package client_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// testPassphrase testdata 中加密私钥的密码
+const testPassphrase = "test-passphrase"
+
+// issueCert 签发证书，parent 为 nil 时生成自签名的 CA 证书
+func issueCert(t *testing.T, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// writeFile 将内容写入临时目录中的文件，返回文件路径
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestClientCertificate(t *testing.T) {
+	s := xcetest.NewTLSServer()
+	defer s.Close()
+	dir := t.TempDir()
+	caCert := writeFile(t, dir, "server.crt", s.CertPEM())
+
+	// 由中间 CA 签发的客户端证书，PKCS#12 文件中包含中间 CA
+	root, rootKey := issueCert(t, "root", true, nil, nil)
+	intermediate, intermediateKey := issueCert(t, "intermediate", true, root, rootKey)
+	leaf, leafKey := issueCert(t, "alice", false, intermediate, intermediateKey)
+	leafCert := writeFile(t, dir, "alice.crt", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}))
+	der, err := x509.MarshalPKCS8PrivateKey(leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafKeyFile := writeFile(t, dir, "alice.key", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	pfx, err := pkcs12.Modern.Encode(leafKey, leaf, []*x509.Certificate{intermediate}, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pfxFile := writeFile(t, dir, "alice.p12", pfx)
+
+	tests := []struct {
+		name      string
+		opts      client.TLSOptions
+		wantChain []string
+		wantErr   string
+	}{
+		{
+			name:      "PEM 私钥",
+			opts:      client.TLSOptions{ClientCert: leafCert, ClientKey: leafKeyFile},
+			wantChain: []string{"alice"},
+		},
+		{
+			name:      "传统格式的加密私钥",
+			opts:      client.TLSOptions{ClientCert: "testdata/client.crt", ClientKey: "testdata/client-legacy.key", KeyPassphrase: testPassphrase},
+			wantChain: []string{"alice"},
+		},
+		{
+			name:      "PKCS#8 AES-256 加密私钥",
+			opts:      client.TLSOptions{ClientCert: "testdata/client.crt", ClientKey: "testdata/client-aes256.key", KeyPassphrase: testPassphrase},
+			wantChain: []string{"alice"},
+		},
+		{
+			name:      "PKCS#8 3DES 加密私钥",
+			opts:      client.TLSOptions{ClientCert: "testdata/client.crt", ClientKey: "testdata/client-des3.key", KeyPassphrase: testPassphrase},
+			wantChain: []string{"alice"},
+		},
+		{
+			name:      "PKCS#12 证书链",
+			opts:      client.TLSOptions{PKCS12: pfxFile, KeyPassphrase: testPassphrase},
+			wantChain: []string{"alice", "intermediate"},
+		},
+		{
+			name:    "未提供密码",
+			opts:    client.TLSOptions{ClientCert: "testdata/client.crt", ClientKey: "testdata/client-aes256.key"},
+			wantErr: client.ClientKeyPassphraseEnv,
+		},
+		{
+			name:    "密码错误",
+			opts:    client.TLSOptions{ClientCert: "testdata/client.crt", ClientKey: "testdata/client-aes256.key", KeyPassphrase: "wrong"},
+			wantErr: "密码错误",
+		},
+		{
+			name:    "不支持的 PKCS#8 加密方式",
+			opts:    client.TLSOptions{ClientCert: "testdata/client.crt", ClientKey: "testdata/client-pbes1.key", KeyPassphrase: testPassphrase},
+			wantErr: "不支持的 PKCS#8 私钥加密方式",
+		},
+		{
+			name:    "PKCS#12 密码错误",
+			opts:    client.TLSOptions{PKCS12: pfxFile, KeyPassphrase: "wrong"},
+			wantErr: "解析 PKCS#12 文件失败",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.CACert = caCert
+			c := client.NewAPIClient(s.URL())
+			err := c.SetTLSOptions(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetTLSOptions() = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetTLSOptions: %v", err)
+			}
+
+			if _, err := c.LogonWithCert(context.Background(), "alice"); err != nil {
+				t.Fatalf("LogonWithCert: %v", err)
+			}
+			requests := s.Requests()
+			var chain []string
+			for _, cert := range requests[len(requests)-1].PeerCertificates {
+				chain = append(chain, cert.Subject.CommonName)
+			}
+			if strings.Join(chain, ",") != strings.Join(tt.wantChain, ",") {
+				t.Errorf("client certificate chain = %v, want %v", chain, tt.wantChain)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"resty.dev/v3"
+)
+
+// 日志级别，级别越高输出的请求信息越详细
+const (
+	// traceLevelURL 输出请求方法、地址、状态码和总耗时
+	traceLevelURL = 6
+	// traceLevelTiming 输出 DNS、建立连接、TLS 握手和首字节的耗时
+	traceLevelTiming = 7
+	// traceLevelHeaders 输出请求头和响应头
+	traceLevelHeaders = 8
+	// traceLevelBody 输出请求和响应的内容
+	traceLevelBody = 9
+)
+
+// maxLoggedBody 日志中输出的请求和响应内容的最大长度，HAR 记录不受此限制
+const maxLoggedBody = 10240
+
+// redactedValue 敏感信息脱敏后的值
+const redactedValue = "******"
+
+// sensitiveHeaders 需要脱敏的请求头和响应头
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// sensitiveFields 请求和响应内容中需要脱敏的 JSON 字段，忽略大小写
+var sensitiveFields = map[string]bool{
+	"password":   true,
+	"passwd":     true,
+	"passphrase": true,
+	"token":      true,
+	"secret":     true,
+}
+
+// timings 记录单次请求各阶段的时间点
+type timings struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	end          time.Time
+	reused       bool
+}
+
+// clientTrace 返回记录各阶段时间点的 httptrace.ClientTrace
+func (t *timings) clientTrace() *httptrace.ClientTrace {
+	record := func(at *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		*at = time.Now()
+	}
+	// 同时尝试多个地址时，连接开始取最早的一次，结束取最后的一次
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { record(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { record(&t.dnsDone) },
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone:       func(string, string, error) { record(&t.connectDone) },
+		TLSHandshakeStart: func() { record(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { record(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			record(&t.gotConn)
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.reused = info.Reused
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { record(&t.wroteRequest) },
+		GotFirstResponseByte: func() { record(&t.firstByte) },
+	}
+}
+
+// between 返回两个时间点的间隔，任一时间点未记录时返回 -1
+func between(from, to time.Time) time.Duration {
+	if from.IsZero() || to.IsZero() {
+		return -1
+	}
+	return to.Sub(from)
+}
+
+// phase 返回用于日志输出的阶段耗时，未经过该阶段 (如复用连接时的 DNS) 时输出 -
+func phase(from, to time.Time) any {
+	if d := between(from, to); d >= 0 {
+		return d
+	}
+	return "-"
+}
+
+// tracing 是否需要记录请求的详细信息
+func (c *APIClient) tracing() bool {
+	return c.log.V(traceLevelURL).Enabled() || harTracing()
+}
+
+// trace 按日志级别输出请求的详细信息，并在开启 HAR 记录时保存本次请求
+func (c *APIClient) trace(r *resty.Request, resp *resty.Response, err error, t *timings) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ex := newExchange(r, resp, err, t)
+	if harTracing() {
+		recordHAR(ex)
+	}
+
+	if log := c.log.V(traceLevelURL); log.Enabled() {
+		if err != nil {
+			log.Info("HTTP 请求失败", "method", ex.method, "url", ex.url, "duration", ex.total(), "err", err)
+		} else {
+			log.Info("HTTP 请求", "method", ex.method, "url", ex.url, "status", ex.status, "duration", ex.total())
+		}
+	}
+	if log := c.log.V(traceLevelTiming); log.Enabled() {
+		log.Info("HTTP 请求耗时",
+			"dns", phase(t.dnsStart, t.dnsDone),
+			"connect", phase(t.connectStart, t.connectDone),
+			"tls", phase(t.tlsStart, t.tlsDone),
+			"ttfb", phase(t.start, t.firstByte),
+			"reused", t.reused)
+	}
+	if log := c.log.V(traceLevelHeaders); log.Enabled() {
+		log.Info("HTTP 请求头", "headers", formatHeaders(ex.requestHeader))
+		if resp != nil && resp.RawResponse != nil {
+			log.Info("HTTP 响应头", "status", ex.status, "headers", formatHeaders(ex.responseHeader))
+		}
+	}
+	if log := c.log.V(traceLevelBody); log.Enabled() {
+		if ex.requestBody != "" {
+			log.Info("HTTP 请求内容", "body", truncate(ex.requestBody, maxLoggedBody))
+		}
+		if ex.responseBody != "" {
+			log.Info("HTTP 响应内容", "body", truncate(ex.responseBody, maxLoggedBody))
+		}
+	}
+}
+
+// exchange 一次已脱敏的请求和响应
+type exchange struct {
+	method         string
+	url            string
+	proto          string
+	requestHeader  http.Header
+	requestBody    string
+	status         int
+	statusText     string
+	responseProto  string
+	responseHeader http.Header
+	responseBody   string
+	err            error
+	timings        *timings
+}
+
+func newExchange(r *resty.Request, resp *resty.Response, err error, t *timings) *exchange {
+	ex := &exchange{
+		method:  r.Method,
+		url:     r.URL,
+		err:     err,
+		timings: t,
+	}
+
+	header := r.Header
+	if raw := r.RawRequest; raw != nil {
+		ex.url = raw.URL.String()
+		ex.proto = raw.Proto
+		header = raw.Header
+	}
+	if u, parseErr := url.Parse(ex.url); parseErr == nil {
+		ex.url = u.Redacted()
+	}
+	ex.requestHeader = redactHeaders(header)
+	ex.requestBody = redactBody(bodyBytes(r.Body))
+
+	if resp != nil && resp.RawResponse != nil {
+		ex.status = resp.StatusCode()
+		ex.statusText = http.StatusText(ex.status)
+		ex.responseProto = resp.Proto()
+		ex.responseHeader = redactHeaders(resp.Header())
+		ex.responseBody = redactBody(resp.Bytes())
+	}
+	return ex
+}
+
+// total 返回请求的总耗时
+func (ex *exchange) total() time.Duration {
+	return between(ex.timings.start, ex.timings.end)
+}
+
+// bodyBytes 返回请求内容，结构体等按 json 格式编码
+func bodyBytes(body any) []byte {
+	switch b := body.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return b
+	case string:
+		return []byte(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return []byte(fmt.Sprintf("%v", b))
+		}
+		return data
+	}
+}
+
+// redactHeaders 返回将认证信息脱敏后的请求头副本，Authorization 保留认证方式
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name, values := range redacted {
+		if !sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		for i, v := range values {
+			if scheme, _, ok := strings.Cut(v, " "); ok && strings.HasSuffix(name, "Authorization") {
+				values[i] = scheme + " " + redactedValue
+			} else {
+				values[i] = redactedValue
+			}
+		}
+	}
+	return redacted
+}
+
+// redactBody 将 JSON 内容中的密码、令牌等字段脱敏，不是 JSON 时原样返回
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	if !redactValue(v) {
+		return string(body)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+// redactValue 递归脱敏敏感字段，返回是否有字段被修改
+func redactValue(v any) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if sensitiveFields[strings.ToLower(key)] {
+				if s, ok := value.(string); !ok || s != "" {
+					v[key] = redactedValue
+					changed = true
+				}
+				continue
+			}
+			changed = redactValue(value) || changed
+		}
+	case []any:
+		for _, item := range v {
+			changed = redactValue(item) || changed
+		}
+	}
+	return changed
+}
+
+// formatHeaders 按名称排序，每行输出一个请求头
+func formatHeaders(header http.Header) string {
+	var b strings.Builder
+	for _, name := range sortedKeys(header) {
+		for _, v := range header[name] {
+			fmt.Fprintf(&b, "%s: %s\n", name, v)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// sortedKeys 返回排序后的请求头或查询参数名称
+func sortedKeys(values map[string][]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// truncate 截断过长的内容
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return fmt.Sprintf("%s... (共 %d 字节)", s[:n], len(s))
+}
@@ -0,0 +1,157 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+)
+
+// harLog 测试中读取的 HAR 文件内容
+type harLog struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+			Response struct {
+				Status  int `json:"status"`
+				Content struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+func readHAR(t *testing.T, path string) (harLog, string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var har harLog
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("%s is not a HAR file: %v\n%s", path, err, data)
+	}
+	return har, string(data)
+}
+
+func TestTraceRedaction(t *testing.T) {
+	const password = "correct-horse-battery"
+	s := xcetest.NewServer(xcetest.WithUser("alice", password))
+	defer s.Close()
+
+	var mu sync.Mutex
+	var logged strings.Builder
+	c := client.NewAPIClient(s.URL())
+	c.SetLogger(funcr.New(func(prefix, args string) {
+		mu.Lock()
+		defer mu.Unlock()
+		logged.WriteString(args + "\n")
+	}, funcr.Options{Verbosity: 9}))
+
+	client.StartHARTrace()
+	resp, err := c.Logon(context.Background(), "alice", password)
+	if err != nil {
+		t.Fatalf("Logon: %v", err)
+	}
+	token := resp.Data.Token
+	if _, err := c.GetJobs(context.Background(), token, nil, client.Page{}); err != nil {
+		t.Fatalf("GetJobs: %v", err)
+	}
+	harFile := filepath.Join(t.TempDir(), "trace.har")
+	if err := client.WriteHARTrace(harFile); err != nil {
+		t.Fatal(err)
+	}
+
+	// 调试日志中只有脱敏后的密码、token 和 Authorization 头
+	for _, secret := range []string{password, token} {
+		if strings.Contains(logged.String(), secret) {
+			t.Errorf("debug log contains %q:\n%s", secret, logged.String())
+		}
+	}
+	for _, want := range []string{`\"password\":\"******\"`, `\"token\":\"******\"`, "Authorization: Bearer ******"} {
+		if !strings.Contains(logged.String(), want) {
+			t.Errorf("debug log does not contain %s:\n%s", want, logged.String())
+		}
+	}
+
+	har, raw := readHAR(t, harFile)
+	for _, secret := range []string{password, token} {
+		if strings.Contains(raw, secret) {
+			t.Errorf("HAR file contains %q:\n%s", secret, raw)
+		}
+	}
+	if n := len(har.Log.Entries); n != 2 {
+		t.Fatalf("HAR file has %d entries, want logon and jobs", n)
+	}
+	logon, jobs := har.Log.Entries[0], har.Log.Entries[1]
+	if logon.Request.PostData == nil || !strings.Contains(logon.Request.PostData.Text, `"password":"******"`) {
+		t.Errorf("logon request body = %+v, want the password redacted", logon.Request.PostData)
+	}
+	if !strings.Contains(logon.Response.Content.Text, `"token":"******"`) {
+		t.Errorf("logon response = %s, want the token redacted", logon.Response.Content.Text)
+	}
+	var authorization string
+	for _, h := range jobs.Request.Headers {
+		if h.Name == "Authorization" {
+			authorization = h.Value
+		}
+	}
+	if authorization != "Bearer ******" {
+		t.Errorf("jobs request Authorization = %q, want Bearer ******", authorization)
+	}
+}
+
+func TestHARTraceReset(t *testing.T) {
+	s := xcetest.NewServer()
+	defer s.Close()
+	c := client.NewAPIClient(s.URL())
+	version := func() {
+		t.Helper()
+		if _, err := c.GetVersion(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := t.TempDir()
+
+	// 之前未写入的记录在重新开始时丢弃
+	client.StartHARTrace()
+	version()
+	client.StartHARTrace()
+	version()
+	first := filepath.Join(dir, "first.har")
+	if err := client.WriteHARTrace(first); err != nil {
+		t.Fatal(err)
+	}
+	if har, _ := readHAR(t, first); len(har.Log.Entries) != 1 {
+		t.Errorf("first trace has %d entries, want 1", len(har.Log.Entries))
+	}
+
+	// 写入后停止记录，下一次记录不包含之前的请求
+	version()
+	client.StartHARTrace()
+	second := filepath.Join(dir, "second.har")
+	if err := client.WriteHARTrace(second); err != nil {
+		t.Fatal(err)
+	}
+	if har, _ := readHAR(t, second); len(har.Log.Entries) != 0 {
+		t.Errorf("second trace has %d entries, want none", len(har.Log.Entries))
+	}
+}
@@ -0,0 +1,226 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// versionPath 服务器版本接口的路径，不区分 API 版本且不需要登录
+const versionPath = apiRoot + "/version"
+
+// API 版本，对应接口路径前缀 /xce/<版本>
+const (
+	APIVersionV1 = "v1"
+	APIVersionV2 = "v2"
+)
+
+// DefaultAPIVersion 未协商 API 版本时使用的版本，不提供版本接口的旧服务器只支持该版本
+const DefaultAPIVersion = APIVersionV1
+
+// SupportedAPIVersions 客户端支持的 API 版本，按优先级从高到低排列
+var SupportedAPIVersions = []string{APIVersionV2, APIVersionV1}
+
+// 服务器能力，由版本接口返回，客户端据此决定是否使用对应的功能
+const (
+	// CapCertLogon 使用客户端证书登录
+	CapCertLogon = "cert-logon"
+	// CapPagination 查询作业和主机时按 limit/offset 分页
+	CapPagination = "pagination"
+	// CapJobFields 查询作业时通过 fields 指定返回的字段
+	CapJobFields = "job-fields"
+	// CapIdempotency 提交作业时通过 Idempotency-Key 识别重复的请求
+	CapIdempotency = "idempotency"
+	// CapJobIDFilter 查询作业时通过 jobid:in:1|2|3 按多个作业 ID 过滤
+	CapJobIDFilter = "jobid-filter"
+)
+
+// capabilityNames 服务器能力对应的功能说明，用于错误信息
+var capabilityNames = map[string]string{
+	CapCertLogon:   "证书登录",
+	CapPagination:  "分页查询",
+	CapJobFields:   "按字段查询作业",
+	CapIdempotency: "幂等提交",
+	CapJobIDFilter: "按作业 ID 批量查询作业",
+}
+
+// ErrUnsupported 服务器不支持请求的功能，可以通过 errors.Is 判断
+var ErrUnsupported = errors.New("服务器不支持该功能")
+
+// UnsupportedError 服务器不支持请求的功能或 API 版本
+type UnsupportedError struct {
+	// Feature 不支持的功能
+	Feature string
+	// Server 服务器地址
+	Server string
+}
+
+// Error 返回错误信息
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("服务器 %s 不支持%s，请升级服务器或更换 APIserver", e.Server, e.Feature)
+}
+
+// Is 匹配 ErrUnsupported
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// VersionInfo 服务器版本接口返回的数据
+type VersionInfo struct {
+	// Version 服务器软件版本，如 2.1.0
+	Version string `json:"version"`
+	// APIVersions 服务器支持的 API 版本，如 [v1 v2]
+	APIVersions []string `json:"apiVersions"`
+	// Capabilities 服务器支持的能力，如 pagination
+	Capabilities []string `json:"capabilities"`
+}
+
+// GetVersion 查询服务器版本、支持的 API 版本和能力。不提供版本接口的旧服务器返回 ErrNotFound
+func (c *APIClient) GetVersion(ctx context.Context) (*VersionInfo, error) {
+	resp, err := do[VersionInfo](ctx, c, request{
+		op:   "查询服务器版本",
+		verb: http.MethodGet,
+		url:  c.baseURL + versionPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// Negotiate 查询服务器版本，选择双方都支持的 API 版本并记录服务器的能力。
+// apiVersion 非空时使用指定的版本，服务器不支持时返回 *UnsupportedError；
+// 旧服务器没有版本接口时使用 DefaultAPIVersion，且不限制任何功能
+func (c *APIClient) Negotiate(ctx context.Context, apiVersion string) (*VersionInfo, error) {
+	info, err := c.GetVersion(ctx)
+	if err != nil {
+		if !isLegacyServer(err) {
+			return nil, err
+		}
+		c.log.V(2).Info(fmt.Sprintf("服务器 %s 没有版本接口，使用 API %s", c.baseURL, DefaultAPIVersion))
+		info = &VersionInfo{APIVersions: []string{DefaultAPIVersion}}
+	}
+
+	selected, err := c.selectAPIVersion(info.APIVersions, apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	c.apiVersion = selected
+	c.SetCapabilities(info.Capabilities)
+	return info, nil
+}
+
+// selectAPIVersion 返回客户端和服务器都支持的 API 版本，apiVersion 非空时只能使用该版本
+func (c *APIClient) selectAPIVersion(serverVersions []string, apiVersion string) (string, error) {
+	candidates := SupportedAPIVersions
+	if apiVersion != "" {
+		if err := checkAPIVersion(apiVersion); err != nil {
+			return "", err
+		}
+		candidates = []string{apiVersion}
+	}
+	for _, v := range candidates {
+		if slices.Contains(serverVersions, v) {
+			return v, nil
+		}
+	}
+	return "", &UnsupportedError{
+		Feature: fmt.Sprintf(" API %s (服务器支持: %s)", strings.Join(candidates, "/"), strings.Join(serverVersions, ", ")),
+		Server:  c.baseURL,
+	}
+}
+
+// isLegacyServer 判断版本查询失败是否是因为服务器没有版本接口
+func isLegacyServer(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// checkAPIVersion 检查客户端是否支持该 API 版本
+func checkAPIVersion(apiVersion string) error {
+	if !slices.Contains(SupportedAPIVersions, apiVersion) {
+		return fmt.Errorf("不支持的 API 版本 %s，可选: %s", apiVersion, strings.Join(SupportedAPIVersions, ", "))
+	}
+	return nil
+}
+
+// APIVersion 返回客户端使用的 API 版本
+func (c *APIClient) APIVersion() string {
+	return c.apiVersion
+}
+
+// SetAPIVersion 设置客户端使用的 API 版本，为空时使用 DefaultAPIVersion
+func (c *APIClient) SetAPIVersion(apiVersion string) error {
+	if apiVersion == "" {
+		apiVersion = DefaultAPIVersion
+	}
+	if err := checkAPIVersion(apiVersion); err != nil {
+		return err
+	}
+	c.apiVersion = apiVersion
+	return nil
+}
+
+// SetCapabilities 设置服务器支持的能力。nil 表示未知，不限制任何功能；
+// 非 nil 的空列表表示服务器声明不支持任何可选能力
+func (c *APIClient) SetCapabilities(capabilities []string) {
+	c.negotiated = capabilities != nil
+	c.capabilities = make(map[string]bool, len(capabilities))
+	for _, capability := range capabilities {
+		c.capabilities[capability] = true
+	}
+}
+
+// Supports 判断服务器是否支持该能力，能力未知时返回 true
+func (c *APIClient) Supports(capability string) bool {
+	return !c.negotiated || c.capabilities[capability]
+}
+
+// advertises 判断服务器是否明确声明了该能力，能力未知时返回 false。
+// 用于依赖服务器配合才安全的行为，如重试提交作业
+func (c *APIClient) advertises(capability string) bool {
+	return c.negotiated && c.capabilities[capability]
+}
+
+// ServerCapabilities 返回服务器配置中记录的能力，登录时未协商到能力 (如旧服务器) 时返回 nil
+func ServerCapabilities(server *config.APIServerInfo) []string {
+	if server.Capabilities == nil {
+		return nil
+	}
+	return ParseCapabilities(*server.Capabilities)
+}
+
+// require 服务器不支持该能力时返回 *UnsupportedError
+func (c *APIClient) require(capability string) error {
+	if c.Supports(capability) {
+		return nil
+	}
+	feature, ok := capabilityNames[capability]
+	if !ok {
+		feature = capability
+	}
+	return &UnsupportedError{Feature: feature, Server: c.baseURL}
+}
+
+// ParseCapabilities 解析配置中以逗号分隔的能力列表，返回的列表非 nil，即使 value 为空也表示能力已知
+func ParseCapabilities(value string) []string {
+	capabilities := []string{}
+	for _, capability := range strings.Split(value, ",") {
+		if capability = strings.TrimSpace(capability); capability != "" {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	return capabilities
+}
@@ -0,0 +1,96 @@
+package table
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// columnPadding 列之间的空格数，与 tabwriter 的用法保持一致
+const columnPadding = 3
+
+// StreamWriter 逐行输出表格。前 buffer 行缓存后用于计算列宽，之后的行直接按该列宽输出，
+// 这样大量数据可以边查询边输出，而不必像 tabwriter 一样等到全部数据到达后再对齐
+type StreamWriter struct {
+	out    io.Writer
+	header []string
+	buffer int
+	rows   [][]string
+	widths []int
+	rowsN  int
+}
+
+// NewStreamWriter 创建表格输出，buffer 为计算列宽前缓存的行数
+func NewStreamWriter(out io.Writer, buffer int, header ...string) *StreamWriter {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	return &StreamWriter{
+		out:    out,
+		header: header,
+		buffer: buffer,
+	}
+}
+
+// Rows 返回已写入的行数
+func (w *StreamWriter) Rows() int {
+	return w.rowsN
+}
+
+// Append 写入一行，缓存的行数达到 buffer 时确定列宽并输出
+func (w *StreamWriter) Append(cells ...string) error {
+	w.rowsN++
+	if w.widths != nil {
+		return w.writeRow(cells)
+	}
+	w.rows = append(w.rows, cells)
+	if len(w.rows) >= w.buffer {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush 输出缓存的行，第一次调用时根据表头和缓存的行确定列宽
+func (w *StreamWriter) Flush() error {
+	if w.widths == nil {
+		w.widths = make([]int, len(w.header))
+		for _, row := range append([][]string{w.header}, w.rows...) {
+			for i, cell := range row {
+				if i < len(w.widths) {
+					w.widths[i] = max(w.widths[i], utf8.RuneCountInString(cell))
+				}
+			}
+		}
+		if err := w.writeRow(w.header); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range w.rows {
+		if err := w.writeRow(row); err != nil {
+			return err
+		}
+	}
+	w.rows = nil
+	return nil
+}
+
+// writeRow 按列宽输出一行，超过列宽的内容会把后面的列向右推
+func (w *StreamWriter) writeRow(cells []string) error {
+	var b strings.Builder
+	for i, cell := range cells {
+		b.WriteString(cell)
+		if i == len(cells)-1 {
+			break
+		}
+		width := 0
+		if i < len(w.widths) {
+			width = w.widths[i]
+		}
+		pad := max(width-utf8.RuneCountInString(cell), 0) + columnPadding
+		b.WriteString(strings.Repeat(" ", pad))
+	}
+	_, err := fmt.Fprintln(w.out, b.String())
+	return err
+}
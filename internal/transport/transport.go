@@ -0,0 +1,189 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zhengyansheng/xcli/pkg/config"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// DefaultConnectTimeout 未单独配置时建立连接的超时时间
+const DefaultConnectTimeout = 30 * time.Second
+
+// Options 定义连接单个服务器的网络选项
+type Options struct {
+	// Proxy 代理地址，支持 http、https 和 socks5，为空时使用 HTTPS_PROXY 等环境变量
+	Proxy string
+	// NoProxy 不使用代理的主机，格式与 NO_PROXY 环境变量相同，与环境变量中的设置合并
+	NoProxy string
+	// Hosts 静态解析，键为主机名或 主机名:端口，值为 IP 地址或 IP:端口
+	Hosts map[string]string
+	// UnixSocket 通过 Unix 域套接字连接服务器，设置后不使用代理和静态解析
+	UnixSocket string
+	// ConnectTimeout 建立连接的超时时间，0 时使用 DefaultConnectTimeout
+	ConnectTimeout time.Duration
+}
+
+// ServerOptions 返回服务器配置对应的网络选项
+func ServerOptions(server *config.APIServerInfo) (Options, error) {
+	opts := Options{
+		Proxy:      server.Proxy,
+		NoProxy:    server.NoProxy,
+		UnixSocket: server.UnixSocket,
+	}
+
+	if server.Proxy != "" {
+		if err := checkProxy(server.Proxy); err != nil {
+			return opts, fmt.Errorf("无效的 proxy: %v", err)
+		}
+	}
+
+	var err error
+	if opts.Hosts, err = ParseHosts(server.Hosts); err != nil {
+		return opts, fmt.Errorf("无效的 hosts: %v", err)
+	}
+
+	if server.ConnectTimeout != "" {
+		if opts.ConnectTimeout, err = time.ParseDuration(server.ConnectTimeout); err != nil || opts.ConnectTimeout <= 0 {
+			return opts, fmt.Errorf("无效的 connect_timeout: %s", server.ConnectTimeout)
+		}
+	}
+	return opts, nil
+}
+
+// Validate 检查未经 ServerOptions 解析的网络选项，如 SDK 中直接设置的选项
+func (o Options) Validate() error {
+	if o.Proxy != "" {
+		if err := checkProxy(o.Proxy); err != nil {
+			return fmt.Errorf("无效的 proxy: %v", err)
+		}
+	}
+	for host, address := range o.Hosts {
+		if host == "" || address == "" {
+			return fmt.Errorf("无效的 hosts: %s=%s 不是 主机名=地址 格式", host, address)
+		}
+	}
+	if o.ConnectTimeout < 0 {
+		return fmt.Errorf("无效的 connect_timeout: %s", o.ConnectTimeout)
+	}
+	return nil
+}
+
+// ParseHosts 解析以逗号分隔的静态解析列表，如 xce.example.com=10.0.0.5,xce.example.com:8443=10.0.0.6:443
+func ParseHosts(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	hosts := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, address, ok := strings.Cut(entry, "=")
+		host, address = strings.TrimSpace(host), strings.TrimSpace(address)
+		if !ok || host == "" || address == "" {
+			return nil, fmt.Errorf("%s 不是 主机名=地址 格式", entry)
+		}
+		hosts[strings.ToLower(host)] = address
+	}
+	return hosts, nil
+}
+
+// checkProxy 检查代理地址的格式和协议
+func checkProxy(proxy string) error {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("不支持的代理协议: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%s 中缺少代理服务器地址", proxy)
+	}
+	return nil
+}
+
+// New 返回使用这些网络选项的 HTTP 传输层，其余设置与 http.DefaultTransport 相同
+func New(opts Options) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	proxyFunc := opts.ProxyFunc()
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+	t.DialContext = opts.DialContext
+	return t
+}
+
+// ProxyFunc 返回为请求地址选择代理的函数，返回 nil 表示直接连接
+func (o Options) ProxyFunc() func(*url.URL) (*url.URL, error) {
+	if o.UnixSocket != "" {
+		return func(*url.URL) (*url.URL, error) {
+			return nil, nil
+		}
+	}
+
+	cfg := httpproxy.FromEnvironment()
+	if o.Proxy != "" {
+		cfg.HTTPProxy = o.Proxy
+		cfg.HTTPSProxy = o.Proxy
+	}
+	if o.NoProxy != "" {
+		cfg.NoProxy = strings.Trim(cfg.NoProxy+","+o.NoProxy, ",")
+	}
+	return cfg.ProxyFunc()
+}
+
+// Dial 建立连接，用于只支持 proxy.Dialer 的场景
+func (o Options) Dial(network, address string) (net.Conn, error) {
+	return o.DialContext(context.Background(), network, address)
+}
+
+// DialContext 建立到 address 的连接，按静态解析替换地址，设置了 Unix 域套接字时连接该套接字
+func (o Options) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	timeout := o.ConnectTimeout
+	if timeout <= 0 {
+		timeout = DefaultConnectTimeout
+	}
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+
+	if o.UnixSocket != "" {
+		return dialer.DialContext(ctx, "unix", o.UnixSocket)
+	}
+	return dialer.DialContext(ctx, network, o.resolve(address))
+}
+
+// resolve 按静态解析替换连接地址，先匹配 主机名:端口 再匹配主机名，未配置端口时沿用原端口
+func (o Options) resolve(address string) string {
+	if len(o.Hosts) == 0 {
+		return address
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+
+	target, ok := o.Hosts[strings.ToLower(address)]
+	if !ok {
+		if target, ok = o.Hosts[strings.ToLower(host)]; !ok {
+			return address
+		}
+	}
+	if _, _, err := net.SplitHostPort(target); err == nil {
+		return target
+	}
+	return net.JoinHostPort(strings.Trim(target, "[]"), port)
+}
@@ -0,0 +1,353 @@
+package wait
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 作业的结束状态
+const (
+	StatusDone = "DONE"
+	StatusExit = "EXIT"
+)
+
+// Result 条件在当前作业状态下的取值
+type Result int
+
+const (
+	// Pending 还不能确定，需要继续等待
+	Pending Result = iota
+	// Satisfied 条件已满足
+	Satisfied
+	// Failed 条件已不可能满足
+	Failed
+)
+
+// Condition 等待条件，由 done(id)、exit(id)、ended(id) 通过 &&、|| 和 ! 组合而成
+type Condition interface {
+	// Eval 按作业状态计算条件的取值，statuses 中没有的作业视为尚未结束
+	Eval(statuses map[int64]string) Result
+	// String 返回条件的规范写法
+	String() string
+	jobs(ids []int64) []int64
+}
+
+// JobIDs 返回条件中引用的作业 ID，按出现顺序排列且不重复
+func JobIDs(cond Condition) []int64 {
+	return cond.jobs(nil)
+}
+
+// jobCondition done(id)、exit(id)、ended(id) 条件
+type jobCondition struct {
+	name string
+	id   int64
+}
+
+// Done 返回等待作业成功结束的条件，即 done(id)
+func Done(id int64) Condition {
+	return jobCondition{name: "done", id: id}
+}
+
+func (c jobCondition) Eval(statuses map[int64]string) Result {
+	status := statuses[c.id]
+	if status != StatusDone && status != StatusExit {
+		return Pending
+	}
+	switch {
+	case c.name == "ended":
+		return Satisfied
+	case c.name == "done" && status == StatusDone, c.name == "exit" && status == StatusExit:
+		return Satisfied
+	default:
+		return Failed
+	}
+}
+
+func (c jobCondition) String() string {
+	return fmt.Sprintf("%s(%d)", c.name, c.id)
+}
+
+func (c jobCondition) jobs(ids []int64) []int64 {
+	if slices.Contains(ids, c.id) {
+		return ids
+	}
+	return append(ids, c.id)
+}
+
+// andCondition a && b，任一条件不可能满足时整体不可能满足
+type andCondition struct {
+	left, right Condition
+}
+
+func (c andCondition) Eval(statuses map[int64]string) Result {
+	left, right := c.left.Eval(statuses), c.right.Eval(statuses)
+	switch {
+	case left == Failed || right == Failed:
+		return Failed
+	case left == Satisfied && right == Satisfied:
+		return Satisfied
+	default:
+		return Pending
+	}
+}
+
+func (c andCondition) String() string {
+	return fmt.Sprintf("%s && %s", group(c.left), group(c.right))
+}
+
+func (c andCondition) jobs(ids []int64) []int64 {
+	return c.right.jobs(c.left.jobs(ids))
+}
+
+// orCondition a || b，任一条件满足时整体满足
+type orCondition struct {
+	left, right Condition
+}
+
+func (c orCondition) Eval(statuses map[int64]string) Result {
+	left, right := c.left.Eval(statuses), c.right.Eval(statuses)
+	switch {
+	case left == Satisfied || right == Satisfied:
+		return Satisfied
+	case left == Failed && right == Failed:
+		return Failed
+	default:
+		return Pending
+	}
+}
+
+func (c orCondition) String() string {
+	return fmt.Sprintf("%s || %s", c.left, c.right)
+}
+
+func (c orCondition) jobs(ids []int64) []int64 {
+	return c.right.jobs(c.left.jobs(ids))
+}
+
+// notCondition !a
+type notCondition struct {
+	cond Condition
+}
+
+func (c notCondition) Eval(statuses map[int64]string) Result {
+	switch c.cond.Eval(statuses) {
+	case Satisfied:
+		return Failed
+	case Failed:
+		return Satisfied
+	default:
+		return Pending
+	}
+}
+
+func (c notCondition) String() string {
+	if _, ok := c.cond.(jobCondition); ok {
+		return "!" + c.cond.String()
+	}
+	return "!(" + c.cond.String() + ")"
+}
+
+func (c notCondition) jobs(ids []int64) []int64 {
+	return c.cond.jobs(ids)
+}
+
+// group 在 && 的子条件 || 两边加上括号
+func group(cond Condition) string {
+	if _, ok := cond.(orCondition); ok {
+		return "(" + cond.String() + ")"
+	}
+	return cond.String()
+}
+
+// Parse 解析 LSF 风格的等待条件，如 "done(123) && (exit(124) || ended(125))"。
+// && 的优先级高于 ||，函数名不区分大小写
+func Parse(expr string) (Condition, error) {
+	p := &parser{expr: expr}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("等待条件不能为空")
+	}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "多余的 %q", tok.text)
+	}
+	return cond, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type parser struct {
+	expr   string
+	tokens []token
+	next   int
+}
+
+// tokenize 将条件拆分为记号
+func (p *parser) tokenize() error {
+	for i := 0; i < len(p.expr); {
+		ch := rune(p.expr[i])
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch == '(':
+			p.tokens = append(p.tokens, token{tokenLParen, "(", i})
+			i++
+		case ch == ')':
+			p.tokens = append(p.tokens, token{tokenRParen, ")", i})
+			i++
+		case ch == '!':
+			p.tokens = append(p.tokens, token{tokenNot, "!", i})
+			i++
+		case strings.HasPrefix(p.expr[i:], "&&"):
+			p.tokens = append(p.tokens, token{tokenAnd, "&&", i})
+			i += 2
+		case strings.HasPrefix(p.expr[i:], "||"):
+			p.tokens = append(p.tokens, token{tokenOr, "||", i})
+			i += 2
+		case unicode.IsDigit(ch):
+			start := i
+			for i < len(p.expr) && unicode.IsDigit(rune(p.expr[i])) {
+				i++
+			}
+			p.tokens = append(p.tokens, token{tokenNumber, p.expr[start:i], start})
+		case unicode.IsLetter(ch):
+			start := i
+			for i < len(p.expr) && (unicode.IsLetter(rune(p.expr[i])) || unicode.IsDigit(rune(p.expr[i])) || p.expr[i] == '_') {
+				i++
+			}
+			p.tokens = append(p.tokens, token{tokenIdent, p.expr[start:i], start})
+		default:
+			return fmt.Errorf("等待条件第 %d 个字符 %q 无效", i+1, ch)
+		}
+	}
+	return nil
+}
+
+func (p *parser) peek() token {
+	if p.next >= len(p.tokens) {
+		return token{kind: tokenEOF, pos: len(p.expr)}
+	}
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	tok := p.peek()
+	if tok.kind != tokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return fmt.Errorf("等待条件第 %d 个字符处%s", tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+// parseOr or := and ('||' and)*
+func (p *parser) parseOr() (Condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.take()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd and := unary ('&&' unary)*
+func (p *parser) parseAnd() (Condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.take()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary unary := '!' unary | '(' or ')' | name '(' jobid ')'
+func (p *parser) parseUnary() (Condition, error) {
+	tok := p.take()
+	switch tok.kind {
+	case tokenNot:
+		cond, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{cond}, nil
+	case tokenLParen:
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.take(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "缺少 )")
+		}
+		return cond, nil
+	case tokenIdent:
+		return p.parseJob(tok)
+	case tokenEOF:
+		return nil, p.errorf(tok, "缺少条件")
+	default:
+		return nil, p.errorf(tok, "应为 done(作业ID)、exit(作业ID) 或 ended(作业ID)，而不是 %q", tok.text)
+	}
+}
+
+// parseJob 解析 done(id) 等作业条件，name 为已读取的函数名
+func (p *parser) parseJob(name token) (Condition, error) {
+	fn := strings.ToLower(name.text)
+	if fn != "done" && fn != "exit" && fn != "ended" {
+		return nil, p.errorf(name, "不支持的条件 %s，可选: done、exit、ended", name.text)
+	}
+	if tok := p.take(); tok.kind != tokenLParen {
+		return nil, p.errorf(tok, "%s 后缺少 (", name.text)
+	}
+	tok := p.take()
+	if tok.kind != tokenNumber {
+		return nil, p.errorf(tok, "%s 的参数应为作业 ID", name.text)
+	}
+	id, err := strconv.ParseInt(tok.text, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, p.errorf(tok, "无效的作业 ID %s", tok.text)
+	}
+	if closing := p.take(); closing.kind != tokenRParen {
+		return nil, p.errorf(closing, "缺少 )")
+	}
+	return jobCondition{name: fn, id: id}, nil
+}
@@ -0,0 +1,106 @@
+package wait
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string // 规范写法
+		ids  []int64
+	}{
+		{expr: "done(123)", want: "done(123)", ids: []int64{123}},
+		{expr: "EXIT(1)", want: "exit(1)", ids: []int64{1}},
+		{expr: " ended( 7 ) ", want: "ended(7)", ids: []int64{7}},
+		{expr: "done(1) && done(2)", want: "done(1) && done(2)", ids: []int64{1, 2}},
+		{expr: "done(1) || exit(2) && ended(3)", want: "done(1) || exit(2) && ended(3)", ids: []int64{1, 2, 3}},
+		{expr: "(done(1) || exit(2)) && ended(3)", want: "(done(1) || exit(2)) && ended(3)", ids: []int64{1, 2, 3}},
+		{expr: "!done(1)", want: "!done(1)", ids: []int64{1}},
+		{expr: "!(done(1) && exit(1))", want: "!(done(1) && exit(1))", ids: []int64{1}},
+		{expr: "done(2) && done(1) || done(2)", want: "done(2) && done(1) || done(2)", ids: []int64{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cond, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if got := cond.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if got := JobIDs(cond); !slices.Equal(got, tt.ids) {
+				t.Errorf("JobIDs() = %v, want %v", got, tt.ids)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string // 错误信息中应包含的内容
+	}{
+		{expr: "", want: "不能为空"},
+		{expr: "   ", want: "不能为空"},
+		{expr: "running(1)", want: "不支持的条件 running"},
+		{expr: "done 1", want: "done 后缺少 ("},
+		{expr: "done(x)", want: "参数应为作业 ID"},
+		{expr: "done(0)", want: "无效的作业 ID 0"},
+		{expr: "done(1", want: "缺少 )"},
+		{expr: "(done(1)", want: "缺少 )"},
+		{expr: "done(1) &&", want: "缺少条件"},
+		{expr: "done(1) done(2)", want: "多余的"},
+		{expr: "done(1) & done(2)", want: "字符 '&' 无效"},
+		{expr: "&& done(1)", want: "而不是 \"&&\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want error containing %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		expr     string
+		statuses map[int64]string
+		want     Result
+	}{
+		{"done(1)", nil, Pending},
+		{"done(1)", map[int64]string{1: "RUN"}, Pending},
+		{"done(1)", map[int64]string{1: StatusDone}, Satisfied},
+		{"done(1)", map[int64]string{1: StatusExit}, Failed},
+		{"exit(1)", map[int64]string{1: StatusExit}, Satisfied},
+		{"exit(1)", map[int64]string{1: StatusDone}, Failed},
+		{"ended(1)", map[int64]string{1: StatusDone}, Satisfied},
+		{"ended(1)", map[int64]string{1: StatusExit}, Satisfied},
+		{"ended(1)", map[int64]string{1: "PEND"}, Pending},
+		{"done(1) && done(2)", map[int64]string{1: StatusDone}, Pending},
+		{"done(1) && done(2)", map[int64]string{1: StatusDone, 2: StatusDone}, Satisfied},
+		{"done(1) && done(2)", map[int64]string{2: StatusExit}, Failed},
+		{"done(1) || done(2)", map[int64]string{2: StatusDone}, Satisfied},
+		{"done(1) || done(2)", map[int64]string{1: StatusExit}, Pending},
+		{"done(1) || done(2)", map[int64]string{1: StatusExit, 2: StatusExit}, Failed},
+		// && 的优先级高于 ||
+		{"done(1) || done(2) && done(3)", map[int64]string{1: StatusDone}, Satisfied},
+		{"(done(1) || done(2)) && done(3)", map[int64]string{1: StatusDone}, Pending},
+		{"!done(1)", map[int64]string{1: StatusExit}, Satisfied},
+		{"!done(1)", map[int64]string{1: StatusDone}, Failed},
+		{"!done(1)", map[int64]string{1: "RUN"}, Pending},
+	}
+	for _, tt := range tests {
+		cond, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		if got := cond.Eval(tt.statuses); got != tt.want {
+			t.Errorf("%s with %v = %v, want %v", tt.expr, tt.statuses, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package wait 实现 bwait 使用的等待条件解析和轮询
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"k8s.io/klog/v2"
+)
+
+// 默认的轮询间隔，作业状态没有变化时间隔逐渐增加到 DefaultMaxInterval，状态变化后恢复为 DefaultInterval
+const (
+	DefaultInterval    = 2 * time.Second
+	DefaultMaxInterval = 30 * time.Second
+)
+
+// backoffFactor 作业状态没有变化时轮询间隔增加的倍数
+const backoffFactor = 1.5
+
+// 可以通过 errors.Is 判断的等待结果
+var (
+	ErrConditionFailed = errors.New("等待条件已不可能满足")
+	ErrTimeout         = errors.New("等待超时")
+)
+
+// StatusFunc 查询多个作业的当前状态，返回作业 ID 到状态的映射
+type StatusFunc func(ctx context.Context, ids []int64) (map[int64]string, error)
+
+// Options 定义等待选项
+type Options struct {
+	// Timeout 最长等待时间，0 表示不限制
+	Timeout time.Duration
+	// Interval 第一次轮询的间隔，0 表示使用 DefaultInterval
+	Interval time.Duration
+	// MaxInterval 轮询间隔的上限，0 表示使用 DefaultMaxInterval
+	MaxInterval time.Duration
+	// OnChange 作业状态发生变化时调用，第一次查询到状态时也会调用
+	OnChange func(id int64, status string)
+}
+
+// Until 轮询条件中引用的作业，直到条件满足时返回 nil。条件不可能满足时返回 ErrConditionFailed，
+// 超过 Timeout 时返回 ErrTimeout，未登录、没有权限和作业不存在时直接返回，服务器暂时不可用 (5xx、429)
+// 和网络错误会在下次轮询时重试。
+// 每次轮询通过 status 一次查询所有尚未结束的作业，已结束的作业不再查询
+func Until(ctx context.Context, cond Condition, status StatusFunc, opts Options) error {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.MaxInterval < opts.Interval {
+		opts.MaxInterval = max(DefaultMaxInterval, opts.Interval)
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, opts.Timeout, ErrTimeout)
+		defer cancel()
+	}
+
+	ids := JobIDs(cond)
+	statuses := make(map[int64]string, len(ids))
+	interval := opts.Interval
+	for {
+		changed, err := poll(ctx, ids, statuses, status, opts.OnChange)
+		if err != nil {
+			return waitError(ctx, err, opts.Timeout, cond, ids, statuses)
+		}
+
+		switch cond.Eval(statuses) {
+		case Satisfied:
+			return nil
+		case Failed:
+			return fmt.Errorf("%w: %s (%s)", ErrConditionFailed, cond, describe(ids, statuses))
+		}
+
+		if changed {
+			interval = opts.Interval
+		} else {
+			interval = min(time.Duration(float64(interval)*backoffFactor), opts.MaxInterval)
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return waitError(ctx, err, opts.Timeout, cond, ids, statuses)
+		}
+	}
+}
+
+// poll 查询尚未结束的作业的状态，返回是否有作业的状态发生变化。可以重试的错误只记录日志
+func poll(ctx context.Context, ids []int64, statuses map[int64]string, status StatusFunc, onChange func(int64, string)) (bool, error) {
+	var pending []int64
+	for _, id := range ids {
+		if !finished(statuses[id]) {
+			pending = append(pending, id)
+		}
+	}
+	if len(pending) == 0 {
+		return false, nil
+	}
+
+	current, err := status(ctx, pending)
+	if err != nil {
+		if ctx.Err() != nil || fatal(err) {
+			return false, err
+		}
+		klog.V(1).Infof("查询作业状态失败，稍后重试: %v", err)
+		return false, nil
+	}
+
+	changed := false
+	for _, id := range pending {
+		if s, ok := current[id]; ok && s != statuses[id] {
+			statuses[id] = s
+			changed = true
+			if onChange != nil {
+				onChange(id, s)
+			}
+		}
+	}
+	return changed, nil
+}
+
+// fatal 判断查询作业状态的错误是否应结束等待，未登录、没有权限和作业不存在时重试也不会成功
+func fatal(err error) bool {
+	return errors.Is(err, client.ErrUnauthorized) || errors.Is(err, client.ErrForbidden) || errors.Is(err, client.ErrNotFound)
+}
+
+// waitError 等待被中断时，超时返回包含作业当前状态的 ErrTimeout，其他情况原样返回
+func waitError(ctx context.Context, err error, timeout time.Duration, cond Condition, ids []int64, statuses map[int64]string) error {
+	if errors.Is(context.Cause(ctx), ErrTimeout) {
+		return fmt.Errorf("%w (%s): %s (%s)", ErrTimeout, timeout, cond, describe(ids, statuses))
+	}
+	return err
+}
+
+// finished 判断作业是否已结束
+func finished(status string) bool {
+	return status == StatusDone || status == StatusExit
+}
+
+// describe 返回作业状态的说明，如 123=DONE, 124=EXIT
+func describe(ids []int64, statuses map[int64]string) string {
+	sorted := append([]int64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	parts := make([]string, 0, len(sorted))
+	for _, id := range sorted {
+		status := statuses[id]
+		if status == "" {
+			status = "-"
+		}
+		parts = append(parts, fmt.Sprintf("%d=%s", id, status))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sleep 等待指定的时间，ctx 被取消时提前返回错误
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// JobStatus 返回通过 API 客户端查询作业状态的 StatusFunc，服务器支持时每次轮询只发送一个请求
+func JobStatus(c *client.APIClient, token string) StatusFunc {
+	return func(ctx context.Context, ids []int64) (map[int64]string, error) {
+		jobs, err := c.GetJobsByID(ctx, token, ids, "jobid", "status")
+		if err != nil {
+			return nil, err
+		}
+		statuses := make(map[int64]string, len(jobs))
+		for id, job := range jobs {
+			statuses[id] = job.Status
+		}
+		return statuses, nil
+	}
+}
+
+// StatusPrinter 返回将作业状态变化逐行写入 w 的 OnChange 函数，如 "15:04:05 作业 123: RUN"
+func StatusPrinter(w io.Writer) func(id int64, status string) {
+	return func(id int64, status string) {
+		fmt.Fprintf(w, "%s 作业 %d: %s\n", time.Now().Format(time.TimeOnly), id, status)
+	}
+}
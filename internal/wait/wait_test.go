@@ -0,0 +1,229 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+)
+
+// sequence 返回依次给出 polls 中各次轮询结果的 StatusFunc，用完后重复最后一次的结果
+func sequence(polls ...map[int64]string) StatusFunc {
+	n := 0
+	return func(ctx context.Context, ids []int64) (map[int64]string, error) {
+		current := polls[min(n, len(polls)-1)]
+		n++
+		if current == nil {
+			return nil, errors.New("connection reset by peer")
+		}
+		return current, nil
+	}
+}
+
+func TestUntil(t *testing.T) {
+	notFound := func(ctx context.Context, ids []int64) (map[int64]string, error) {
+		return nil, fmt.Errorf("作业 %d: %w", ids[0], client.ErrNotFound)
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		status  StatusFunc
+		timeout time.Duration
+		want    error
+	}{
+		{
+			name:   "满足",
+			expr:   "done(1) && ended(2)",
+			status: sequence(map[int64]string{1: "RUN", 2: "PEND"}, map[int64]string{1: "DONE", 2: "RUN"}, map[int64]string{2: "EXIT"}),
+		},
+		{
+			name:   "网络错误后重试",
+			expr:   "done(1)",
+			status: sequence(nil, map[int64]string{1: "DONE"}),
+		},
+		{
+			name:   "不可能满足",
+			expr:   "done(1) || done(2)",
+			status: sequence(map[int64]string{1: "EXIT", 2: "RUN"}, map[int64]string{2: "EXIT"}),
+			want:   ErrConditionFailed,
+		},
+		{
+			name:    "超时",
+			expr:    "done(1)",
+			status:  sequence(map[int64]string{1: "RUN"}),
+			timeout: 20 * time.Millisecond,
+			want:    ErrTimeout,
+		},
+		{
+			name:   "作业不存在",
+			expr:   "done(1)",
+			status: notFound,
+			want:   client.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Until(context.Background(), cond, tt.status, Options{
+				Timeout:     tt.timeout,
+				Interval:    time.Millisecond,
+				MaxInterval: time.Millisecond,
+			})
+			if tt.want == nil && err != nil {
+				t.Errorf("Until() = %v, want nil", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Until() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUntilSkipsFinishedJobs(t *testing.T) {
+	var queried [][]int64
+	status := func(ctx context.Context, ids []int64) (map[int64]string, error) {
+		queried = append(queried, ids)
+		return map[int64]string{1: "DONE", 2: "RUN", 3: "DONE"}, nil
+	}
+	cond, _ := Parse("done(1) && done(2) && done(3)")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	Until(ctx, cond, status, Options{Interval: time.Millisecond, MaxInterval: time.Millisecond})
+
+	if len(queried) < 2 {
+		t.Fatalf("polled %d times, want at least 2", len(queried))
+	}
+	if got := fmt.Sprint(queried[0]); got != "[1 2 3]" {
+		t.Errorf("first poll = %s, want [1 2 3]", got)
+	}
+	if got := fmt.Sprint(queried[1]); got != "[2]" {
+		t.Errorf("second poll = %s, want only the unfinished job [2]", got)
+	}
+}
+
+func TestJobStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		negotiate bool
+		batched   bool
+	}{
+		{name: "服务器支持按作业 ID 过滤", negotiate: true, batched: true},
+		{name: "能力未知时逐个查询"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := xcetest.NewServer()
+			defer s.Close()
+			for range 3 {
+				if _, err := s.Submit("alice", client.JobSubmitRequest{Command: "sleep 60"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.SetJobState(2, xcetest.StatusDone, 0)
+
+			c := client.NewAPIClient(s.URL())
+			if tt.negotiate {
+				if _, err := c.Negotiate(context.Background(), ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := len(s.Requests())
+			status := JobStatus(c, s.IssueToken("alice"))
+			got, err := status(context.Background(), []int64{1, 2, 3})
+			if err != nil {
+				t.Fatalf("status: %v", err)
+			}
+			if want := "map[1:PEND 2:DONE 3:PEND]"; fmt.Sprint(got) != want {
+				t.Errorf("status = %v, want %s", got, want)
+			}
+
+			var filters []string
+			for _, r := range s.Requests()[before:] {
+				if r.Method == http.MethodGet && strings.HasSuffix(r.Path, "/jobs") {
+					query, _ := url.ParseQuery(r.Query)
+					filters = append(filters, query.Get("filter"))
+				}
+			}
+			want := []string{"[jobid:eq:1]", "[jobid:eq:2]", "[jobid:eq:3]"}
+			if tt.batched {
+				want = []string{"[jobid:in:1|2|3]"}
+			}
+			if fmt.Sprint(filters) != fmt.Sprint(want) {
+				t.Errorf("job queries = %v, want %v", filters, want)
+			}
+
+			if _, err := status(context.Background(), []int64{1, 99}); !errors.Is(err, client.ErrNotFound) {
+				t.Errorf("status of a missing job: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestUntilRetriesServerErrors(t *testing.T) {
+	s := xcetest.NewServer()
+	defer s.Close()
+	if _, err := s.Submit("alice", client.JobSubmitRequest{Command: "sleep 60"}); err != nil {
+		t.Fatal(err)
+	}
+	c := client.NewAPIClient(s.URL())
+	// 关闭客户端的重试，由轮询处理服务器错误
+	c.SetRetryPolicy(client.RetryPolicy{})
+	if _, err := c.Negotiate(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+	token := s.IssueToken("alice")
+	cond, _ := Parse("done(1)")
+	opts := Options{Timeout: 5 * time.Second, Interval: time.Millisecond, MaxInterval: time.Millisecond}
+
+	// 作业结束前服务器先返回几次 503 和 429
+	s.InjectFault(xcetest.Fault{Method: http.MethodGet, Path: "/xce/v2/jobs", Times: 3, Status: http.StatusServiceUnavailable})
+	s.InjectFault(xcetest.Fault{Method: http.MethodGet, Path: "/xce/v2/jobs", Times: 1, Status: http.StatusTooManyRequests})
+	var polled []string
+	opts.OnChange = func(id int64, status string) {
+		polled = append(polled, status)
+		if status == xcetest.StatusPend {
+			s.SetJobState(1, xcetest.StatusDone, 0)
+		}
+	}
+	if err := Until(context.Background(), cond, JobStatus(c, token), opts); err != nil {
+		t.Fatalf("Until() = %v, want nil after the server recovers", err)
+	}
+	if got := fmt.Sprint(polled); got != "[PEND DONE]" {
+		t.Errorf("status changes = %s, want [PEND DONE]", got)
+	}
+	queries := 0
+	for _, r := range s.Requests() {
+		if r.Method == http.MethodGet && r.Path == "/xce/v2/jobs" {
+			queries++
+		}
+	}
+	if queries != 6 {
+		t.Errorf("job queries = %d, want 4 failed and 2 successful", queries)
+	}
+
+	// 未登录和没有权限时直接返回
+	for _, tt := range []struct {
+		status int
+		want   error
+	}{
+		{status: http.StatusUnauthorized, want: client.ErrUnauthorized},
+		{status: http.StatusForbidden, want: client.ErrForbidden},
+	} {
+		s.InjectFault(xcetest.Fault{Method: http.MethodGet, Path: "/xce/v2/jobs", Times: 1, Status: tt.status})
+		opts.OnChange = nil
+		if err := Until(context.Background(), cond, JobStatus(c, token), opts); !errors.Is(err, tt.want) {
+			t.Errorf("Until() with HTTP %d = %v, want %v", tt.status, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package xcetest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 提供假服务器使用的当前时间
+type Clock interface {
+	Now() time.Time
+}
+
+// RealClock 使用系统时间
+type RealClock struct{}
+
+// Now 返回系统当前时间
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock 只有调用 Advance 或 Set 时才会变化的时钟，用于控制作业状态的变化
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// DefaultStartTime FakeClock 的默认起始时间
+var DefaultStartTime = time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+
+// NewFakeClock 创建从 start 开始的时钟
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now 返回时钟的当前时间
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance 将时钟向后拨 d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set 将时钟设置为 t
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
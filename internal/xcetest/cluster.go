@@ -0,0 +1,96 @@
+package xcetest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	mathrand "math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+)
+
+// GenerateHosts 生成 n 台随机配置的主机，相同的 rng 种子生成相同的主机
+func GenerateHosts(n int, rng *mathrand.Rand) []client.Host {
+	cpus := []int{8, 16, 32, 64}
+	hosts := make([]client.Host, 0, n)
+	for i := 1; i <= n; i++ {
+		host := client.Host{
+			HostName:       fmt.Sprintf("node%03d", i),
+			HostType:       "X86_64",
+			HostModel:      "XeonGold",
+			CpuFactor:      1,
+			MaxCpus:        cpus[rng.IntN(len(cpus))],
+			NDisks:         1,
+			Windows:        "-",
+			IsServer:       true,
+			HostAddr:       fmt.Sprintf("10.0.%d.%d", i/250, i%250+1),
+			Pprocs:         2,
+			ThreadsPerCore: 1,
+		}
+		// 约五分之一的主机为 ARM
+		if rng.IntN(5) == 0 {
+			host.HostType = "ARM"
+			host.HostModel = "Kunpeng920"
+			host.CpuFactor = 0.8
+		}
+		host.Cores = host.MaxCpus
+		host.CoresPerProc = host.MaxCpus / host.Pprocs
+		// 内存等以 KB 为单位，每个 CPU 4GB 内存
+		host.MaxMem = int64(host.MaxCpus) * 4 * 1024 * 1024
+		host.MaxSwap = 8 * 1024 * 1024
+		host.MaxTmp = int64(100+rng.IntN(400)) * 1024 * 1024
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// DefaultQueues 模拟集群的默认队列，优先级高的队列中的作业先派发
+var DefaultQueues = []Queue{
+	{Name: "normal", Priority: 30, Status: "Open:Active", Description: "默认队列"},
+	{Name: "short", Priority: 40, Status: "Open:Active", Description: "短作业队列"},
+	{Name: "long", Priority: 20, Status: "Open:Active", Description: "长作业队列"},
+}
+
+// SelfSignedCert 生成自签名的服务器证书，names 为证书中的主机名或 IP 地址
+func SelfSignedCert(names ...string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "xce mock-server"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -0,0 +1,20 @@
+// Package xcetest 提供进程内的假 XCE APIserver，用于测试和演示，无需连接真实集群。
+//
+// 假服务器实现版本、登录、登出、作业提交和查询、主机查询以及队列查询接口，响应格式与 APIserver 相同，
+// 支持 limit/offset 分页、filter (包括按多个作业 ID 过滤的 jobid:in:1|2) 和 fields 参数以及 Idempotency-Key。
+// 默认同时提供 /xce/v1 和 /xce/v2 接口，WithVersion 可以限制 API 版本和能力，
+// WithLegacyAPI 模拟没有版本接口的旧服务器。
+// 作业状态由 Scheduler 按 Clock 的时间推进，默认使用 FakeClock，调用 Advance 后作业才会变化:
+//
+//	clock := xcetest.NewFakeClock(xcetest.DefaultStartTime)
+//	s := xcetest.NewTLSServer(xcetest.WithClock(clock), xcetest.WithUser("alice", "secret"))
+//	defer s.Close()
+//
+//	id, _ := s.Submit("alice", client.JobSubmitRequest{Command: "sleep 60"})
+//	clock.Advance(time.Minute)
+//	job, _ := s.Job(id)  // job.Status == xcetest.StatusRun
+//
+// InjectFault 可以让匹配的请求返回错误状态码、延迟或直接断开连接，用于测试重试和超时:
+//
+//	s.InjectFault(xcetest.Fault{Method: http.MethodGet, Path: "/xce/v1/jobs", Status: http.StatusServiceUnavailable, Times: 1})
+package xcetest
@@ -0,0 +1,115 @@
+package xcetest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault 注入到匹配请求上的故障
+type Fault struct {
+	// Method 匹配的 HTTP 方法，为空时匹配所有方法
+	Method string
+	// Path 匹配的路径前缀，如 /xce/v1/jobs，为空时匹配所有路径
+	Path string
+	// Times 生效次数，0 表示一直生效
+	Times int
+	// Delay 处理请求前的延迟，可用于测试超时
+	Delay time.Duration
+	// Status 返回的 HTTP 状态码，0 表示不修改响应，只注入延迟
+	Status int
+	// Code 响应中的业务码，为 0 时与 Status 相同
+	Code int
+	// Msg 响应中的错误信息
+	Msg string
+	// RetryAfter 非零时通过 Retry-After 头要求客户端等待
+	RetryAfter time.Duration
+	// Header 额外的响应头，如 HTTP 日期格式的 Retry-After
+	Header http.Header
+	// Drop 不返回响应，直接关闭连接
+	Drop bool
+}
+
+// matches 判断故障是否作用于该请求
+func (f *Fault) matches(r *http.Request) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+		return false
+	}
+	return strings.HasPrefix(r.URL.Path, f.Path)
+}
+
+// InjectFault 注入故障，多个故障按注入顺序匹配，每个请求只触发第一个匹配的故障
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults 清除所有故障
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// takeFault 返回作用于该请求的故障并减少其剩余次数
+func (s *Server) takeFault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.faults {
+		if !f.matches(r) {
+			continue
+		}
+		fault := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return &fault
+	}
+	return nil
+}
+
+// applyFault 执行故障，返回 true 表示已处理该请求
+func applyFault(w http.ResponseWriter, r *http.Request, f *Fault) bool {
+	if f.Delay > 0 {
+		select {
+		case <-time.After(f.Delay):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	if f.Drop {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return true
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+
+	if f.Status == 0 {
+		return false
+	}
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(f.RetryAfter.Seconds())))
+	}
+	for name, values := range f.Header {
+		w.Header()[name] = values
+	}
+	code := f.Code
+	if code == 0 {
+		code = f.Status
+	}
+	msg := f.Msg
+	if msg == "" {
+		msg = http.StatusText(f.Status)
+	}
+	writeJSON(w, f.Status, response{Code: code, Msg: msg})
+	return true
+}
@@ -0,0 +1,107 @@
+package xcetest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+)
+
+// 作业状态
+const (
+	StatusPend = "PEND"
+	StatusRun  = "RUN"
+	StatusDone = "DONE"
+	StatusExit = "EXIT"
+)
+
+// Job 假服务器中的作业，Job 字段为接口返回的内容
+type Job struct {
+	client.Job
+	// SubmitAt 提交时间
+	SubmitAt time.Time
+	// StartAt 开始运行的时间，未开始时为零值
+	StartAt time.Time
+	// EndAt 结束的时间，未结束时为零值
+	EndAt time.Time
+	// Slots 占用的作业槽数
+	Slots int
+	// Hosts 运行作业的主机
+	Hosts []string
+	// ExitCode 作业退出码，非 0 时作业以 EXIT 状态结束
+	ExitCode int
+	// pinned 状态由 SetJobState 指定，调度器不再修改
+	pinned bool
+}
+
+// Finished 作业是否已经结束
+func (j *Job) Finished() bool {
+	return j.Status == StatusDone || j.Status == StatusExit
+}
+
+// Scheduler 决定作业状态如何随时间变化
+type Scheduler interface {
+	// Submit 在作业提交时调用，可以设置作业的初始状态
+	Submit(job *Job, now time.Time)
+	// Update 在每次处理请求前调用，按当前时间更新作业状态，jobs 按作业 ID 排序
+	Update(jobs []*Job, now time.Time)
+}
+
+// Lifecycle 按固定时长变化的作业状态: 提交后 Pend 时间内为 PEND，之后 Run 时间内为 RUN，然后结束
+type Lifecycle struct {
+	// Pend 排队时间
+	Pend time.Duration
+	// Run 运行时间
+	Run time.Duration
+	// ExitCode 结束时的退出码，非 0 时以 EXIT 状态结束
+	ExitCode int
+}
+
+// DefaultLifecycle 未指定时使用的作业生命周期
+var DefaultLifecycle = Lifecycle{Pend: 10 * time.Second, Run: time.Minute}
+
+// Submit 实现 Scheduler
+func (l Lifecycle) Submit(job *Job, now time.Time) {
+	job.Status = StatusPend
+	job.ExitCode = l.ExitCode
+}
+
+// Update 实现 Scheduler
+func (l Lifecycle) Update(jobs []*Job, now time.Time) {
+	for _, job := range jobs {
+		if job.pinned || job.Finished() {
+			continue
+		}
+		start := job.SubmitAt.Add(l.Pend)
+		end := start.Add(l.Run)
+		switch {
+		case now.Before(start):
+			job.Status = StatusPend
+		case now.Before(end):
+			job.Status = StatusRun
+			job.StartAt = start
+		default:
+			job.StartAt = start
+			finish(job, end)
+		}
+	}
+}
+
+// finish 按退出码结束作业
+func finish(job *Job, at time.Time) {
+	job.EndAt = at
+	job.Status = StatusDone
+	if job.ExitCode != 0 {
+		job.Status = StatusExit
+	}
+}
+
+// checkStatus 检查作业状态是否有效
+func checkStatus(status string) error {
+	switch status {
+	case StatusPend, StatusRun, StatusDone, StatusExit:
+		return nil
+	default:
+		return fmt.Errorf("无效的作业状态: %s", status)
+	}
+}
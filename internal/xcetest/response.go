@@ -0,0 +1,38 @@
+package xcetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// response 与 APIserver 相同的响应格式
+type response struct {
+	Code  int    `json:"code"`
+	Msg   string `json:"msg"`
+	Data  any    `json:"data,omitempty"`
+	Count int    `json:"count,omitempty"`
+}
+
+// writeJSON 以 json 格式写入响应
+func writeJSON(w http.ResponseWriter, status int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// readBody 读取请求内容
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	defer r.Body.Close()
+	return io.ReadAll(r.Body)
+}
+
+// withBody 返回请求内容可以再次读取的请求
+func withBody(r *http.Request, body []byte) *http.Request {
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return r
+}
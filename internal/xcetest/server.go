@@ -0,0 +1,733 @@
+package xcetest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+)
+
+// apiPrefix 默认 API 版本的接口路径前缀，与 APIserver 一致
+const apiPrefix = "/xce/" + client.DefaultAPIVersion
+
+// DefaultVersion 假服务器默认返回的版本信息，支持 v1、v2 两个 API 版本和全部能力
+var DefaultVersion = client.VersionInfo{
+	Version:     "2.0.0-xcetest",
+	APIVersions: []string{client.APIVersionV1, client.APIVersionV2},
+	Capabilities: []string{
+		client.CapCertLogon,
+		client.CapPagination,
+		client.CapJobFields,
+		client.CapIdempotency,
+		client.CapJobIDFilter,
+	},
+}
+
+// Queue 作业队列
+type Queue struct {
+	Name        string `json:"queueName"`
+	Priority    int    `json:"priority"`
+	Status      string `json:"status"`
+	Description string `json:"description,omitempty"`
+	NJobs       int    `json:"njobs"`
+	Pend        int    `json:"pend"`
+	Run         int    `json:"run"`
+}
+
+// Request 服务器收到的请求，用于断言客户端的行为
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+	// PeerCertificates 客户端在 TLS 握手时提供的证书链，HTTP 请求或未提供证书时为空
+	PeerCertificates []*x509.Certificate
+}
+
+// Option 创建假服务器时的可选配置
+type Option func(*Server)
+
+// WithClock 使用指定的时钟，默认使用从 DefaultStartTime 开始的 FakeClock
+func WithClock(clock Clock) Option {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
+
+// WithScheduler 使用指定的调度器决定作业状态，默认使用 DefaultLifecycle
+func WithScheduler(scheduler Scheduler) Option {
+	return func(s *Server) {
+		s.scheduler = scheduler
+	}
+}
+
+// WithUser 添加可以登录的用户，未添加任何用户时任意用户名和非空密码均可登录
+func WithUser(username, password string) Option {
+	return func(s *Server) {
+		s.users[username] = password
+	}
+}
+
+// WithVersion 设置版本接口返回的版本信息，服务器只提供 APIVersions 中各版本的接口，
+// 并忽略 Capabilities 以外功能的请求参数
+func WithVersion(info client.VersionInfo) Option {
+	return func(s *Server) {
+		s.version = &info
+	}
+}
+
+// WithLegacyAPI 模拟没有版本接口的旧服务器，只提供 /xce/v1 接口
+func WithLegacyAPI() Option {
+	return func(s *Server) {
+		s.version = nil
+	}
+}
+
+// WithHosts 添加主机
+func WithHosts(hosts ...client.Host) Option {
+	return func(s *Server) {
+		s.hosts = append(s.hosts, hosts...)
+	}
+}
+
+// WithQueues 添加队列，第一个队列为默认队列
+func WithQueues(queues ...Queue) Option {
+	return func(s *Server) {
+		s.queues = append(s.queues, queues...)
+	}
+}
+
+// Server 进程内的假 XCE APIserver，实现登录、作业、主机和队列接口
+type Server struct {
+	clock     Clock
+	scheduler Scheduler
+	ts        *httptest.Server
+	mux       *http.ServeMux
+	// version 版本接口返回的信息，nil 表示没有版本接口的旧服务器
+	version *client.VersionInfo
+
+	mu          sync.Mutex
+	users       map[string]string
+	tokens      map[string]string
+	nextToken   int
+	hosts       []client.Host
+	queues      []Queue
+	jobs        map[int64]*Job
+	nextJobID   int64
+	idempotency map[string]int64
+	faults      []*Fault
+	requests    []Request
+}
+
+// New 创建未监听端口的假服务器，可作为 http.Handler 使用
+func New(opts ...Option) *Server {
+	s := &Server{
+		clock:       NewFakeClock(DefaultStartTime),
+		scheduler:   DefaultLifecycle,
+		users:       make(map[string]string),
+		tokens:      make(map[string]string),
+		jobs:        make(map[int64]*Job),
+		nextJobID:   1,
+		idempotency: make(map[string]int64),
+		version:     &DefaultVersion,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if len(s.queues) == 0 {
+		s.queues = []Queue{{Name: "normal", Priority: 30, Status: "Open:Active"}}
+	}
+
+	s.mux = http.NewServeMux()
+	if s.version != nil {
+		s.mux.HandleFunc("GET /xce/version", s.handleVersion)
+	}
+	for _, prefix := range s.apiPrefixes() {
+		s.mux.HandleFunc("POST "+prefix+"/auth/logout", s.authorized(s.handleLogout))
+		s.mux.HandleFunc("POST "+prefix+"/jobs", s.authorized(s.handleSubmit))
+		s.mux.HandleFunc("GET "+prefix+"/jobs", s.authorized(s.handleJobs))
+		s.mux.HandleFunc("GET "+prefix+"/hosts", s.authorized(s.handleHosts))
+		s.mux.HandleFunc("GET "+prefix+"/queues", s.authorized(s.handleQueues))
+	}
+	return s
+}
+
+// apiPrefixes 返回服务器提供的各 API 版本的接口路径前缀
+func (s *Server) apiPrefixes() []string {
+	if s.version == nil {
+		return []string{apiPrefix}
+	}
+	prefixes := make([]string, 0, len(s.version.APIVersions))
+	for _, v := range s.version.APIVersions {
+		prefixes = append(prefixes, "/xce/"+v)
+	}
+	return prefixes
+}
+
+// supports 判断服务器是否支持该能力，旧服务器支持全部能力
+func (s *Server) supports(capability string) bool {
+	return s.version == nil || slices.Contains(s.version.Capabilities, capability)
+}
+
+// NewServer 创建并启动 HTTP 假服务器
+func NewServer(opts ...Option) *Server {
+	s := New(opts...)
+	s.ts = httptest.NewServer(s)
+	return s
+}
+
+// NewTLSServer 创建并启动 HTTPS 假服务器，客户端提供的证书可用于证书登录
+func NewTLSServer(opts ...Option) *Server {
+	s := New(opts...)
+	s.ts = httptest.NewUnstartedServer(s)
+	s.ts.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	s.ts.StartTLS()
+	return s
+}
+
+// URL 返回服务器地址，如 https://127.0.0.1:41234，即 cli apiserver logon --url 使用的地址
+func (s *Server) URL() string {
+	return s.ts.URL
+}
+
+// LogonURL 返回默认 API 版本的登录接口地址
+func (s *Server) LogonURL() string {
+	return s.ts.URL + apiPrefix + "/logon"
+}
+
+// CertPEM 返回 HTTPS 服务器证书的 PEM 内容，HTTP 服务器返回 nil
+func (s *Server) CertPEM() []byte {
+	if s.ts == nil || s.ts.Certificate() == nil {
+		return nil
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.ts.Certificate().Raw})
+}
+
+// HTTPClient 返回信任该服务器证书的 http.Client
+func (s *Server) HTTPClient() *http.Client {
+	return s.ts.Client()
+}
+
+// Close 关闭服务器
+func (s *Server) Close() {
+	if s.ts != nil {
+		s.ts.Close()
+	}
+}
+
+// Clock 返回服务器使用的时钟
+func (s *Server) Clock() Clock {
+	return s.clock
+}
+
+// AddUser 添加可以登录的用户
+func (s *Server) AddUser(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[username] = password
+}
+
+// IssueToken 不经过登录直接为用户签发令牌
+func (s *Server) IssueToken(username string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issueToken(username)
+}
+
+func (s *Server) issueToken(username string) string {
+	s.nextToken++
+	token := fmt.Sprintf("xcetest-%s-%d", username, s.nextToken)
+	s.tokens[token] = username
+	return token
+}
+
+// RevokeTokens 使所有令牌失效，用于测试登录过期
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]string)
+}
+
+// AddHost 添加主机
+func (s *Server) AddHost(host client.Host) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hosts = append(s.hosts, host)
+}
+
+// AddQueue 添加队列
+func (s *Server) AddQueue(queue Queue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queues = append(s.queues, queue)
+}
+
+// Submit 以 username 的身份提交作业，不经过 HTTP 接口，返回作业 ID
+func (s *Server) Submit(username string, req client.JobSubmitRequest) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, err := s.submit(username, req)
+	if err != nil {
+		return 0, err
+	}
+	return job.JobID, nil
+}
+
+// Job 返回作业的当前状态
+func (s *Server) Job(id int64) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.update()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Jobs 返回按作业 ID 排序的所有作业
+func (s *Server) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.update()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.sortedJobs() {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// SetJobState 直接设置作业状态，之后调度器不再修改该作业。作业不再运行时调度器释放其占用的作业槽
+func (s *Server) SetJobState(id int64, status string, exitCode int) error {
+	if err := checkStatus(status); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("作业 %d 不存在", id)
+	}
+	job.Status = status
+	job.ExitCode = exitCode
+	job.pinned = true
+	now := s.clock.Now()
+	if status != StatusPend && job.StartAt.IsZero() {
+		job.StartAt = now
+	}
+	if job.Finished() {
+		job.EndAt = now
+	}
+	return nil
+}
+
+// Requests 返回服务器收到的所有请求
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Msg: err.Error()})
+		return
+	}
+	var peerCerts []*x509.Certificate
+	if r.TLS != nil {
+		peerCerts = r.TLS.PeerCertificates
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method:           r.Method,
+		Path:             r.URL.Path,
+		Query:            r.URL.RawQuery,
+		Header:           r.Header.Clone(),
+		Body:             body,
+		PeerCertificates: peerCerts,
+	})
+	s.mu.Unlock()
+
+	if f := s.takeFault(r); f != nil && applyFault(w, r, f) {
+		return
+	}
+
+	// 登录接口为 /xce/<API 版本>/logon，兼容直接向服务器地址或接口路径前缀登录的旧客户端
+	if r.Method == http.MethodPost && (r.URL.Path == "/" || slices.Contains(s.apiPrefixes(), r.URL.Path) || strings.HasSuffix(r.URL.Path, "/logon")) {
+		s.handleLogon(w, r, body)
+		return
+	}
+	s.dropUnsupported(r)
+	s.mux.ServeHTTP(w, withBody(r, body))
+}
+
+// dropUnsupported 去掉服务器不支持的功能对应的请求参数，与不认识这些参数的旧服务器行为一致
+func (s *Server) dropUnsupported(r *http.Request) {
+	query := r.URL.Query()
+	if !s.supports(client.CapPagination) {
+		query.Del("limit")
+		query.Del("offset")
+	}
+	if !s.supports(client.CapJobFields) {
+		query.Del("fields")
+	}
+	r.URL.RawQuery = query.Encode()
+	if !s.supports(client.CapIdempotency) {
+		r.Header.Del("Idempotency-Key")
+	}
+}
+
+// authorized 校验 Bearer 令牌，并将用户名传给处理函数
+func (s *Server) authorized(next func(w http.ResponseWriter, r *http.Request, user string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		user, valid := s.tokens[token]
+		s.mu.Unlock()
+		if !ok || !valid {
+			writeJSON(w, http.StatusUnauthorized, response{Code: http.StatusUnauthorized, Msg: "invalid or expired token"})
+			return
+		}
+		next(w, r, user)
+	}
+}
+
+func (s *Server) handleLogon(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		AuthType string `json:"auth_type"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Username == "" {
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Msg: "username is required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case req.AuthType == "cert" && !s.supports(client.CapCertLogon):
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Msg: "certificate logon is not supported"})
+		return
+	case req.AuthType == "cert":
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			writeJSON(w, http.StatusUnauthorized, response{Code: http.StatusUnauthorized, Msg: "client certificate required"})
+			return
+		}
+	case len(s.users) == 0:
+		if req.Password == "" {
+			writeJSON(w, http.StatusUnauthorized, response{Code: http.StatusUnauthorized, Msg: "password is required"})
+			return
+		}
+	default:
+		if password, ok := s.users[req.Username]; !ok || password != req.Password {
+			writeJSON(w, http.StatusUnauthorized, response{Code: http.StatusUnauthorized, Msg: "invalid username or password"})
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response{Code: http.StatusOK, Msg: "ok", Data: client.LogonData{
+		Token: s.issueToken(req.Username),
+		Path:  "/home/" + req.Username,
+		IP:    r.RemoteAddr,
+	}})
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, response{Code: http.StatusOK, Msg: "ok", Data: s.version})
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request, user string) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	delete(s.tokens, token)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, response{Code: http.StatusOK, Msg: "ok"})
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request, user string) {
+	var req client.JobSubmitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Msg: "invalid request body"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 相同幂等键的重复请求返回第一次提交的作业，新提交的作业返回 201
+	key := r.Header.Get("Idempotency-Key")
+	if id, ok := s.idempotency[key]; ok && key != "" {
+		writeJSON(w, http.StatusOK, response{Code: http.StatusOK, Msg: "ok", Data: submitData(s.jobs[id])})
+		return
+	}
+
+	job, err := s.submit(user, req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Msg: err.Error()})
+		return
+	}
+	if key != "" {
+		s.idempotency[key] = job.JobID
+	}
+	writeJSON(w, http.StatusCreated, response{Code: http.StatusCreated, Msg: "ok", Data: submitData(job)})
+}
+
+func submitData(job *Job) client.JobSubmitData {
+	return client.JobSubmitData{
+		JobID:   job.JobID,
+		Message: fmt.Sprintf("Job <%d> is submitted to queue <%s>.", job.JobID, job.Queue),
+	}
+}
+
+// submit 创建作业，调用方需持有锁
+func (s *Server) submit(user string, req client.JobSubmitRequest) (*Job, error) {
+	if strings.TrimSpace(req.Command) == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+	queue := req.Queue
+	if queue == "" {
+		queue = s.queues[0].Name
+	}
+	if s.queue(queue) == nil {
+		return nil, fmt.Errorf("queue %s does not exist", queue)
+	}
+
+	now := s.clock.Now()
+	job := &Job{
+		Job: client.Job{
+			JobID:      s.nextJobID,
+			User:       user,
+			JobName:    strings.Fields(req.Command)[0],
+			Queue:      queue,
+			Command:    req.Command,
+			ResReq:     req.ResReq,
+			SubmitTime: now.Format("2006-01-02 15:04:05"),
+		},
+		SubmitAt: now,
+		Slots:    1,
+	}
+	s.nextJobID++
+	s.scheduler.Submit(job, now)
+	s.jobs[job.JobID] = job
+	return job, nil
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request, user string) {
+	filters, err := parseFilter(r.URL.Query().Get("filter"), s.supports(client.CapJobIDFilter))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Msg: err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	s.update()
+	var jobs []client.Job
+	for _, job := range s.sortedJobs() {
+		if matchJob(job, filters) {
+			jobs = append(jobs, job.Job)
+		}
+	}
+	s.mu.Unlock()
+
+	page, count, err := paginate(jobs, r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Msg: err.Error()})
+		return
+	}
+	data, err := selectFields(page, r.URL.Query().Get("fields"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, response{Code: http.StatusInternalServerError, Msg: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, response{Code: http.StatusOK, Msg: "ok", Data: data, Count: count})
+}
+
+func (s *Server) handleHosts(w http.ResponseWriter, r *http.Request, user string) {
+	filters, err := parseFilter(r.URL.Query().Get("filter"), false)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Msg: err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	var hosts []client.Host
+	for _, host := range s.hosts {
+		if hostType, ok := filters["hostType"]; ok && !strings.EqualFold(host.HostType, hostType[0]) {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	s.mu.Unlock()
+
+	page, count, err := paginate(hosts, r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Msg: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, response{Code: http.StatusOK, Msg: "ok", Data: page, Count: count})
+}
+
+func (s *Server) handleQueues(w http.ResponseWriter, r *http.Request, user string) {
+	s.mu.Lock()
+	s.update()
+	queues := make([]Queue, len(s.queues))
+	copy(queues, s.queues)
+	for i := range queues {
+		for _, job := range s.jobs {
+			if job.Queue != queues[i].Name || job.Finished() {
+				continue
+			}
+			queues[i].NJobs += job.Slots
+			switch job.Status {
+			case StatusPend:
+				queues[i].Pend += job.Slots
+			case StatusRun:
+				queues[i].Run += job.Slots
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, response{Code: http.StatusOK, Msg: "ok", Data: queues, Count: len(queues)})
+}
+
+// update 按当前时间更新作业状态，调用方需持有锁
+func (s *Server) update() {
+	s.scheduler.Update(s.sortedJobs(), s.clock.Now())
+}
+
+// sortedJobs 返回按作业 ID 排序的作业，调用方需持有锁
+func (s *Server) sortedJobs() []*Job {
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].JobID < jobs[j].JobID })
+	return jobs
+}
+
+// queue 按名称查找队列，调用方需持有锁
+func (s *Server) queue(name string) *Queue {
+	for i := range s.queues {
+		if s.queues[i].Name == name {
+			return &s.queues[i]
+		}
+	}
+	return nil
+}
+
+// matchJob 判断作业是否符合过滤条件，支持 jobid、user、queue 和 status
+func matchJob(job *Job, filters map[string][]string) bool {
+	for field, values := range filters {
+		var actual string
+		switch field {
+		case "jobid":
+			actual = strconv.FormatInt(job.JobID, 10)
+		case "user":
+			actual = job.User
+		case "queue":
+			actual = job.Queue
+		case "status":
+			actual = job.Status
+		default:
+			continue
+		}
+		if !slices.Contains(values, actual) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFilter 解析 [field:eq:value,...] 或 field:eq:value 格式的过滤条件，返回每个字段可以匹配的值。
+// allowIn 为 true 时还支持 field:in:value1|value2
+func parseFilter(filter string, allowIn bool) (map[string][]string, error) {
+	filters := make(map[string][]string)
+	filter = strings.TrimSuffix(strings.TrimPrefix(filter, "["), "]")
+	if filter == "" {
+		return filters, nil
+	}
+	for _, cond := range strings.Split(filter, ",") {
+		parts := strings.SplitN(cond, ":", 3)
+		switch {
+		case len(parts) != 3:
+			return nil, fmt.Errorf("invalid filter: %s", cond)
+		case parts[1] == "eq":
+			filters[parts[0]] = []string{parts[2]}
+		case parts[1] == "in" && allowIn:
+			filters[parts[0]] = strings.Split(parts[2], "|")
+		default:
+			return nil, fmt.Errorf("invalid filter: %s", cond)
+		}
+	}
+	return filters, nil
+}
+
+// paginate 按 limit 和 offset 参数返回一页结果以及结果总数
+func paginate[T any](items []T, r *http.Request) ([]T, int, error) {
+	count := len(items)
+	query := r.URL.Query()
+	if query.Get("limit") == "" {
+		return items, count, nil
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 0 {
+		return nil, 0, fmt.Errorf("invalid limit: %s", query.Get("limit"))
+	}
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if query.Get("offset") != "" && (err != nil || offset < 0) {
+		return nil, 0, fmt.Errorf("invalid offset: %s", query.Get("offset"))
+	}
+	if offset > count {
+		offset = count
+	}
+	end := min(offset+limit, count)
+	return items[offset:end], count, nil
+}
+
+// selectFields 只保留 fields 中列出的字段，fields 为空时返回全部字段
+func selectFields[T any](items []T, fields string) (any, error) {
+	if fields == "" {
+		if items == nil {
+			return []T{}, nil
+		}
+		return items, nil
+	}
+
+	keep := make(map[string]bool)
+	for _, field := range strings.Split(fields, ",") {
+		keep[strings.TrimSpace(field)] = true
+	}
+	selected := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var m map[string]any
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		for key := range m {
+			if !keep[key] {
+				delete(m, key)
+			}
+		}
+		selected = append(selected, m)
+	}
+	return selected, nil
+}
@@ -0,0 +1,84 @@
+package xcetest_test
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+)
+
+func TestSubmitStatus(t *testing.T) {
+	s := xcetest.New()
+	token := s.IssueToken("alice")
+	submit := func(key string) (int, client.JobSubmitResponse) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, "/xce/v1/jobs", strings.NewReader(`{"command": "sleep 60"}`))
+		r.Header.Set("Authorization", "Bearer "+token)
+		r.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		var resp client.JobSubmitResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode %q: %v", w.Body.String(), err)
+		}
+		return w.Code, resp
+	}
+
+	// 新提交的作业返回 201，相同幂等键的重复请求返回 200 和第一次提交的作业
+	status, resp := submit("key-1")
+	if status != http.StatusCreated || resp.Code != http.StatusCreated || resp.Data.JobID != 1 {
+		t.Errorf("submit = %d %+v, want 201 with job 1", status, resp)
+	}
+	status, resp = submit("key-1")
+	if status != http.StatusOK || resp.Data.JobID != 1 {
+		t.Errorf("repeated submit = %d %+v, want 200 with job 1", status, resp)
+	}
+	if status, resp = submit("key-2"); status != http.StatusCreated || resp.Data.JobID != 2 {
+		t.Errorf("submit with another key = %d %+v, want 201 with job 2", status, resp)
+	}
+}
+
+func TestSetJobStateFreesSlots(t *testing.T) {
+	for _, status := range []string{xcetest.StatusDone, xcetest.StatusExit, xcetest.StatusPend} {
+		t.Run(status, func(t *testing.T) {
+			clock := xcetest.NewFakeClock(xcetest.DefaultStartTime)
+			scheduler := xcetest.NewSlotScheduler([]client.Host{{HostName: "node001", MaxCpus: 1}}, rand.New(rand.NewPCG(1, 2)))
+			s := xcetest.New(xcetest.WithClock(clock), xcetest.WithScheduler(scheduler))
+			for _, command := range []string{"sleep 600", "sleep 60"} {
+				if _, err := s.Submit("alice", client.JobSubmitRequest{Command: command}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := jobStatus(s); got != "RUN PEND" {
+				t.Fatalf("jobs = %s, want RUN PEND", got)
+			}
+
+			// 指定第一个作业的状态后其作业槽释放，排队的作业开始运行
+			if err := s.SetJobState(1, status, 0); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := jobStatus(s), status+" RUN"; got != want {
+				t.Errorf("jobs after SetJobState = %s, want %s", got, want)
+			}
+			clock.Advance(time.Minute)
+			if got, want := jobStatus(s), status+" DONE"; got != want {
+				t.Errorf("jobs after the second job ends = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+// jobStatus 返回按作业 ID 排列的作业状态
+func jobStatus(s *xcetest.Server) string {
+	var status []string
+	for _, job := range s.Jobs() {
+		status = append(status, job.Status)
+	}
+	return strings.Join(status, " ")
+}
@@ -0,0 +1,217 @@
+package xcetest
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+)
+
+// SlotScheduler 按主机作业槽调度的模拟调度器: 作业提交后排队，有足够的空闲槽时按队列优先级和
+// 提交顺序派发到主机上运行，运行到请求的时长后结束并释放作业槽
+type SlotScheduler struct {
+	// MinRun 和 MaxRun 未指定运行时长的作业在该范围内随机运行
+	MinRun time.Duration
+	MaxRun time.Duration
+	// FailRate 作业以非 0 退出码结束的概率
+	FailRate float64
+	// Priority 返回队列优先级，值越大越先派发，为 nil 时按提交顺序派发
+	Priority func(queue string) int
+
+	hosts []slotHost
+	rng   *rand.Rand
+	// plans 作业的运行时长和退出码，在提交时确定，保证同一种子下结果可以复现
+	plans map[int64]plan
+}
+
+type slotHost struct {
+	name  string
+	slots int
+	used  int
+}
+
+type plan struct {
+	run      time.Duration
+	exitCode int
+}
+
+// NewSlotScheduler 创建调度到 hosts 上的调度器，每个主机的作业槽数为其 MaxCpus，rng 决定随机的运行时长和失败
+func NewSlotScheduler(hosts []client.Host, rng *rand.Rand) *SlotScheduler {
+	s := &SlotScheduler{
+		MinRun: 30 * time.Second,
+		MaxRun: 5 * time.Minute,
+		rng:    rng,
+		plans:  make(map[int64]plan),
+	}
+	for _, h := range hosts {
+		s.hosts = append(s.hosts, slotHost{name: h.HostName, slots: max(h.MaxCpus, 1)})
+	}
+	return s
+}
+
+// Submit 实现 Scheduler，按资源需求确定作业槽数、运行时长和退出码
+func (s *SlotScheduler) Submit(job *Job, now time.Time) {
+	job.Status = StatusPend
+	job.Slots = 1
+	if n, ok := resReqValue(job.ResReq, "slots"); ok {
+		if slots, err := strconv.Atoi(n); err == nil && slots > 0 {
+			job.Slots = slots
+		}
+	}
+
+	p := plan{run: s.runTime(job)}
+	if s.FailRate > 0 && s.rng.Float64() < s.FailRate {
+		p.exitCode = 1 + s.rng.IntN(127)
+	}
+	s.plans[job.JobID] = p
+}
+
+// runTime 返回作业的运行时长: 资源需求中的 duration=，其次为 sleep 命令的秒数，否则随机
+func (s *SlotScheduler) runTime(job *Job) time.Duration {
+	if v, ok := resReqValue(job.ResReq, "duration"); ok {
+		if d, err := parseSeconds(v); err == nil {
+			return d
+		}
+	}
+	if fields := strings.Fields(job.Command); len(fields) == 2 && fields[0] == "sleep" {
+		if d, err := parseSeconds(fields[1]); err == nil {
+			return d
+		}
+	}
+	if s.MaxRun <= s.MinRun {
+		return s.MinRun
+	}
+	return s.MinRun + time.Duration(s.rng.Int64N(int64(s.MaxRun-s.MinRun)))
+}
+
+// Update 实现 Scheduler，按时间顺序处理 now 之前结束的作业，每释放一次作业槽就派发一次排队的作业
+func (s *SlotScheduler) Update(jobs []*Job, now time.Time) {
+	s.recount(jobs)
+	for {
+		next := s.nextEnd(jobs, now)
+		if next == nil {
+			break
+		}
+		s.release(next)
+		finish(next, next.StartAt.Add(s.plans[next.JobID].run))
+		s.dispatch(jobs, next.EndAt)
+	}
+	s.dispatch(jobs, now)
+}
+
+// nextEnd 返回 now 之前最早结束的运行中作业
+func (s *SlotScheduler) nextEnd(jobs []*Job, now time.Time) *Job {
+	var next *Job
+	var nextEnd time.Time
+	for _, job := range jobs {
+		if job.Status != StatusRun || job.pinned {
+			continue
+		}
+		end := job.StartAt.Add(s.plans[job.JobID].run)
+		if end.After(now) {
+			continue
+		}
+		if next == nil || end.Before(nextEnd) {
+			next, nextEnd = job, end
+		}
+	}
+	return next
+}
+
+// dispatch 在 at 时刻将排队的作业派发到有足够空闲槽的主机上
+func (s *SlotScheduler) dispatch(jobs []*Job, at time.Time) {
+	var pending []*Job
+	for _, job := range jobs {
+		if job.Status == StatusPend && !job.pinned && !job.SubmitAt.After(at) {
+			pending = append(pending, job)
+		}
+	}
+	if s.Priority != nil {
+		sort.SliceStable(pending, func(i, j int) bool {
+			return s.Priority(pending[i].Queue) > s.Priority(pending[j].Queue)
+		})
+	}
+
+	for _, job := range pending {
+		host := s.freeHost(job.Slots)
+		if host == nil {
+			continue
+		}
+		host.used += job.Slots
+		job.Hosts = []string{host.name}
+		job.Status = StatusRun
+		job.StartAt = at
+		job.ExitCode = s.plans[job.JobID].exitCode
+	}
+}
+
+// freeHost 返回第一个空闲槽不少于 slots 的主机
+func (s *SlotScheduler) freeHost(slots int) *slotHost {
+	for i := range s.hosts {
+		if s.hosts[i].slots-s.hosts[i].used >= slots {
+			return &s.hosts[i]
+		}
+	}
+	return nil
+}
+
+// recount 按运行中的作业重新计算各主机已用的作业槽，
+// 通过 SetJobState 结束或改回排队的作业不再经过 nextEnd，其作业槽在这里释放
+func (s *SlotScheduler) recount(jobs []*Job) {
+	for i := range s.hosts {
+		s.hosts[i].used = 0
+	}
+	for _, job := range jobs {
+		if job.Status == StatusRun {
+			s.acquire(job)
+		}
+	}
+}
+
+// acquire 占用作业所在主机的作业槽
+func (s *SlotScheduler) acquire(job *Job) {
+	for _, name := range job.Hosts {
+		for i := range s.hosts {
+			if s.hosts[i].name == name {
+				s.hosts[i].used += job.Slots
+			}
+		}
+	}
+}
+
+// release 释放作业占用的作业槽
+func (s *SlotScheduler) release(job *Job) {
+	for _, name := range job.Hosts {
+		for i := range s.hosts {
+			if s.hosts[i].name == name {
+				s.hosts[i].used -= job.Slots
+			}
+		}
+	}
+}
+
+// resReqValue 读取资源需求中 key=value 形式的值，多个条件以空格或分号分隔
+func resReqValue(resReq, key string) (string, bool) {
+	for _, field := range strings.FieldsFunc(resReq, func(r rune) bool { return r == ' ' || r == ';' }) {
+		if k, v, ok := strings.Cut(field, "="); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// parseSeconds 解析秒数或 Go 时长格式，如 90、1m30s
+func parseSeconds(s string) (time.Duration, error) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("无效的时长: %s", s)
+	}
+	return d, nil
+}
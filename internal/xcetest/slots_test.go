@@ -0,0 +1,163 @@
+package xcetest_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+)
+
+// newJob 创建在 at 时刻提交的作业
+func newJob(id int64, queue, command, resReq string, at time.Time) *xcetest.Job {
+	return &xcetest.Job{
+		Job:      client.Job{JobID: id, Queue: queue, Command: command, ResReq: resReq},
+		SubmitAt: at,
+	}
+}
+
+// submitAll 依次向调度器提交作业
+func submitAll(s *xcetest.SlotScheduler, jobs []*xcetest.Job) {
+	for _, job := range jobs {
+		s.Submit(job, job.SubmitAt)
+	}
+}
+
+func TestSlotSchedulerPriority(t *testing.T) {
+	start := xcetest.DefaultStartTime
+	s := xcetest.NewSlotScheduler([]client.Host{{HostName: "node001", MaxCpus: 1}}, rand.New(rand.NewPCG(1, 2)))
+	priorities := map[string]int{"short": 40, "normal": 30, "long": 20}
+	s.Priority = func(queue string) int { return priorities[queue] }
+
+	jobs := []*xcetest.Job{
+		newJob(1, "long", "sleep 60", "", start),
+		newJob(2, "normal", "sleep 60", "", start),
+		newJob(3, "short", "sleep 60", "", start),
+		newJob(4, "long", "sleep 60", "", start),
+	}
+	submitAll(s, jobs)
+
+	// 只有一个作业槽，高优先级队列中的作业先运行，同一队列按提交顺序
+	s.Update(jobs, start)
+	if got := statuses(jobs); got != "PEND PEND RUN PEND" {
+		t.Fatalf("status = %s, want only job 3 running", got)
+	}
+	s.Update(jobs, start.Add(4*time.Minute))
+	for id, want := range map[int64]time.Duration{3: 0, 2: time.Minute, 1: 2 * time.Minute, 4: 3 * time.Minute} {
+		job := jobs[id-1]
+		if job.Status != xcetest.StatusDone || job.StartAt.Sub(start) != want || job.EndAt.Sub(job.StartAt) != time.Minute {
+			t.Errorf("job %d: %s started at +%s ended at +%s, want DONE started at +%s",
+				id, job.Status, job.StartAt.Sub(start), job.EndAt.Sub(start), want)
+		}
+	}
+}
+
+func TestSlotSchedulerFreesSlots(t *testing.T) {
+	clock := xcetest.NewFakeClock(xcetest.DefaultStartTime)
+	hosts := []client.Host{{HostName: "node001", MaxCpus: 4}, {HostName: "node002", MaxCpus: 2}}
+	s := xcetest.NewSlotScheduler(hosts, rand.New(rand.NewPCG(1, 2)))
+
+	jobs := []*xcetest.Job{
+		newJob(1, "normal", "sleep 60", "slots=3", clock.Now()),
+		newJob(2, "normal", "sleep 60", "slots=2", clock.Now()),
+		newJob(3, "normal", "sleep 120", "slots=2", clock.Now()),
+		newJob(4, "normal", "sleep 30", "slots=1", clock.Now()),
+		// 超过任何主机的作业槽数，一直排队
+		newJob(5, "normal", "sleep 30", "slots=8", clock.Now()),
+	}
+	submitAll(s, jobs)
+
+	// node001 剩余 1 个作业槽，node002 被作业 2 占满，作业 3 排队，作业 4 使用 node001 的剩余作业槽
+	s.Update(jobs, clock.Now())
+	if got := statuses(jobs); got != "RUN RUN PEND RUN PEND" {
+		t.Fatalf("status = %s, want RUN RUN PEND RUN PEND", got)
+	}
+	if got := hostsOf(jobs); got != "node001 node002 - node001 -" {
+		t.Errorf("hosts = %s, want node001 node002 - node001 -", got)
+	}
+
+	// 作业 4 结束后剩余的作业槽仍不够作业 3 使用
+	clock.Advance(30 * time.Second)
+	s.Update(jobs, clock.Now())
+	if got := statuses(jobs); got != "RUN RUN PEND DONE PEND" {
+		t.Fatalf("status after 30s = %s, want job 3 still pending", got)
+	}
+
+	// 作业 1 和 2 同时结束，作业 3 在先释放的 node001 上运行
+	clock.Advance(30 * time.Second)
+	s.Update(jobs, clock.Now())
+	if got := statuses(jobs); got != "DONE DONE RUN DONE PEND" {
+		t.Fatalf("status after 60s = %s, want job 3 running", got)
+	}
+	if job := jobs[2]; job.Hosts[0] != "node001" || !job.StartAt.Equal(clock.Now()) {
+		t.Errorf("job 3 runs on %v from %s, want node001 from %s", job.Hosts, job.StartAt, clock.Now())
+	}
+
+	clock.Advance(time.Hour)
+	s.Update(jobs, clock.Now())
+	if got := statuses(jobs); got != "DONE DONE DONE DONE PEND" {
+		t.Errorf("status after an hour = %s, want job 5 still pending", got)
+	}
+}
+
+func TestSlotSchedulerDeterministic(t *testing.T) {
+	simulate := func(seed uint64) string {
+		start := xcetest.DefaultStartTime
+		rng := rand.New(rand.NewPCG(seed, 2))
+		s := xcetest.NewSlotScheduler(xcetest.GenerateHosts(3, rng), rng)
+		s.FailRate = 0.3
+
+		var jobs []*xcetest.Job
+		for i := range 40 {
+			jobs = append(jobs, newJob(int64(i+1), "normal", "hostname", fmt.Sprintf("slots=%d", 1+i%8), start.Add(time.Duration(i)*time.Second)))
+		}
+		submitAll(s, jobs)
+		// 每 7 分钟推进一次时间，与假服务器处理请求时一样多次调用 Update
+		for at := start; !at.After(start.Add(2 * time.Hour)); at = at.Add(7 * time.Minute) {
+			s.Update(jobs, at)
+		}
+
+		var b strings.Builder
+		for _, job := range jobs {
+			fmt.Fprintf(&b, "%d %s %v %d %s %s\n", job.JobID, job.Status, job.Hosts, job.ExitCode,
+				job.StartAt.Sub(start), job.EndAt.Sub(start))
+		}
+		return b.String()
+	}
+
+	first := simulate(42)
+	if second := simulate(42); second != first {
+		t.Errorf("same seed gives different results:\n%s\nand\n%s", first, second)
+	}
+	if !strings.Contains(first, xcetest.StatusExit) || strings.Contains(first, xcetest.StatusPend) {
+		t.Errorf("simulation with seed 42 = \n%s\nwant all jobs finished with some failures", first)
+	}
+	if other := simulate(7); other == first {
+		t.Error("different seeds give the same results")
+	}
+}
+
+// statuses 返回各作业的状态
+func statuses(jobs []*xcetest.Job) string {
+	var status []string
+	for _, job := range jobs {
+		status = append(status, job.Status)
+	}
+	return strings.Join(status, " ")
+}
+
+// hostsOf 返回各作业运行的主机，未运行时为 -
+func hostsOf(jobs []*xcetest.Job) string {
+	var hosts []string
+	for _, job := range jobs {
+		if len(job.Hosts) == 0 {
+			hosts = append(hosts, "-")
+			continue
+		}
+		hosts = append(hosts, strings.Join(job.Hosts, ","))
+	}
+	return strings.Join(hosts, " ")
+}
@@ -1,31 +1,30 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"k8s.io/klog/v2"
 
-	"github.com/xx/cmd"
-	"github.com/xx/pkg/config"
+	"github.com/zhengyansheng/xcli/cmd"
+	"github.com/zhengyansheng/xcli/pkg/config"
 )
 
 func main() {
-	// 初始化配置管理器
-	configManager, err := config.NewConfigManager()
-	if err != nil {
-		klog.Errorf("初始化配置失败: %v\n", err)
-		os.Exit(1)
-	}
+	// 初始化配置管理器，配置文件路径在解析 --config 参数后确定
+	configManager := config.NewConfigManager()
 
-	// 确保配置管理器被正确初始化
-	if configManager == nil {
-		klog.Error("配置管理器初始化失败")
-		os.Exit(1)
-	}
+	// 按下 Ctrl-C 或收到 SIGTERM 时取消正在进行的请求
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := cmd.Execute(ctx, configManager)
+	stop()
 
-	// 执行命令
-	if err := cmd.Execute(configManager); err != nil {
-		klog.Fatal(err)
-		os.Exit(1)
+	// 根据错误类型返回不同的退出码
+	if err != nil {
+		klog.Error(err)
+		klog.Flush()
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,287 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// BundleKind 配置包的类型标识
+const BundleKind = "cli-config-bundle"
+
+// 导入配置包时处理冲突的方式
+const (
+	// ConflictError 存在冲突时不做任何修改并报错
+	ConflictError = "error"
+	// ConflictSkip 保留本地配置
+	ConflictSkip = "skip"
+	// ConflictOverwrite 使用配置包中的内容覆盖本地配置
+	ConflictOverwrite = "overwrite"
+)
+
+// Bundle 可以分享给其他用户的配置包，不包含 token、账号和客户端证书等个人信息
+type Bundle struct {
+	Kind             string         `json:"kind"`
+	SchemaVersion    int            `json:"schemaVersion"`
+	DefaultAPIServer string         `json:"defaultAPIserver,omitempty"`
+	Servers          []BundleServer `json:"servers"`
+	Contexts         []Context      `json:"contexts,omitempty"`
+}
+
+// BundleServer 配置包中的服务器信息，与 APIServerInfo 字段相同，但 CA 证书和证书包以 PEM 内容的形式保存，
+// 不包含 token 和客户端证书等个人信息
+type BundleServer APIServerInfo
+
+// Export 导出指定的服务器 (名称或 URL，为空时导出全部) 以及引用这些服务器的上下文
+func (c *Config) Export(names ...string) (*Bundle, error) {
+	servers := c.APIServerInfo
+	if len(names) > 0 {
+		servers = nil
+		for _, name := range names {
+			server := c.LookupServer(name)
+			if server == nil {
+				return nil, fmt.Errorf("未找到指定的 APIserver: %s", name)
+			}
+			servers = append(servers, *server)
+		}
+	}
+
+	bundle := &Bundle{
+		Kind:          BundleKind,
+		SchemaVersion: CurrentSchemaVersion,
+		Servers:       make([]BundleServer, 0, len(servers)),
+	}
+	exported := make(map[string]bool)
+	for _, server := range servers {
+		item, err := exportServer(server)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Servers = append(bundle.Servers, item)
+		exported[server.Name] = true
+		if server.URL == c.DefaultAPIServer {
+			bundle.DefaultAPIServer = server.URL
+		}
+	}
+
+	for _, ctx := range c.Contexts {
+		server := c.LookupServer(ctx.Server)
+		if server == nil || !exported[server.Name] {
+			continue
+		}
+		ctx.Server = server.Name
+		ctx.Account = ""
+		bundle.Contexts = append(bundle.Contexts, ctx)
+	}
+	return bundle, nil
+}
+
+// Import 将配置包合并到配置中，CA 证书保存到 certPath 返回的服务器证书路径，返回所做修改的说明。
+// 同一 URL 的服务器或同名上下文内容不同时按 policy 处理；
+// 名称与本地其他服务器重复的新服务器会被重命名，使用 ConflictError 时视为冲突
+func (c *Config) Import(bundle *Bundle, certPath func(serverURL string) (string, error), policy string) ([]string, error) {
+	if bundle.Kind != BundleKind {
+		return nil, fmt.Errorf("不是有效的配置包")
+	}
+	if bundle.SchemaVersion > CurrentSchemaVersion {
+		return nil, fmt.Errorf("配置包版本 %d 高于当前 cli 支持的版本 %d，请升级 cli", bundle.SchemaVersion, CurrentSchemaVersion)
+	}
+	switch policy {
+	case ConflictError, ConflictSkip, ConflictOverwrite:
+	default:
+		return nil, fmt.Errorf("无效的冲突处理方式: %s，必须是 error、skip 或 overwrite", policy)
+	}
+
+	// 在副本上修改，存在冲突时不影响原配置
+	work := *c
+	work.APIServerInfo = append([]APIServerInfo{}, c.APIServerInfo...)
+	work.Contexts = append([]Context{}, c.Contexts...)
+
+	var changes, conflicts []string
+	certFiles := make(map[string]string)
+	localNames := make(map[string]string) // 配置包中的服务器名称 -> 本地名称
+
+	for _, item := range bundle.Servers {
+		if item.URL == "" {
+			return nil, fmt.Errorf("配置包中的 APIserver %s 未设置 url", item.Name)
+		}
+
+		if local := work.FindServer(item.URL); local != nil {
+			localNames[item.Name] = local.Name
+			if sameBundleServer(local, item) {
+				continue
+			}
+			conflicts = append(conflicts, fmt.Sprintf("APIserver %s (%s) 与本地配置不同", local.Name, item.URL))
+			if policy == ConflictOverwrite {
+				if err := applyBundleServer(local, item, certPath, certFiles); err != nil {
+					return nil, err
+				}
+				changes = append(changes, fmt.Sprintf("更新 APIserver %s (%s)", local.Name, item.URL))
+			} else {
+				changes = append(changes, fmt.Sprintf("跳过 APIserver %s (%s)，保留本地配置", local.Name, item.URL))
+			}
+			continue
+		}
+
+		server := APIServerInfo{Name: item.Name, URL: item.URL}
+		if server.Name == "" {
+			server.Name = work.NextServerName()
+		} else if work.LookupServer(server.Name) != nil {
+			server.Name = uniqueServerName(&work, item.Name)
+			conflicts = append(conflicts, fmt.Sprintf("APIserver 名称 %s 已被 %s 使用", item.Name, work.LookupServer(item.Name).URL))
+		}
+		if err := applyBundleServer(&server, item, certPath, certFiles); err != nil {
+			return nil, err
+		}
+		added, err := work.AddServer(server)
+		if err != nil {
+			return nil, err
+		}
+		localNames[item.Name] = added.Name
+		if added.Name != item.Name {
+			changes = append(changes, fmt.Sprintf("添加 APIserver %s (%s)，已重命名为 %s", item.Name, item.URL, added.Name))
+		} else {
+			changes = append(changes, fmt.Sprintf("添加 APIserver %s (%s)", added.Name, item.URL))
+		}
+	}
+
+	for _, ctx := range bundle.Contexts {
+		if name, ok := localNames[ctx.Server]; ok {
+			ctx.Server = name
+		} else if work.LookupServer(ctx.Server) == nil {
+			changes = append(changes, fmt.Sprintf("跳过上下文 %s，引用的 APIserver %s 不存在", ctx.Name, ctx.Server))
+			continue
+		}
+
+		local := work.LookupContext(ctx.Name)
+		if local == nil {
+			if err := work.SetContext(ctx); err != nil {
+				return nil, err
+			}
+			changes = append(changes, fmt.Sprintf("添加上下文 %s", ctx.Name))
+			continue
+		}
+
+		// 配置包不包含账号，保留本地设置的账号
+		ctx.Account = local.Account
+		if *local == ctx {
+			continue
+		}
+		conflicts = append(conflicts, fmt.Sprintf("上下文 %s 与本地配置不同", ctx.Name))
+		if policy == ConflictOverwrite {
+			*local = ctx
+			changes = append(changes, fmt.Sprintf("更新上下文 %s", ctx.Name))
+		} else {
+			changes = append(changes, fmt.Sprintf("跳过上下文 %s，保留本地配置", ctx.Name))
+		}
+	}
+
+	if server := work.FindServer(bundle.DefaultAPIServer); work.DefaultAPIServer == "" && server != nil {
+		work.DefaultAPIServer = server.URL
+		changes = append(changes, fmt.Sprintf("设置默认 APIserver 为 %s", server.URL))
+	}
+
+	if len(conflicts) > 0 && policy == ConflictError {
+		return nil, fmt.Errorf("配置包与本地配置存在冲突:\n  %s\n请使用 --conflict skip 保留本地配置，或 --conflict overwrite 覆盖本地配置",
+			strings.Join(conflicts, "\n  "))
+	}
+	if err := work.Validate(); err != nil {
+		return nil, fmt.Errorf("导入后的配置无效: %v", err)
+	}
+
+	for path, content := range certFiles {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(path, []byte(content), 0600); err != nil {
+			return nil, fmt.Errorf("保存证书失败: %v", err)
+		}
+	}
+
+	*c = work
+	return changes, nil
+}
+
+// readPEM 读取证书文件内容，路径为空时返回空字符串
+func readPEM(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// exportServer 将服务器转换为配置包中的形式，去掉个人信息并读取证书内容
+func exportServer(server APIServerInfo) (BundleServer, error) {
+	item := copyPersonal(BundleServer(server), BundleServer{})
+	var err error
+	if item.CACert, err = readPEM(server.CACert); err != nil {
+		return item, fmt.Errorf("读取 APIserver %s 的 CA 证书失败: %v", server.Name, err)
+	}
+	if item.CABundle, err = readPEM(server.CABundle); err != nil {
+		return item, fmt.Errorf("读取 APIserver %s 的证书包失败: %v", server.Name, err)
+	}
+	return item, nil
+}
+
+// copyPersonal 返回将 token 和客户端证书等不随配置包分享的个人信息替换为 from 中的值后的 item
+func copyPersonal(item, from BundleServer) BundleServer {
+	item.Token = from.Token
+	item.Path = from.Path
+	item.ClientCert = from.ClientCert
+	item.ClientKey = from.ClientKey
+	item.PKCS12 = from.PKCS12
+	return item
+}
+
+// sameBundleServer 判断本地服务器与配置包中的服务器是否一致，不比较名称和个人信息
+func sameBundleServer(server *APIServerInfo, item BundleServer) bool {
+	exported, err := exportServer(*server)
+	if err != nil {
+		return false
+	}
+	item = copyPersonal(item, BundleServer{})
+	item.Name, item.URL = exported.Name, exported.URL
+	return reflect.DeepEqual(exported, item)
+}
+
+// applyBundleServer 使用配置包中的内容更新服务器，保留服务器的名称、URL 和个人信息，
+// 需要保存的证书记录到 certFiles (路径 -> 内容)
+func applyBundleServer(server *APIServerInfo, item BundleServer, certPath func(string) (string, error), certFiles map[string]string) error {
+	local := BundleServer(*server)
+	item = copyPersonal(item, local)
+	item.Name, item.URL = local.Name, local.URL
+	*server = APIServerInfo(item)
+	if item.CACert == "" && item.CABundle == "" {
+		return nil
+	}
+
+	path, err := certPath(server.URL)
+	if err != nil {
+		return fmt.Errorf("获取 APIserver %s 的证书路径失败: %v", server.Name, err)
+	}
+	if item.CACert != "" {
+		server.CACert = path
+		certFiles[server.CACert] = item.CACert
+	}
+	if item.CABundle != "" {
+		server.CABundle = strings.TrimSuffix(path, ".crt") + "-bundle.crt"
+		certFiles[server.CABundle] = item.CABundle
+	}
+	return nil
+}
+
+// uniqueServerName 生成以 name 为前缀且未被占用的服务器名称，如 prod-2
+func uniqueServerName(c *Config, name string) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s-%d", name, n)
+		if c.LookupServer(candidate) == nil {
+			return candidate
+		}
+	}
+}
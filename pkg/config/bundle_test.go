@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.crt")
+	const caPEM = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	if err := os.WriteFile(caPath, []byte(caPEM), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &Config{APIServerInfo: []APIServerInfo{{
+		Name:       "prod",
+		URL:        "https://prod.example.com:8443",
+		Token:      "secret-token",
+		Path:       "/home/alice",
+		ClientCert: "/home/alice/client.crt",
+		ClientKey:  "/home/alice/client.key",
+		CACert:     caPath,
+		Proxy:      "http://proxy:3128",
+		Retries:    3,
+	}}}
+	bundle, err := source.Export()
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, personal := range []string{"secret-token", "/home/alice", "token", "client_cert"} {
+		if strings.Contains(string(data), personal) {
+			t.Errorf("exported bundle contains %q: %s", personal, data)
+		}
+	}
+
+	certDir := filepath.Join(dir, "certs")
+	certPath := func(serverURL string) (string, error) {
+		return filepath.Join(certDir, "prod.example.com_8443.crt"), nil
+	}
+	target := &Config{APIServerInfo: []APIServerInfo{}}
+	if _, err := target.Import(bundle, certPath, ConflictError); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	imported := target.FindServer("https://prod.example.com:8443")
+	if imported == nil {
+		t.Fatal("imported server not found")
+	}
+	if imported.Token != "" || imported.ClientCert != "" {
+		t.Errorf("imported personal fields: token=%q client_cert=%q", imported.Token, imported.ClientCert)
+	}
+	if imported.Proxy != "http://proxy:3128" || imported.Retries != 3 {
+		t.Errorf("imported proxy=%q retries=%d, want the exported values", imported.Proxy, imported.Retries)
+	}
+	if want := filepath.Join(certDir, "prod.example.com_8443.crt"); imported.CACert != want {
+		t.Errorf("cacert = %q, want %q", imported.CACert, want)
+	}
+	if got, err := os.ReadFile(imported.CACert); err != nil || string(got) != caPEM {
+		t.Errorf("imported cacert content = %q, %v", got, err)
+	}
+
+	// 再次导入没有变化
+	changes, err := target.Import(bundle, certPath, ConflictError)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("re-Import = %v, %v, want no changes", changes, err)
+	}
+
+	// 覆盖本地配置时保留 token
+	target.FindServer("https://prod.example.com:8443").Token = "local-token"
+	bundle.Servers[0].Proxy = "http://other:3128"
+	if _, err := target.Import(bundle, certPath, ConflictError); err == nil {
+		t.Error("Import with a conflicting server and ConflictError: want error")
+	}
+	if _, err := target.Import(bundle, certPath, ConflictOverwrite); err != nil {
+		t.Fatalf("Import overwrite: %v", err)
+	}
+	imported = target.FindServer("https://prod.example.com:8443")
+	if imported.Proxy != "http://other:3128" || imported.Token != "local-token" {
+		t.Errorf("after overwrite proxy=%q token=%q, want bundle proxy and local token", imported.Proxy, imported.Token)
+	}
+}
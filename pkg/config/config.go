@@ -1,63 +1,103 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"os/user"
-	"path"
+	"path/filepath"
 )
 
 type APIServerInfo struct {
-	Name         string `json:"name"`
-	URL          string `json:"url"`
-	Token        string `json:"token"`
-	Path         string `json:"path"`
-	JobIDRange   string `json:"jobid_range,omitempty"`
-	ClusterIndex string `json:"cluster_index,omitempty"`
-	Version      string `json:"version,omitempty"`
+	Name           string  `json:"name"`
+	URL            string  `json:"url" url:"true"`
+	Token          string  `json:"token,omitempty" secret:"true"`
+	Path           string  `json:"path,omitempty"`
+	JobIDRange     string  `json:"jobid_range,omitempty"`
+	ClusterIndex   string  `json:"cluster_index,omitempty"`
+	Version        string  `json:"version,omitempty"`
+	APIVersion     string  `json:"api_version,omitempty"`
+	Capabilities   *string `json:"capabilities,omitempty"`
+	Fingerprint    string  `json:"fingerprint,omitempty"`
+	CACert         string  `json:"cacert,omitempty"`
+	CABundle       string  `json:"ca_bundle,omitempty"`
+	SystemCA       bool    `json:"system_ca,omitempty"`
+	ClientCert     string  `json:"client_cert,omitempty"`
+	ClientKey      string  `json:"client_key,omitempty"`
+	PKCS12         string  `json:"pkcs12,omitempty"`
+	TLSServerName  string  `json:"tls_server_name,omitempty"`
+	Retries        int     `json:"retries,omitempty"`
+	RetryWait      string  `json:"retry_wait,omitempty"`
+	RetryMaxWait   string  `json:"retry_max_wait,omitempty"`
+	Proxy          string  `json:"proxy,omitempty"`
+	NoProxy        string  `json:"no_proxy,omitempty"`
+	Hosts          string  `json:"hosts,omitempty"`
+	UnixSocket     string  `json:"unix_socket,omitempty"`
+	ConnectTimeout string  `json:"connect_timeout,omitempty"`
 }
 
 type Config struct {
+	SchemaVersion    int             `json:"schemaVersion"`
 	Account          string          `json:"account"`
-	DefaultAPIServer string          `json:"defaultAPIserver"`
-	DefaultQueryAll  bool            `json:"defaultqueryall"`
-	CACert           string          `json:"cacert"`
+	DefaultAPIServer string          `json:"defaultAPIserver" url:"true"`
+	DefaultQueryAll  *bool           `json:"defaultqueryall,omitempty"`
 	APIServerInfo    []APIServerInfo `json:"servers"`
+	CurrentContext   string          `json:"current-context,omitempty"`
+	Contexts         []Context       `json:"contexts,omitempty"`
 }
 
+// ErrCorruptConfig 表示配置文件内容无法解析
+var ErrCorruptConfig = errors.New("配置文件已损坏")
+
 // ConfigManager 用于统一管理配置
 type ConfigManager struct {
-	config     *Config
-	configPath string
+	config          *Config
+	configPath      string
+	contextOverride string
+	// loaded 加载时配置文件的原始内容，保存时用于判断文件是否已被其他进程修改
+	loaded []byte
+	// system 系统级配置，用户配置叠加在其上，没有系统级配置时为 nil
+	system     *Config
+	systemPath string
 }
 
-// NewConfigManager 创建配置管理器
-func NewConfigManager() (*ConfigManager, error) {
-	usr, err := user.Current()
-	if err != nil {
-		return nil, fmt.Errorf("获取用户信息失败: %v", err)
+// NewConfigManager 创建配置管理器。配置文件路径在第一次读写配置时按 ResolveConfigPath 的优先级确定，
+// 以便在解析命令行参数后通过 SetConfigPath 指定，无法确定用户主目录时也能使用 --config
+func NewConfigManager() *ConfigManager {
+	return &ConfigManager{
+		systemPath: SystemConfigPath(),
 	}
+}
 
-	configDir := path.Join(usr.HomeDir, ".cli")
-	configPath := path.Join(configDir, "config.json")
-
-	// 确保配置目录存在
-	if err := os.MkdirAll(configDir, 0700); err != nil {
-		return nil, fmt.Errorf("创建配置目录失败: %v", err)
-	}
+// SetConfigPath 设置配置文件路径，已加载的配置会被丢弃
+func (cm *ConfigManager) SetConfigPath(configPath string) {
+	cm.configPath = configPath
+	cm.config = nil
+	cm.loaded = nil
+}
 
-	cm := &ConfigManager{
-		configPath: configPath,
+// resolvePath 返回配置文件路径，未通过 SetConfigPath 指定时按环境变量和用户主目录确定
+func (cm *ConfigManager) resolvePath() (string, error) {
+	if cm.configPath == "" {
+		configPath, err := ResolveConfigPath("")
+		if err != nil {
+			return "", err
+		}
+		cm.configPath = configPath
 	}
+	return cm.configPath, nil
+}
 
-	// 初始化时加载配置
-	_, err = cm.GetConfig()
-	if err != nil {
-		return nil, fmt.Errorf("加载配置失败: %v", err)
-	}
+// ConfigPath 返回配置文件路径，无法确定时返回空字符串
+func (cm *ConfigManager) ConfigPath() string {
+	configPath, _ := cm.resolvePath()
+	return configPath
+}
 
-	return cm, nil
+// CertDir 返回保存服务器证书的目录，位于配置文件所在目录下
+func (cm *ConfigManager) CertDir() string {
+	return filepath.Join(filepath.Dir(cm.ConfigPath()), "certs")
 }
 
 // GetConfig 获取配置
@@ -70,6 +110,12 @@ func (cm *ConfigManager) GetConfig() (*Config, error) {
 		return cm.config, nil
 	}
 
+	unlock, err := cm.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	config, err := cm.loadConfig()
 	if err != nil {
 		return nil, err
@@ -79,71 +125,203 @@ func (cm *ConfigManager) GetConfig() (*Config, error) {
 	return config, nil
 }
 
-// SaveConfig 保存配置
-func (cm *ConfigManager) SaveConfig(config *Config) error {
-	data, err := json.MarshalIndent(config, "", "  ")
+// Update 在持有配置文件锁期间重新加载配置、调用 fn 修改并保存，多个进程同时修改配置时不会互相覆盖。
+// fn 返回错误时不保存配置并原样返回该错误，修改配置的命令都应通过 Update 完成
+func (cm *ConfigManager) Update(fn func(config *Config) error) error {
+	if cm == nil {
+		return fmt.Errorf("配置管理器未初始化")
+	}
+
+	unlock, err := cm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := cm.loadConfig()
 	if err != nil {
+		return fmt.Errorf("获取配置失败: %w", err)
+	}
+	if err := fn(config); err != nil {
 		return err
 	}
+	if err := cm.saveConfig(config); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	return nil
+}
+
+// lock 对配置文件加排他锁，读取、修改和保存配置期间持有，返回的函数用于解锁
+func (cm *ConfigManager) lock() (func(), error) {
+	configPath, err := cm.resolvePath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		return nil, err
+	}
+	return lockFile(configPath + ".lock")
+}
 
-	dir := path.Dir(cm.configPath)
-	if err := os.MkdirAll(dir, 0700); err != nil {
+// SaveConfig 保存通过 GetConfig 获取并修改的配置。读取和保存之间没有持有文件锁，
+// 如果期间配置文件已被其他进程修改，会尽量将本进程的修改合并到最新的配置上，合并结果写回 config；
+// 需要保证读-改-写原子性时应使用 Update
+func (cm *ConfigManager) SaveConfig(config *Config) error {
+	unlock, err := cm.lock()
+	if err != nil {
 		return err
 	}
+	defer unlock()
+	return cm.saveConfig(config)
+}
 
-	if err := os.WriteFile(cm.configPath, data, 0600); err != nil {
+// saveConfig 在持有文件锁时保存配置，只有与系统级配置不同的部分会写入用户配置文件。
+// 通过临时文件加重命名原子地替换配置文件，被替换的配置保存为 BackupPath 以便恢复
+func (cm *ConfigManager) saveConfig(config *Config) error {
+	current, err := os.ReadFile(cm.configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	user := unlayerConfig(cm.system, config)
+	if err == nil && !bytes.Equal(current, cm.loaded) {
+		if err := cm.mergeInto(user, current); err != nil {
+			return err
+		}
+	}
+
+	user.SchemaVersion = CurrentSchemaVersion
+	data, err := json.MarshalIndent(user, "", "  ")
+	if err != nil {
+		return err
+	}
+	if len(current) > 0 && !bytes.Equal(current, data) {
+		if err := writeFileAtomic(cm.BackupPath(), current, 0600); err != nil {
+			return fmt.Errorf("备份配置文件失败: %v", err)
+		}
+	}
+	if err := writeFileAtomic(cm.configPath, data, 0600); err != nil {
 		return err
 	}
 
+	*config = *layerConfig(cm.system, user)
 	cm.config = config
+	cm.loaded = data
+	return nil
+}
+
+// BackupPath 返回上一次保存前的配置文件备份路径
+func (cm *ConfigManager) BackupPath() string {
+	return cm.configPath + ".bak"
+}
+
+// mergeInto 将用户配置 config 相对加载时的修改合并到其他进程写入的配置 current 上
+func (cm *ConfigManager) mergeInto(config *Config, current []byte) error {
+	base, _, err := parseConfig(cm.loaded)
+	if err != nil {
+		return fmt.Errorf("解析配置文件失败: %v", err)
+	}
+	theirs, _, err := parseConfig(current)
+	if err != nil {
+		return fmt.Errorf("配置文件已被其他进程修改且无法解析: %v", err)
+	}
+
+	merged := mergeConfig(base, config, theirs)
+	if err := layerConfig(cm.system, merged).Validate(); err != nil {
+		return fmt.Errorf("配置文件已被其他进程修改，合并后的配置无效: %v", err)
+	}
+	*config = *merged
 	return nil
 }
 
-// loadConfig 从文件加载配置
+// loadConfig 在持有文件锁时从文件加载配置并叠加在系统级配置之上，旧版本的配置文件会被升级并保存，原文件另行备份
 func (cm *ConfigManager) loadConfig() (*Config, error) {
+	// 系统级配置由管理员维护，无法读取时忽略，避免影响所有命令
+	system, err := loadSystemConfig(cm.systemPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "忽略系统配置: %v\n", err)
+	}
+	cm.system = system
+
+	data, err := os.ReadFile(cm.configPath)
 	// 如果配置文件不存在，创建默认配置
-	if _, err := os.Stat(cm.configPath); os.IsNotExist(err) {
-		defaultConfig := &Config{
-			APIServerInfo: make([]APIServerInfo, 0),
-		}
+	if os.IsNotExist(err) {
+		defaultConfig, _, _ := parseConfig(nil)
 		// 保存默认配置
-		if err := cm.SaveConfig(defaultConfig); err != nil {
+		if err := cm.saveConfig(defaultConfig); err != nil {
 			return nil, fmt.Errorf("创建默认配置失败: %v", err)
 		}
 		return defaultConfig, nil
 	}
-
-	data, err := os.ReadFile(cm.configPath)
 	if err != nil {
 		return nil, fmt.Errorf("读取配置文件失败: %v", err)
 	}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
+	config, version, err := parseConfig(data)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%w: %s: %v，请运行 cli config doctor --fix 备份损坏的文件并恢复配置", ErrCorruptConfig, cm.configPath, err)
+		}
 		return nil, fmt.Errorf("解析配置文件失败: %v", err)
 	}
+	cm.loaded = data
 
-	return &config, nil
-}
-
-func (c *Config) Save() error {
-	usr, err := user.Current()
-	if err != nil {
-		return err
+	if version < CurrentSchemaVersion {
+		backup := fmt.Sprintf("%s.v%d.bak", cm.configPath, version)
+		if err := writeFileAtomic(backup, data, 0600); err != nil {
+			return nil, fmt.Errorf("备份旧版本配置文件失败: %v", err)
+		}
+		if err := cm.saveConfig(config); err != nil {
+			return nil, fmt.Errorf("保存升级后的配置失败: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "配置文件已从版本 %d 升级到 %d，原文件备份为 %s\n", version, CurrentSchemaVersion, backup)
+		return config, nil
 	}
 
-	configDir := path.Join(usr.HomeDir, ".cli")
-	configPath := path.Join(configDir, "config.json")
+	return layerConfig(cm.system, config), nil
+}
 
-	// 确保目录存在
-	if err := os.MkdirAll(configDir, 0700); err != nil {
-		return err
+// QueryAll 返回是否默认查询所有用户的作业，未设置时为 false
+func (c *Config) QueryAll() bool {
+	return c.DefaultQueryAll != nil && *c.DefaultQueryAll
+}
+
+// Validate 校验配置的一致性
+func (c *Config) Validate() error {
+	names := make(map[string]bool)
+	for i, server := range c.APIServerInfo {
+		if server.URL == "" {
+			return fmt.Errorf("第 %d 个 APIserver 未设置 url", i)
+		}
+		if server.Name == "" {
+			return fmt.Errorf("APIserver %s 未设置名称", server.URL)
+		}
+		if names[server.Name] {
+			return fmt.Errorf("APIserver 名称重复: %s", server.Name)
+		}
+		names[server.Name] = true
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
-	if err != nil {
-		return err
+	if c.DefaultAPIServer != "" && c.FindServer(c.DefaultAPIServer) == nil {
+		return fmt.Errorf("默认 APIserver %s 不在服务器列表中", c.DefaultAPIServer)
 	}
 
-	return os.WriteFile(configPath, data, 0600)
+	contexts := make(map[string]bool)
+	for _, ctx := range c.Contexts {
+		if ctx.Name == "" {
+			return fmt.Errorf("存在未命名的上下文")
+		}
+		if contexts[ctx.Name] {
+			return fmt.Errorf("上下文名称重复: %s", ctx.Name)
+		}
+		contexts[ctx.Name] = true
+		if ctx.Server != "" && c.LookupServer(ctx.Server) == nil {
+			return fmt.Errorf("上下文 %s 引用的 APIserver %s 不存在", ctx.Name, ctx.Server)
+		}
+	}
+	if c.CurrentContext != "" && !contexts[c.CurrentContext] {
+		return fmt.Errorf("当前上下文 %s 不存在", c.CurrentContext)
+	}
+	return nil
 }
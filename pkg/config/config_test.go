@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+const (
+	hammerWorkers = 8
+	hammerUpdates = 10
+)
+
+// hammerConfigEnv 设置时 TestHelperProcess 作为子进程修改该配置文件
+const hammerConfigEnv = "CLI_TEST_HAMMER_CONFIG"
+
+// newTestManager 返回使用 configPath 且不读取系统级配置的配置管理器
+func newTestManager(t *testing.T, configPath string) *ConfigManager {
+	t.Helper()
+	t.Setenv(SystemConfigEnv, filepath.Join(t.TempDir(), "system.json"))
+	cm := NewConfigManager()
+	cm.SetConfigPath(configPath)
+	return cm
+}
+
+// newHammerConfig 创建包含计数服务器的配置文件
+func newHammerConfig(t *testing.T) string {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	err := newTestManager(t, configPath).Update(func(cfg *Config) error {
+		_, err := cfg.AddServer(APIServerInfo{Name: "counter", URL: "https://counter.example.com"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return configPath
+}
+
+// hammer 通过独立的配置管理器为 worker 添加 hammerUpdates 个服务器，每次添加时将计数服务器的 retries 加一。
+// 计数依赖修改前读到的值，读取和保存之间没有持有文件锁时无法通过合并恢复
+func hammer(configPath string, worker int) error {
+	cm := NewConfigManager()
+	cm.SetConfigPath(configPath)
+	for i := 0; i < hammerUpdates; i++ {
+		err := cm.Update(func(cfg *Config) error {
+			cfg.LookupServer("counter").Retries++
+			_, err := cfg.AddServer(APIServerInfo{
+				Name: fmt.Sprintf("w%d-%d", worker, i),
+				URL:  fmt.Sprintf("https://w%d-%d.example.com", worker, i),
+			})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkHammered 检查所有 worker 添加的服务器都已保存
+func checkHammered(t *testing.T, configPath string) {
+	t.Helper()
+	cfg, err := newTestManager(t, configPath).GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig: %v", err)
+	}
+	if got, want := cfg.LookupServer("counter").Retries, hammerWorkers*hammerUpdates; got != want {
+		t.Errorf("counter = %d, want %d: concurrent updates were lost", got, want)
+	}
+	for w := 0; w < hammerWorkers; w++ {
+		for i := 0; i < hammerUpdates; i++ {
+			if cfg.LookupServer(fmt.Sprintf("w%d-%d", w, i)) == nil {
+				t.Errorf("server w%d-%d missing", w, i)
+			}
+		}
+	}
+}
+
+func TestUpdateConcurrentGoroutines(t *testing.T) {
+	configPath := newHammerConfig(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, hammerWorkers)
+	for w := 0; w < hammerWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- hammer(configPath, w)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+	checkHammered(t, configPath)
+}
+
+func TestUpdateConcurrentProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("启动子进程")
+	}
+	configPath := newHammerConfig(t)
+
+	cmds := make([]*exec.Cmd, hammerWorkers)
+	for w := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+		cmd.Env = append(os.Environ(), hammerConfigEnv+"="+configPath, "CLI_TEST_HAMMER_WORKER="+strconv.Itoa(w))
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds[w] = cmd
+	}
+	for w, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("worker %d: %v", w, err)
+		}
+	}
+	checkHammered(t, configPath)
+}
+
+// TestHelperProcess 不是真正的测试，由 TestUpdateConcurrentProcesses 作为子进程启动
+func TestHelperProcess(t *testing.T) {
+	configPath := os.Getenv(hammerConfigEnv)
+	if configPath == "" {
+		return
+	}
+	worker, _ := strconv.Atoi(os.Getenv("CLI_TEST_HAMMER_WORKER"))
+	if err := hammer(configPath, worker); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// ContextEnv 指定当前上下文的环境变量
+const ContextEnv = "CLI_CONTEXT"
+
+// Context 定义一组常用的默认设置：服务器、账号、队列、资源需求和输出格式
+type Context struct {
+	Name    string `json:"name"`
+	Server  string `json:"server"`
+	Account string `json:"account,omitempty"`
+	Queue   string `json:"queue,omitempty"`
+	ResReq  string `json:"resreq,omitempty"`
+	Output  string `json:"output,omitempty"`
+}
+
+// LookupContext 按名称查找上下文，未找到时返回 nil
+func (c *Config) LookupContext(name string) *Context {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i]
+		}
+	}
+	return nil
+}
+
+// ServerContexts 返回引用该服务器的上下文名称，上下文可以通过名称或 URL 引用服务器
+func (c *Config) ServerContexts(serverName string) []string {
+	var names []string
+	for _, ctx := range c.Contexts {
+		if server := c.LookupServer(ctx.Server); ctx.Server != "" && server != nil && server.Name == serverName {
+			names = append(names, ctx.Name)
+		}
+	}
+	return names
+}
+
+// SetContext 添加或更新上下文
+func (c *Config) SetContext(ctx Context) error {
+	if ctx.Name == "" {
+		return fmt.Errorf("上下文名称不能为空")
+	}
+	if ctx.Server != "" && c.LookupServer(ctx.Server) == nil {
+		return fmt.Errorf("未找到指定的 APIserver: %s", ctx.Server)
+	}
+
+	if existing := c.LookupContext(ctx.Name); existing != nil {
+		*existing = ctx
+		return nil
+	}
+	c.Contexts = append(c.Contexts, ctx)
+	return nil
+}
+
+// UseContext 切换当前上下文
+func (c *Config) UseContext(name string) error {
+	if c.LookupContext(name) == nil {
+		return fmt.Errorf("未找到指定的上下文: %s", name)
+	}
+	c.CurrentContext = name
+	return nil
+}
+
+// ContextServer 返回上下文对应的服务器
+func (c *Config) ContextServer(ctx *Context) (*APIServerInfo, error) {
+	if ctx.Server == "" {
+		return nil, fmt.Errorf("上下文 %s 未指定 APIserver", ctx.Name)
+	}
+	server := c.LookupServer(ctx.Server)
+	if server == nil {
+		return nil, fmt.Errorf("上下文 %s 引用的 APIserver %s 不存在", ctx.Name, ctx.Server)
+	}
+	return server, nil
+}
+
+// SetContextOverride 设置命令行 --context 指定的上下文，优先于环境变量和配置文件
+func (cm *ConfigManager) SetContextOverride(name string) {
+	cm.contextOverride = name
+}
+
+// CurrentContext 返回当前生效的上下文，未使用上下文时返回 nil。
+// 优先级: --context 参数 > CLI_CONTEXT 环境变量 > 配置文件中的 current-context，
+// 上下文中的账号、队列、资源需求和输出格式可以再被 CLI_ACCOUNT 等环境变量覆盖。
+// 返回值是副本，修改它不会影响配置
+func (cm *ConfigManager) CurrentContext() (*Context, error) {
+	ctx, err := cm.selectedContext()
+	if err != nil {
+		return nil, err
+	}
+	return applyContextEnv(ctx), nil
+}
+
+// selectedContext 返回通过参数、环境变量或配置文件选中的上下文
+func (cm *ConfigManager) selectedContext() (*Context, error) {
+	cfg, err := cm.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	name := cm.contextOverride
+	if name == "" {
+		name = os.Getenv(ContextEnv)
+	}
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	ctx := cfg.LookupContext(name)
+	if ctx == nil {
+		return nil, fmt.Errorf("未找到指定的上下文: %s", name)
+	}
+	return ctx, nil
+}
+
+// CurrentServer 返回当前生效的服务器。
+// 优先级: --context 参数指定的上下文 > CLI_SERVER 环境变量 > CLI_CONTEXT 或 current-context 指定的上下文 > 默认服务器，
+// 设置了 CLI_TOKEN 时使用其中的 token。返回值是副本，修改它不会影响配置
+func (cm *ConfigManager) CurrentServer() (*APIServerInfo, error) {
+	server, err := cm.selectedServer()
+	if err != nil {
+		return nil, err
+	}
+
+	effective := *server
+	if token := os.Getenv(TokenEnv); token != "" {
+		effective.Token = token
+	}
+	return &effective, nil
+}
+
+// selectedServer 返回通过参数、环境变量或配置文件选中的服务器
+func (cm *ConfigManager) selectedServer() (*APIServerInfo, error) {
+	cfg, err := cm.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if serverEnv := os.Getenv(ServerEnv); serverEnv != "" && cm.contextOverride == "" {
+		if server := cfg.LookupServer(serverEnv); server != nil {
+			return server, nil
+		}
+		// 未配置的服务器，直接按 URL 访问
+		return &APIServerInfo{Name: serverEnv, URL: serverEnv}, nil
+	}
+
+	ctx, err := cm.selectedContext()
+	if err != nil {
+		return nil, err
+	}
+	if ctx != nil {
+		return cfg.ContextServer(ctx)
+	}
+
+	// 检查是否有默认服务器
+	if cfg.DefaultAPIServer == "" {
+		return nil, fmt.Errorf("未设置默认 APIserver，请先设置默认服务器或指定服务器")
+	}
+	server := cfg.FindServer(cfg.DefaultAPIServer)
+	if server == nil {
+		return nil, fmt.Errorf("未找到默认服务器信息")
+	}
+	return server, nil
+}
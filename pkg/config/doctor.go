@@ -0,0 +1,163 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+)
+
+// 检查结果的状态
+const (
+	CheckOK    = "OK"
+	CheckWarn  = "WARN"
+	CheckError = "ERROR"
+)
+
+// Check 配置文件的单项检查结果
+type Check struct {
+	Name    string
+	Status  string
+	Message string
+}
+
+// Diagnose 检查系统级配置和用户配置文件能否读取和解析、版本、一致性、权限以及引用的证书文件是否存在。
+// 与 GetConfig 不同，配置文件损坏时也能返回检查结果
+func (cm *ConfigManager) Diagnose() []Check {
+	var checks []Check
+	add := func(name, status, format string, args ...any) {
+		checks = append(checks, Check{Name: name, Status: status, Message: fmt.Sprintf(format, args...)})
+	}
+
+	system, err := loadSystemConfig(cm.systemPath)
+	switch {
+	case err != nil:
+		add("系统配置", CheckWarn, "%v，将被忽略", err)
+	case system != nil:
+		add("系统配置", CheckOK, "%s", cm.systemPath)
+	}
+
+	if _, err := cm.resolvePath(); err != nil {
+		add("文件", CheckError, "%v", err)
+		return checks
+	}
+	info, err := os.Stat(cm.configPath)
+	if os.IsNotExist(err) {
+		add("文件", CheckOK, "配置文件尚未创建，首次使用时会自动创建")
+		return checks
+	}
+	if err != nil {
+		add("文件", CheckError, "%v", err)
+		return checks
+	}
+	data, err := os.ReadFile(cm.configPath)
+	if err != nil {
+		add("文件", CheckError, "读取失败: %v", err)
+		return checks
+	}
+	add("文件", CheckOK, "%s", cm.configPath)
+
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		add("权限", CheckWarn, "权限为 %04o，配置中包含 token，建议设置为 0600", info.Mode().Perm())
+	} else {
+		add("权限", CheckOK, "%04o", info.Mode().Perm())
+	}
+
+	config, version, err := parseConfig(data)
+	switch {
+	case err != nil && version > CurrentSchemaVersion:
+		add("版本", CheckError, "%v", err)
+		return checks
+	case err != nil:
+		add("格式", CheckError, "无法解析: %v，可使用 --fix 备份损坏的文件并恢复配置", err)
+		return checks
+	case version < CurrentSchemaVersion:
+		add("版本", CheckWarn, "版本 %d，加载时将自动升级到版本 %d", version, CurrentSchemaVersion)
+	default:
+		add("版本", CheckOK, "%d", version)
+	}
+	config = layerConfig(system, config)
+
+	if err := config.Validate(); err != nil {
+		add("一致性", CheckError, "%v", err)
+	} else {
+		add("一致性", CheckOK, "服务器 %d 个，上下文 %d 个", len(config.APIServerInfo), len(config.Contexts))
+	}
+	if config.DefaultAPIServer == "" && len(config.APIServerInfo) > 0 {
+		add("默认服务器", CheckWarn, "未设置默认 APIserver")
+	}
+
+	for _, ctx := range config.Contexts {
+		if _, err := config.ContextServer(&ctx); err != nil {
+			add("上下文", CheckError, "%v", err)
+		}
+	}
+
+	for _, server := range config.APIServerInfo {
+		files := []struct{ name, path string }{
+			{"cacert", server.CACert},
+			{"ca_bundle", server.CABundle},
+			{"client_cert", server.ClientCert},
+			{"client_key", server.ClientKey},
+			{"pkcs12", server.PKCS12},
+		}
+		for _, file := range files {
+			if file.path == "" {
+				continue
+			}
+			if _, err := os.Stat(file.path); err != nil {
+				add("证书文件", CheckError, "APIserver %s 的 %s 不可用: %v", server.Name, file.name, err)
+			}
+		}
+	}
+	return checks
+}
+
+// Recover 将无法解析的配置文件改名备份，并从上一次保存前的备份恢复，
+// 没有可用的备份时使用空配置。返回损坏文件的备份路径和恢复所用的备份路径 (使用空配置时为空)
+func (cm *ConfigManager) Recover() (string, string, error) {
+	if _, err := cm.resolvePath(); err != nil {
+		return "", "", err
+	}
+	unlock, err := lockFile(cm.configPath + ".lock")
+	if err != nil {
+		return "", "", err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(cm.configPath)
+	if err != nil {
+		return "", "", fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	if _, _, err := parseConfig(data); err == nil {
+		return "", "", errors.New("配置文件可以正常解析，无需恢复")
+	}
+
+	corruptPath := fmt.Sprintf("%s.corrupt-%s", cm.configPath, time.Now().Format("20060102150405"))
+	if err := os.Rename(cm.configPath, corruptPath); err != nil {
+		return "", "", fmt.Errorf("备份损坏的配置文件失败: %v", err)
+	}
+
+	restored, restoredFrom := []byte(nil), ""
+	if backup, err := os.ReadFile(cm.BackupPath()); err == nil && !bytes.Equal(backup, data) {
+		if _, _, err := parseConfig(backup); err == nil {
+			restored, restoredFrom = backup, cm.BackupPath()
+		}
+	}
+	if restored == nil {
+		empty, _, _ := parseConfig(nil)
+		if restored, err = json.MarshalIndent(empty, "", "  "); err != nil {
+			return corruptPath, "", err
+		}
+	}
+	if err := writeFileAtomic(cm.configPath, restored, 0600); err != nil {
+		return corruptPath, "", err
+	}
+
+	cm.config = nil
+	cm.loaded = nil
+	return corruptPath, restoredFrom, nil
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+)
+
+// 配置相关的环境变量。
+//
+// 配置文件路径的优先级（从高到低）:
+//  1. 命令行参数 --config
+//  2. 环境变量 CLI_CONFIG
+//  3. $XDG_CONFIG_HOME/cli/config.json（已设置 XDG_CONFIG_HOME，且旧版本的 ~/.cli/config.json 不存在时）
+//  4. ~/.cli/config.json
+//
+// 用户配置叠加在系统级配置 /etc/cli/config.json 之上，系统级配置的路径可以通过 CLI_SYSTEM_CONFIG 修改。
+//
+// 配置项的优先级（从高到低）: 命令行参数 > 环境变量 > 配置文件中的上下文 > 配置文件中的默认值。
+// 其中 --context 参数指定的上下文优先于 CLI_SERVER，CLI_SERVER 优先于 CLI_CONTEXT 和 current-context。
+const (
+	// ConfigEnv 指定配置文件路径
+	ConfigEnv = "CLI_CONFIG"
+	// SystemConfigEnv 指定系统级配置文件路径
+	SystemConfigEnv = "CLI_SYSTEM_CONFIG"
+	// ServerEnv 指定使用的 APIserver 名称或 URL
+	ServerEnv = "CLI_SERVER"
+	// TokenEnv 指定访问 APIserver 使用的 token
+	TokenEnv = "CLI_TOKEN"
+	// AccountEnv 指定账号
+	AccountEnv = "CLI_ACCOUNT"
+	// QueueEnv 指定默认作业队列
+	QueueEnv = "CLI_QUEUE"
+	// ResReqEnv 指定默认资源需求
+	ResReqEnv = "CLI_RESREQ"
+	// OutputEnv 指定默认输出格式
+	OutputEnv = "CLI_OUTPUT"
+)
+
+// ResolveConfigPath 按优先级确定配置文件路径，flagPath 为 --config 参数的值
+func ResolveConfigPath(flagPath string) (string, error) {
+	if flagPath != "" {
+		return flagPath, nil
+	}
+	if envPath := os.Getenv(ConfigEnv); envPath != "" {
+		return envPath, nil
+	}
+
+	home, homeErr := homeDir()
+	var legacyPath string
+	if homeErr == nil {
+		legacyPath = filepath.Join(home, ".cli", "config.json")
+	}
+
+	// 已有旧版本配置文件时继续使用，避免设置 XDG_CONFIG_HOME 后配置丢失
+	if xdgHome := os.Getenv("XDG_CONFIG_HOME"); xdgHome != "" {
+		xdgPath := filepath.Join(xdgHome, "cli", "config.json")
+		if legacyPath == "" || fileExists(xdgPath) || !fileExists(legacyPath) {
+			return xdgPath, nil
+		}
+	}
+
+	if homeErr != nil {
+		return "", fmt.Errorf("无法确定配置文件路径，请通过 --config 或 %s 指定: %v", ConfigEnv, homeErr)
+	}
+	return legacyPath, nil
+}
+
+// currentUser 查询当前用户的 passwd 条目，测试时可以替换
+var currentUser = user.Current
+
+// homeDir 返回用户主目录，优先使用 $HOME，以支持没有 passwd 条目的容器
+func homeDir() (string, error) {
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		return home, nil
+	}
+	usr, err := currentUser()
+	if err != nil {
+		return "", fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	return usr.HomeDir, nil
+}
+
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
+
+// applyContextEnv 使用环境变量覆盖上下文中的默认值，返回新的上下文
+func applyContextEnv(ctx *Context) *Context {
+	overrides := map[string]*string{}
+	var effective Context
+	if ctx != nil {
+		effective = *ctx
+	}
+	overrides[AccountEnv] = &effective.Account
+	overrides[QueueEnv] = &effective.Queue
+	overrides[ResReqEnv] = &effective.ResReq
+	overrides[OutputEnv] = &effective.Output
+
+	changed := false
+	for env, field := range overrides {
+		if value := os.Getenv(env); value != "" {
+			*field = value
+			changed = true
+		}
+	}
+
+	if ctx == nil && !changed {
+		return nil
+	}
+	return &effective
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// noUser 模拟没有 $HOME 且没有 passwd 条目的容器
+func noUser(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", "")
+	saved := currentUser
+	currentUser = func() (*user.User, error) { return nil, errors.New("user: unknown userid 12345") }
+	t.Cleanup(func() { currentUser = saved })
+}
+
+func touch(t *testing.T, file string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveConfigPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("$HOME 在 Windows 上不决定用户主目录")
+	}
+
+	tests := []struct {
+		name         string
+		flag         string
+		env          string
+		xdg          bool
+		legacyExists bool
+		xdgExists    bool
+		noHome       bool
+		want         string // home、xdg 分别代表 ~/.cli/config.json 和 $XDG_CONFIG_HOME/cli/config.json
+		wantErr      bool
+	}{
+		{name: "参数优先于环境变量", flag: "/flag/config.json", env: "/env/config.json", xdg: true, want: "/flag/config.json"},
+		{name: "环境变量优先于 XDG", env: "/env/config.json", xdg: true, legacyExists: true, want: "/env/config.json"},
+		{name: "XDG", xdg: true, want: "xdg"},
+		{name: "已有旧配置时继续使用", xdg: true, legacyExists: true, want: "home"},
+		{name: "XDG 配置已存在", xdg: true, legacyExists: true, xdgExists: true, want: "xdg"},
+		{name: "默认", want: "home"},
+		{name: "默认且旧配置已存在", legacyExists: true, want: "home"},
+		{name: "无主目录时使用参数", flag: "/flag/config.json", noHome: true, want: "/flag/config.json"},
+		{name: "无主目录时使用环境变量", env: "/env/config.json", noHome: true, want: "/env/config.json"},
+		{name: "无主目录时使用 XDG", xdg: true, noHome: true, want: "xdg"},
+		{name: "无主目录且未指定", noHome: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := t.TempDir()
+			home := filepath.Join(base, "home")
+			xdgHome := filepath.Join(base, "xdg")
+			legacyPath := filepath.Join(home, ".cli", "config.json")
+			xdgPath := filepath.Join(xdgHome, "cli", "config.json")
+
+			t.Setenv("HOME", home)
+			t.Setenv(ConfigEnv, tt.env)
+			t.Setenv("XDG_CONFIG_HOME", "")
+			if tt.xdg {
+				t.Setenv("XDG_CONFIG_HOME", xdgHome)
+			}
+			if tt.legacyExists {
+				touch(t, legacyPath)
+			}
+			if tt.xdgExists {
+				touch(t, xdgPath)
+			}
+			if tt.noHome {
+				noUser(t)
+			}
+
+			got, err := ResolveConfigPath(tt.flag)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "--config") {
+					t.Fatalf("ResolveConfigPath() = %q, %v, want error mentioning --config", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveConfigPath() error: %v", err)
+			}
+			want := map[string]string{"home": legacyPath, "xdg": xdgPath}[tt.want]
+			if want == "" {
+				want = tt.want
+			}
+			if got != want {
+				t.Errorf("ResolveConfigPath() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSystemConfigPath(t *testing.T) {
+	t.Setenv(SystemConfigEnv, "/opt/cli/config.json")
+	if got := SystemConfigPath(); got != "/opt/cli/config.json" {
+		t.Errorf("SystemConfigPath() = %q, want the %s value", got, SystemConfigEnv)
+	}
+
+	t.Setenv(SystemConfigEnv, "")
+	if runtime.GOOS != "windows" {
+		if got := SystemConfigPath(); got != "/etc/cli/config.json" {
+			t.Errorf("SystemConfigPath() = %q, want /etc/cli/config.json", got)
+		}
+	}
+}
+
+func TestConfigManagerResolvesPathLazily(t *testing.T) {
+	noUser(t)
+	t.Setenv(ConfigEnv, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv(SystemConfigEnv, filepath.Join(t.TempDir(), "system.json"))
+
+	// 无法确定主目录时，创建配置管理器不应失败，直到读取配置时才报错
+	cm := NewConfigManager()
+	if _, err := cm.GetConfig(); err == nil {
+		t.Fatal("GetConfig() without a config path: want error")
+	}
+
+	// 解析命令行参数后指定的路径生效
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	cm.SetConfigPath(configPath)
+	if _, err := cm.GetConfig(); err != nil {
+		t.Fatalf("GetConfig() after SetConfigPath: %v", err)
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		t.Errorf("default config not created at %s: %v", configPath, err)
+	}
+
+	// 环境变量同样在第一次使用时才读取
+	envPath := filepath.Join(t.TempDir(), "env.json")
+	cm = NewConfigManager()
+	t.Setenv(ConfigEnv, envPath)
+	if got := cm.ConfigPath(); got != envPath {
+		t.Errorf("ConfigPath() = %q, want %q", got, envPath)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// lockRetryInterval 锁文件已存在时的重试间隔
+	lockRetryInterval = 50 * time.Millisecond
+	// lockStaleAfter 超过该时间未释放的锁文件视为进程异常退出遗留
+	lockStaleAfter = 30 * time.Second
+	// lockTimeout 等待锁的最长时间
+	lockTimeout = time.Minute
+)
+
+// lockFile 在不支持 flock 的平台上通过独占创建锁文件实现排他锁，返回的函数用于解锁
+func lockFile(lockPath string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("创建锁文件失败: %v", err)
+		}
+
+		// 清理异常退出遗留的锁文件
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("等待配置文件锁超时，如确认没有其他 cli 进程在运行，请删除 %s", lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
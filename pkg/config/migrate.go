@@ -0,0 +1,192 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CurrentSchemaVersion 当前配置文件格式的版本，修改配置格式时需要递增并在 migrations 中添加升级步骤
+const CurrentSchemaVersion = 3
+
+// migration 定义将配置文件升级到 version 版本的步骤，操作的是解析后的原始 json，
+// 以便处理字段改名等无法通过 Config 结构体表达的变化
+type migration struct {
+	version     int
+	description string
+	migrate     func(raw map[string]any) error
+}
+
+// migrations 按版本顺序排列的升级步骤
+var migrations = []migration{
+	{
+		version:     1,
+		description: "全局 CA 证书迁移到各服务器，为未命名的服务器生成名称",
+		migrate:     migrateV1,
+	},
+	{
+		version:     2,
+		description: "服务器地址去掉接口路径并规范化，合并指向同一服务器的重复条目",
+		migrate:     migrateV2,
+	},
+	{
+		version:     3,
+		description: "defaultqueryall 为 false 时视为未设置，以便继承系统配置",
+		migrate:     migrateV3,
+	},
+}
+
+// schemaVersion 返回原始配置中的版本号，没有版本号的旧配置为 0
+func schemaVersion(raw map[string]any) (int, error) {
+	value, ok := raw["schemaVersion"]
+	if !ok {
+		return 0, nil
+	}
+	version, ok := value.(float64)
+	if !ok || version < 0 || version != float64(int(version)) {
+		return 0, fmt.Errorf("无效的 schemaVersion: %v", value)
+	}
+	return int(version), nil
+}
+
+// migrateRaw 将原始配置升级到当前版本，返回升级前的版本
+func migrateRaw(raw map[string]any) (int, error) {
+	version, err := schemaVersion(raw)
+	if err != nil {
+		return 0, err
+	}
+	if version > CurrentSchemaVersion {
+		return version, fmt.Errorf("配置文件版本 %d 高于当前 cli 支持的版本 %d，请升级 cli", version, CurrentSchemaVersion)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.migrate(raw); err != nil {
+			return version, fmt.Errorf("升级配置文件到版本 %d (%s) 失败: %v", m.version, m.description, err)
+		}
+	}
+	raw["schemaVersion"] = CurrentSchemaVersion
+	return version, nil
+}
+
+// parseConfig 解析配置文件内容并升级到当前版本，返回配置和文件原来的版本，空内容返回空配置
+func parseConfig(data []byte) (*Config, int, error) {
+	config := &Config{
+		SchemaVersion: CurrentSchemaVersion,
+		APIServerInfo: make([]APIServerInfo, 0),
+	}
+	if len(data) == 0 {
+		return config, CurrentSchemaVersion, nil
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, err
+	}
+	version, err := migrateRaw(raw)
+	if err != nil {
+		return nil, version, err
+	}
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, version, err
+	}
+	if err := json.Unmarshal(migrated, config); err != nil {
+		return nil, version, err
+	}
+	return config, version, nil
+}
+
+// migrateV1 将旧版本的全局 CA 证书迁移到未单独配置证书的服务器，并为未命名的服务器生成名称
+func migrateV1(raw map[string]any) error {
+	servers, _ := raw["servers"].([]any)
+
+	caCert, _ := raw["cacert"].(string)
+	delete(raw, "cacert")
+
+	used := make(map[string]bool)
+	for _, item := range servers {
+		if server, ok := item.(map[string]any); ok {
+			if name, _ := server["name"].(string); name != "" {
+				used[name] = true
+			}
+		}
+	}
+
+	next := 1
+	for i, item := range servers {
+		server, ok := item.(map[string]any)
+		if !ok {
+			return fmt.Errorf("第 %d 个 APIserver 格式错误", i)
+		}
+		if existing, _ := server["cacert"].(string); existing == "" && caCert != "" {
+			server["cacert"] = caCert
+		}
+		if name, _ := server["name"].(string); name == "" {
+			for used[fmt.Sprintf("apiserver%d", next)] {
+				next++
+			}
+			server["name"] = fmt.Sprintf("apiserver%d", next)
+			used[server["name"].(string)] = true
+		}
+	}
+	return nil
+}
+
+// migrateV2 将服务器地址规范化，旧版本保存的是登录时输入的地址，可能包含 /xce/v1/logon 等接口路径。
+// 规范化后指向同一服务器的条目只保留第一个，引用被合并条目的上下文改为引用保留的条目
+func migrateV2(raw map[string]any) error {
+	servers, _ := raw["servers"].([]any)
+
+	kept := make(map[string]string)   // 规范化的地址 -> 保留的服务器名称
+	merged := make(map[string]string) // 被合并的服务器名称 -> 保留的服务器名称
+	result := make([]any, 0, len(servers))
+	for i, item := range servers {
+		server, ok := item.(map[string]any)
+		if !ok {
+			return fmt.Errorf("第 %d 个 APIserver 格式错误", i)
+		}
+		rawURL, _ := server["url"].(string)
+		url := canonicalURL(rawURL)
+		name, _ := server["name"].(string)
+		if keptName, ok := kept[url]; ok {
+			merged[name] = keptName
+			continue
+		}
+		server["url"] = url
+		kept[url] = name
+		result = append(result, server)
+	}
+	raw["servers"] = result
+
+	if defaultServer, _ := raw["defaultAPIserver"].(string); defaultServer != "" {
+		raw["defaultAPIserver"] = canonicalURL(defaultServer)
+	}
+
+	contexts, _ := raw["contexts"].([]any)
+	for _, item := range contexts {
+		ctx, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		ref, _ := ctx["server"].(string)
+		if name, ok := merged[ref]; ok {
+			ctx["server"] = name
+		} else if strings.Contains(ref, "://") {
+			ctx["server"] = canonicalURL(ref)
+		}
+	}
+	return nil
+}
+
+// migrateV3 删除值为 false 的 defaultqueryall。旧版本总是写入该字段，无法区分用户关闭和未设置，
+// 按未设置处理，使系统配置中的 true 仍然生效
+func migrateV3(raw map[string]any) error {
+	if queryAll, ok := raw["defaultqueryall"].(bool); ok && !queryAll {
+		delete(raw, "defaultqueryall")
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// redactedValue 敏感配置项脱敏后显示的值
+const redactedValue = "******"
+
+// GetValue 按点分隔的键路径读取配置值，键名与配置文件中的字段名一致。
+// 列表元素可以用下标或名称访问，如 servers.0.url、servers.apiserver1.token
+func (c *Config) GetValue(key string) (interface{}, error) {
+	v, err := lookupPath(reflect.ValueOf(c).Elem(), splitKey(key))
+	if err != nil {
+		return nil, err
+	}
+	// 可选配置项未设置时返回零值
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Zero(v.Type().Elem()).Interface(), nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface(), nil
+}
+
+// SetValue 按键路径设置配置值，值会按字段类型解析和校验。
+// 带有 url 标签的服务器地址按 NormalizeURL 规范化，修改服务器地址时同步更新引用旧地址的默认服务器和上下文
+func (c *Config) SetValue(key, value string) error {
+	parts := splitKey(key)
+	root := reflect.ValueOf(c).Elem()
+	v, err := lookupPath(root, parts)
+	if err != nil {
+		return err
+	}
+	if !isURLField(root, parts) {
+		if err := parseInto(v, value); err != nil {
+			return fmt.Errorf("配置项 %s 的值无效: %v", key, err)
+		}
+		return nil
+	}
+
+	url, err := NormalizeURL(value)
+	if err != nil {
+		return fmt.Errorf("配置项 %s 的值无效: %v", key, err)
+	}
+	parent, _ := lookupPath(root, parts[:len(parts)-1])
+	server, ok := parent.Addr().Interface().(*APIServerInfo)
+	if !ok {
+		v.SetString(url)
+		return nil
+	}
+	if other := c.FindServer(url); other != nil && other != server {
+		return fmt.Errorf("APIserver %s 已存在: %s", url, other.Name)
+	}
+	c.replaceServerURL(server.URL, url)
+	server.URL = url
+	return nil
+}
+
+// isURLField 判断键路径指向的是否是带有 url 标签的服务器地址字段
+func isURLField(root reflect.Value, parts []string) bool {
+	parent, err := lookupPath(root, parts[:len(parts)-1])
+	if err != nil || parent.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < parent.NumField(); i++ {
+		field := parent.Type().Field(i)
+		if field.IsExported() && strings.EqualFold(jsonName(field), parts[len(parts)-1]) {
+			return field.Tag.Get("url") == "true"
+		}
+	}
+	return false
+}
+
+// replaceServerURL 将引用服务器旧地址的默认服务器和上下文改为新地址
+func (c *Config) replaceServerURL(oldURL, newURL string) {
+	if oldURL == "" {
+		return
+	}
+	if SameURL(c.DefaultAPIServer, oldURL) {
+		c.DefaultAPIServer = newURL
+	}
+	for i := range c.Contexts {
+		if strings.Contains(c.Contexts[i].Server, "://") && SameURL(c.Contexts[i].Server, oldURL) {
+			c.Contexts[i].Server = newURL
+		}
+	}
+}
+
+// UnsetValue 按键路径清除配置值，列表元素会从列表中删除
+func (c *Config) UnsetValue(key string) error {
+	parts := splitKey(key)
+	parent, err := lookupPath(reflect.ValueOf(c).Elem(), parts[:len(parts)-1])
+	if err != nil {
+		return err
+	}
+
+	last := parts[len(parts)-1]
+	if parent.Kind() == reflect.Slice {
+		idx, err := sliceIndex(parent, last)
+		if err != nil {
+			return err
+		}
+		remaining := reflect.MakeSlice(parent.Type(), 0, parent.Len()-1)
+		remaining = reflect.AppendSlice(remaining, parent.Slice(0, idx))
+		remaining = reflect.AppendSlice(remaining, parent.Slice(idx+1, parent.Len()))
+		parent.Set(remaining)
+		return nil
+	}
+
+	v, err := lookupPath(parent, []string{last})
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.Zero(v.Type()))
+	return nil
+}
+
+// Redacted 返回隐藏了 token 等敏感信息的配置副本
+func (c *Config) Redacted() (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var copied Config
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	redact(reflect.ValueOf(&copied).Elem())
+	return &copied, nil
+}
+
+// redact 将带有 secret 标签的非空字符串字段替换为脱敏值
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Tag.Get("secret") == "true" && v.Field(i).Kind() == reflect.String {
+				if v.Field(i).String() != "" {
+					v.Field(i).SetString(redactedValue)
+				}
+				continue
+			}
+			redact(v.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			redact(v.Index(i))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			redact(v.Elem())
+		}
+	}
+}
+
+func splitKey(key string) []string {
+	return strings.Split(strings.Trim(key, "."), ".")
+}
+
+// lookupPath 沿键路径查找字段，返回可设置的值
+func lookupPath(v reflect.Value, parts []string) (reflect.Value, error) {
+	for i, part := range parts {
+		path := strings.Join(parts[:i+1], ".")
+		if part == "" {
+			return reflect.Value{}, fmt.Errorf("配置项不能为空")
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByJSONName(v, part)
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("未知的配置项: %s", path)
+			}
+			v = field
+		case reflect.Slice:
+			idx, err := sliceIndex(v, part)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v = v.Index(idx)
+		case reflect.Ptr:
+			if v.IsNil() {
+				return reflect.Value{}, fmt.Errorf("配置项 %s 未设置", strings.Join(parts[:i], "."))
+			}
+			return lookupPath(v.Elem(), parts[i:])
+		default:
+			return reflect.Value{}, fmt.Errorf("配置项 %s 不是对象，不能访问 %s", strings.Join(parts[:i], "."), part)
+		}
+	}
+	return v, nil
+}
+
+// fieldByJSONName 按 JSON 字段名查找结构体字段，忽略大小写
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if strings.EqualFold(jsonName(field), name) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// jsonName 返回字段在配置文件中的名称
+func jsonName(field reflect.StructField) string {
+	tag := strings.Split(field.Tag.Get("json"), ",")[0]
+	if tag == "" {
+		return field.Name
+	}
+	return tag
+}
+
+// sliceIndex 将下标或元素名称解析为列表下标
+func sliceIndex(v reflect.Value, part string) (int, error) {
+	if idx, err := strconv.Atoi(part); err == nil {
+		if idx < 0 || idx >= v.Len() {
+			return 0, fmt.Errorf("下标 %d 超出范围，共 %d 项", idx, v.Len())
+		}
+		return idx, nil
+	}
+
+	// 按元素的 name 字段查找
+	for i := 0; i < v.Len(); i++ {
+		elem := reflect.Indirect(v.Index(i))
+		if elem.Kind() != reflect.Struct {
+			break
+		}
+		if name, ok := fieldByJSONName(elem, "name"); ok && name.Kind() == reflect.String && name.String() == part {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("未找到名称为 %s 的项", part)
+}
+
+// parseInto 按字段类型解析字符串并设置值
+func parseInto(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("需要整数: %s", value)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("需要非负整数: %s", value)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("需要数字: %s", value)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+			items := strings.Split(value, ",")
+			for i := range items {
+				items[i] = strings.TrimSpace(items[i])
+			}
+			v.Set(reflect.ValueOf(items).Convert(v.Type()))
+			return nil
+		}
+		return parseJSONInto(v, value)
+	case reflect.Ptr:
+		// 可选配置项分配新值，不修改可能与系统配置共用的原值
+		elem := reflect.New(v.Type().Elem())
+		if err := parseInto(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+	default:
+		return parseJSONInto(v, value)
+	}
+	return nil
+}
+
+// parseJSONInto 将 JSON 文本解析到复合类型的字段
+func parseJSONInto(v reflect.Value, value string) error {
+	ptr := reflect.New(v.Type())
+	if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+		return fmt.Errorf("需要 JSON 格式的 %s: %v", v.Type(), err)
+	}
+	v.Set(ptr.Elem())
+	return nil
+}
+
+// parseBool 解析布尔值，支持 y/n
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("需要布尔值 (true/false 或 y/n): %s", value)
+	}
+	return b, nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetValueNormalizesServerURL(t *testing.T) {
+	cfg := &Config{
+		DefaultAPIServer: "https://a.example.com",
+		APIServerInfo: []APIServerInfo{
+			{Name: "a", URL: "https://a.example.com"},
+			{Name: "b", URL: "https://b.example.com:8443"},
+		},
+		Contexts: []Context{{Name: "prod", Server: "https://a.example.com"}},
+	}
+
+	if err := cfg.SetValue("servers.a.url", "HTTPS://A2.Example.com:443/xce/v1/logon"); err != nil {
+		t.Fatalf("SetValue: %v", err)
+	}
+	if got, want := cfg.APIServerInfo[0].URL, "https://a2.example.com"; got != want {
+		t.Errorf("url = %q, want %q", got, want)
+	}
+	if got, want := cfg.DefaultAPIServer, "https://a2.example.com"; got != want {
+		t.Errorf("defaultAPIserver = %q, want %q", got, want)
+	}
+	if got, want := cfg.Contexts[0].Server, "https://a2.example.com"; got != want {
+		t.Errorf("context server = %q, want %q", got, want)
+	}
+
+	err := cfg.SetValue("servers.a.url", "b.example.com:8443/")
+	if err == nil || !strings.Contains(err.Error(), "已存在") {
+		t.Errorf("SetValue to another server's URL: err = %v, want duplicate error", err)
+	}
+	if err := cfg.SetValue("servers.b.url", "ftp://b.example.com"); err == nil {
+		t.Error("SetValue with ftp scheme: want error")
+	}
+
+	if err := cfg.SetValue("defaultAPIserver", "https://B.example.com:8443/"); err != nil {
+		t.Fatalf("SetValue defaultAPIserver: %v", err)
+	}
+	if got, want := cfg.DefaultAPIServer, "https://b.example.com:8443"; got != want {
+		t.Errorf("defaultAPIserver = %q, want %q", got, want)
+	}
+
+	// 其他字符串配置项原样保存
+	if err := cfg.SetValue("servers.b.proxy", "HTTP://Proxy:3128/"); err != nil {
+		t.Fatalf("SetValue proxy: %v", err)
+	}
+	if got, want := cfg.APIServerInfo[1].Proxy, "HTTP://Proxy:3128/"; got != want {
+		t.Errorf("proxy = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FindServer 按 URL 查找服务器，URL 按 NormalizeURL 的规则比较，未找到时返回 nil
+func (c *Config) FindServer(url string) *APIServerInfo {
+	target := canonicalURL(url)
+	for i := range c.APIServerInfo {
+		if canonicalURL(c.APIServerInfo[i].URL) == target {
+			return &c.APIServerInfo[i]
+		}
+	}
+	return nil
+}
+
+// LookupServer 按名称或 URL 查找服务器，名称优先，未找到时返回 nil
+func (c *Config) LookupServer(nameOrURL string) *APIServerInfo {
+	for i := range c.APIServerInfo {
+		if c.APIServerInfo[i].Name == nameOrURL {
+			return &c.APIServerInfo[i]
+		}
+	}
+	return c.FindServer(nameOrURL)
+}
+
+// NextServerName 生成未被占用的服务器名称，如 apiserver1、apiserver2
+func (c *Config) NextServerName() string {
+	for n := 1; ; n++ {
+		name := fmt.Sprintf("apiserver%d", n)
+		if c.LookupServer(name) == nil {
+			return name
+		}
+	}
+}
+
+// AddServer 添加服务器，URL 会被规范化，名称为空时自动生成，第一个服务器会被设为默认服务器
+func (c *Config) AddServer(server APIServerInfo) (*APIServerInfo, error) {
+	url, err := NormalizeURL(server.URL)
+	if err != nil {
+		return nil, err
+	}
+	server.URL = url
+	if c.FindServer(server.URL) != nil {
+		return nil, fmt.Errorf("APIserver %s 已存在", server.URL)
+	}
+	if server.Name == "" {
+		server.Name = c.NextServerName()
+	}
+	if err := c.checkServerName(server.Name); err != nil {
+		return nil, err
+	}
+
+	c.APIServerInfo = append(c.APIServerInfo, server)
+
+	// 如果是第一个服务器，设置为默认服务器
+	if len(c.APIServerInfo) == 1 {
+		c.DefaultAPIServer = server.URL
+	}
+	return &c.APIServerInfo[len(c.APIServerInfo)-1], nil
+}
+
+// ErrServerInUse 表示要删除的服务器仍被上下文引用
+var ErrServerInUse = errors.New("APIserver 仍被上下文引用")
+
+// RemoveServer 删除服务器，删除的是默认服务器时将第一个剩余服务器设为默认服务器。
+// 服务器仍被上下文引用时，removeContexts 为 false 则拒绝删除，为 true 则一并删除这些上下文，返回删除的上下文名称
+func (c *Config) RemoveServer(nameOrURL string, removeContexts bool) (*APIServerInfo, []string, error) {
+	target := c.LookupServer(nameOrURL)
+	if target == nil {
+		return nil, nil, fmt.Errorf("未找到指定的 APIserver: %s", nameOrURL)
+	}
+	server := *target
+	contexts := c.ServerContexts(server.Name)
+	if len(contexts) > 0 && !removeContexts {
+		return nil, nil, fmt.Errorf("%w: %s (上下文 %s)", ErrServerInUse, server.Name, strings.Join(contexts, ", "))
+	}
+
+	c.Contexts = slices.DeleteFunc(c.Contexts, func(ctx Context) bool {
+		return slices.Contains(contexts, ctx.Name)
+	})
+	if slices.Contains(contexts, c.CurrentContext) {
+		c.CurrentContext = ""
+	}
+	c.APIServerInfo = slices.DeleteFunc(c.APIServerInfo, func(s APIServerInfo) bool {
+		return s.Name == server.Name
+	})
+	if SameURL(c.DefaultAPIServer, server.URL) {
+		c.DefaultAPIServer = ""
+		if len(c.APIServerInfo) > 0 {
+			c.DefaultAPIServer = c.APIServerInfo[0].URL
+		}
+	}
+	return &server, contexts, nil
+}
+
+// RenameServer 重命名服务器
+func (c *Config) RenameServer(oldName, newName string) error {
+	server := c.LookupServer(oldName)
+	if server == nil {
+		return fmt.Errorf("未找到指定的 APIserver: %s", oldName)
+	}
+	if server.Name == newName {
+		return nil
+	}
+	if err := c.checkServerName(newName); err != nil {
+		return err
+	}
+
+	// 同步更新引用该服务器的上下文
+	for i := range c.Contexts {
+		if c.Contexts[i].Server == server.Name {
+			c.Contexts[i].Server = newName
+		}
+	}
+	server.Name = newName
+	return nil
+}
+
+// UseServer 将指定服务器设为默认服务器
+func (c *Config) UseServer(nameOrURL string) (*APIServerInfo, error) {
+	server := c.LookupServer(nameOrURL)
+	if server == nil {
+		return nil, fmt.Errorf("未找到指定的 APIserver: %s", nameOrURL)
+	}
+	c.DefaultAPIServer = server.URL
+	return server, nil
+}
+
+// checkServerName 校验服务器名称是否可用
+func (c *Config) checkServerName(name string) error {
+	if name == "" {
+		return fmt.Errorf("APIserver 名称不能为空")
+	}
+	// 名称会用于 config get servers.<name> 等键路径
+	if strings.ContainsAny(name, ". \t") {
+		return fmt.Errorf("APIserver 名称不能包含点号或空白字符: %s", name)
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("APIserver 名称不能是纯数字: %s", name)
+	}
+	if c.LookupServer(name) != nil {
+		return fmt.Errorf("APIserver 名称已被使用: %s", name)
+	}
+	return nil
+}
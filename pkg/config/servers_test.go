@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateContextServers(t *testing.T) {
+	cfg := &Config{
+		DefaultAPIServer: "https://a.example.com",
+		APIServerInfo:    []APIServerInfo{{Name: "a", URL: "https://a.example.com"}},
+		Contexts: []Context{
+			{Name: "by-name", Server: "a"},
+			{Name: "by-url", Server: "https://a.example.com"},
+			{Name: "no-server"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	cfg.Contexts = append(cfg.Contexts, Context{Name: "dangling", Server: "b"})
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "上下文 dangling 引用的 APIserver b 不存在") {
+		t.Errorf("Validate with a dangling context = %v, want an error", err)
+	}
+}
+
+func TestRemoveServer(t *testing.T) {
+	newConfig := func() *Config {
+		return &Config{
+			DefaultAPIServer: "https://a.example.com",
+			APIServerInfo: []APIServerInfo{
+				{Name: "a", URL: "https://a.example.com"},
+				{Name: "b", URL: "https://b.example.com"},
+			},
+			CurrentContext: "prod",
+			Contexts: []Context{
+				{Name: "prod", Server: "a"},
+				{Name: "lab", Server: "https://a.example.com"},
+				{Name: "dev", Server: "b"},
+			},
+		}
+	}
+
+	cfg := newConfig()
+	_, _, err := cfg.RemoveServer("a", false)
+	if !errors.Is(err, ErrServerInUse) || !strings.Contains(err.Error(), "prod, lab") {
+		t.Errorf("RemoveServer without removing contexts = %v, want ErrServerInUse listing prod, lab", err)
+	}
+	if len(cfg.APIServerInfo) != 2 || len(cfg.Contexts) != 3 {
+		t.Errorf("config changed after a refused removal: %+v", cfg)
+	}
+
+	server, contexts, err := cfg.RemoveServer("https://a.example.com", true)
+	if err != nil {
+		t.Fatalf("RemoveServer: %v", err)
+	}
+	if server.Name != "a" || strings.Join(contexts, ",") != "prod,lab" {
+		t.Errorf("RemoveServer = %s, %v, want a, [prod lab]", server.Name, contexts)
+	}
+	if cfg.DefaultAPIServer != "https://b.example.com" || cfg.CurrentContext != "" {
+		t.Errorf("default server = %s, current context = %q, want b and no current context", cfg.DefaultAPIServer, cfg.CurrentContext)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate after RemoveServer: %v", err)
+	}
+
+	// 没有上下文引用的服务器可以直接删除
+	cfg = newConfig()
+	if _, contexts, err := cfg.RemoveServer("b", false); err == nil {
+		t.Errorf("RemoveServer(b) = %v, want ErrServerInUse for context dev", contexts)
+	}
+	cfg.Contexts = cfg.Contexts[:2]
+	if _, contexts, err := cfg.RemoveServer("b", false); err != nil || len(contexts) != 0 {
+		t.Errorf("RemoveServer(b) = %v, %v, want no error and no contexts", contexts, err)
+	}
+	if _, _, err := cfg.RemoveServer("missing", true); err == nil {
+		t.Error("RemoveServer(missing): want error")
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// writeFileAtomic 先写入同目录下的临时文件再重命名，避免其他进程读到写了一半的配置
+func writeFileAtomic(file string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // 重命名成功后删除不会生效
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, file)
+}
+
+// mergeConfig 将本进程在 base 基础上做的修改 (ours) 合并到其他进程保存的最新配置 (theirs) 上。
+// 单个配置项以本进程修改过的值为准，服务器按 URL、上下文按名称逐项合并
+func mergeConfig(base, ours, theirs *Config) *Config {
+	merged := *theirs
+	if ours.Account != base.Account {
+		merged.Account = ours.Account
+	}
+	if ours.DefaultAPIServer != base.DefaultAPIServer {
+		merged.DefaultAPIServer = ours.DefaultAPIServer
+	}
+	if !sameBool(ours.DefaultQueryAll, base.DefaultQueryAll) {
+		merged.DefaultQueryAll = ours.DefaultQueryAll
+	}
+	if ours.CurrentContext != base.CurrentContext {
+		merged.CurrentContext = ours.CurrentContext
+	}
+
+	merged.APIServerInfo = mergeList(base.APIServerInfo, ours.APIServerInfo, theirs.APIServerInfo,
+		func(server APIServerInfo) string { return server.URL })
+	merged.Contexts = mergeList(base.Contexts, ours.Contexts, theirs.Contexts,
+		func(ctx Context) string { return ctx.Name })
+	return &merged
+}
+
+// sameBool 比较两个可选的布尔值，未设置与 false 不同
+func sameBool(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// mergeList 按 key 合并列表: 本进程新增或修改的元素覆盖其他进程的版本，
+// 本进程删除且其他进程未修改的元素被删除，其余保持其他进程的版本。元素按值比较，指针字段比较指向的值
+func mergeList[T any](base, ours, theirs []T, key func(T) string) []T {
+	baseByKey := make(map[string]T, len(base))
+	for _, item := range base {
+		baseByKey[key(item)] = item
+	}
+	oursByKey := make(map[string]T, len(ours))
+	for _, item := range ours {
+		oursByKey[key(item)] = item
+	}
+
+	result := make([]T, 0, len(theirs)+len(ours))
+	seen := make(map[string]bool, len(theirs))
+	for _, item := range theirs {
+		k := key(item)
+		seen[k] = true
+		baseItem, inBase := baseByKey[k]
+		oursItem, inOurs := oursByKey[k]
+		switch {
+		case inOurs && (!inBase || !reflect.DeepEqual(oursItem, baseItem)):
+			result = append(result, oursItem)
+		case !inOurs && inBase && reflect.DeepEqual(item, baseItem):
+			// 本进程已删除
+		default:
+			result = append(result, item)
+		}
+	}
+
+	for _, item := range ours {
+		k := key(item)
+		if seen[k] {
+			continue
+		}
+		// 其他进程已删除且本进程未修改的元素不再保留
+		if baseItem, inBase := baseByKey[k]; inBase && reflect.DeepEqual(item, baseItem) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
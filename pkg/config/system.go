@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+)
+
+// SystemConfigPath 返回系统级配置文件路径，由管理员维护，对所有用户生效
+func SystemConfigPath() string {
+	if envPath := os.Getenv(SystemConfigEnv); envPath != "" {
+		return envPath
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), "cli", "config.json")
+	}
+	return "/etc/cli/config.json"
+}
+
+// loadSystemConfig 加载系统级配置，文件不存在时返回 nil
+func loadSystemConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取系统配置文件失败: %v", err)
+	}
+	config, _, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析系统配置文件 %s 失败: %v", path, err)
+	}
+	return config, nil
+}
+
+// layerConfig 将用户配置叠加在系统配置之上: 用户设置的配置项优先，
+// 系统配置中的服务器 (按 URL) 和上下文 (按名称) 在用户配置中没有同名项时保留
+func layerConfig(system, user *Config) *Config {
+	layered := *user
+	if system == nil {
+		return &layered
+	}
+
+	if layered.Account == "" {
+		layered.Account = system.Account
+	}
+	if layered.DefaultAPIServer == "" {
+		layered.DefaultAPIServer = system.DefaultAPIServer
+	}
+	if layered.CurrentContext == "" {
+		layered.CurrentContext = system.CurrentContext
+	}
+	// 用户配置可以将系统配置中的 true 改为 false，继承的值与系统配置共用同一个指针
+	if layered.DefaultQueryAll == nil {
+		layered.DefaultQueryAll = system.DefaultQueryAll
+	}
+
+	layered.APIServerInfo = append([]APIServerInfo{}, user.APIServerInfo...)
+	for _, server := range system.APIServerInfo {
+		if user.FindServer(server.URL) == nil && user.LookupServer(server.Name) == nil {
+			layered.APIServerInfo = append(layered.APIServerInfo, server)
+		}
+	}
+	layered.Contexts = append([]Context{}, user.Contexts...)
+	for _, ctx := range system.Contexts {
+		if user.LookupContext(ctx.Name) == nil {
+			layered.Contexts = append(layered.Contexts, ctx)
+		}
+	}
+	return &layered
+}
+
+// unlayerConfig 从叠加后的配置中去掉与系统配置相同的部分，得到需要保存到用户配置文件的内容
+func unlayerConfig(system, layered *Config) *Config {
+	user := *layered
+	user.APIServerInfo = append([]APIServerInfo{}, layered.APIServerInfo...)
+	user.Contexts = append([]Context{}, layered.Contexts...)
+	if system == nil {
+		return &user
+	}
+
+	if user.Account == system.Account {
+		user.Account = ""
+	}
+	if user.DefaultAPIServer == system.DefaultAPIServer {
+		user.DefaultAPIServer = ""
+	}
+	if user.CurrentContext == system.CurrentContext {
+		user.CurrentContext = ""
+	}
+	// 只去掉从系统配置继承的值，用户显式设置的值即使与系统配置相同也保留
+	if user.DefaultQueryAll == system.DefaultQueryAll {
+		user.DefaultQueryAll = nil
+	}
+
+	user.APIServerInfo = user.APIServerInfo[:0]
+	for _, server := range layered.APIServerInfo {
+		if systemServer := system.FindServer(server.URL); systemServer == nil || !reflect.DeepEqual(*systemServer, server) {
+			user.APIServerInfo = append(user.APIServerInfo, server)
+		}
+	}
+	user.Contexts = user.Contexts[:0]
+	for _, ctx := range layered.Contexts {
+		if systemCtx := system.LookupContext(ctx.Name); systemCtx == nil || *systemCtx != ctx {
+			user.Contexts = append(user.Contexts, ctx)
+		}
+	}
+	return &user
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// userQueryAll 返回用户配置文件中保存的 defaultqueryall，未保存时返回 nil
+func userQueryAll(t *testing.T, configPath string) *bool {
+	t.Helper()
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Config
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	return saved.DefaultQueryAll
+}
+
+func TestDefaultQueryAllLayering(t *testing.T) {
+	dir := t.TempDir()
+	systemPath := filepath.Join(dir, "system.json")
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(systemPath, []byte(`{"schemaVersion": 3, "defaultqueryall": true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(SystemConfigEnv, systemPath)
+
+	// 旧版本总是写入 false，升级后继承系统配置
+	if err := os.WriteFile(configPath, []byte(`{"schemaVersion": 2, "defaultqueryall": false, "servers": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	newManager := func() *ConfigManager {
+		cm := NewConfigManager()
+		cm.SetConfigPath(configPath)
+		return cm
+	}
+	cfg, err := newManager().GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig: %v", err)
+	}
+	if !cfg.QueryAll() {
+		t.Error("QueryAll() after migrating a v2 false = false, want the system true")
+	}
+
+	set := func(value string) {
+		t.Helper()
+		err := newManager().Update(func(cfg *Config) error {
+			return cfg.SetValue("defaultqueryall", value)
+		})
+		if err != nil {
+			t.Fatalf("SetValue(%s): %v", value, err)
+		}
+	}
+
+	// 用户可以关闭系统配置中打开的选项
+	set("n")
+	if cfg, _ = newManager().GetConfig(); cfg.QueryAll() {
+		t.Error("QueryAll() after user set n = true, want false")
+	}
+	if got := userQueryAll(t, configPath); got == nil || *got {
+		t.Errorf("saved defaultqueryall = %v, want false", got)
+	}
+
+	// 与系统配置相同的显式设置仍然保存
+	set("y")
+	if got := userQueryAll(t, configPath); got == nil || !*got {
+		t.Errorf("saved defaultqueryall = %v, want true", got)
+	}
+
+	// 清除后重新继承系统配置
+	err = newManager().Update(func(cfg *Config) error {
+		return cfg.UnsetValue("defaultqueryall")
+	})
+	if err != nil {
+		t.Fatalf("UnsetValue: %v", err)
+	}
+	if got := userQueryAll(t, configPath); got != nil {
+		t.Errorf("saved defaultqueryall = %v, want unset", *got)
+	}
+	if cfg, _ = newManager().GetConfig(); !cfg.QueryAll() {
+		t.Error("QueryAll() after unset = false, want the system true")
+	}
+	if value, err := cfg.GetValue("defaultqueryall"); err != nil || value != true {
+		t.Errorf("GetValue(defaultqueryall) = %v, %v, want true", value, err)
+	}
+}
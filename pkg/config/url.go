@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// apiPathPrefix 接口路径的前缀，服务器地址中从这里开始的部分是接口路径，不属于服务器标识
+const apiPathPrefix = "/xce"
+
+// NormalizeURL 返回服务器地址的规范形式，作为服务器的标识保存和比较:
+// 协议和主机名转为小写，去掉默认端口、查询参数、末尾的斜杠以及 /xce/v1/logon 等接口路径，
+// 反向代理等使用的路径前缀会被保留。未指定协议时使用 https
+func NormalizeURL(rawURL string) (string, error) {
+	raw := strings.TrimSpace(rawURL)
+	if raw == "" {
+		return "", fmt.Errorf("APIserver 地址不能为空")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("无效的 APIserver 地址 %s: %v", rawURL, err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", fmt.Errorf("无效的 APIserver 地址 %s: 只支持 http 和 https", rawURL)
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("无效的 APIserver 地址 %s: 不能包含用户名或密码", rawURL)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return "", fmt.Errorf("无效的 APIserver 地址 %s: 缺少主机名", rawURL)
+	}
+
+	port := u.Port()
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		port = ""
+	}
+	switch {
+	case port != "":
+		host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		host = "[" + host + "]"
+	}
+
+	path := u.Path
+	if idx := strings.Index(path+"/", apiPathPrefix+"/"); idx != -1 {
+		path = path[:idx]
+	}
+	return scheme + "://" + host + strings.TrimRight(path, "/"), nil
+}
+
+// SameURL 判断两个服务器地址是否指向同一个服务器
+func SameURL(a, b string) bool {
+	return canonicalURL(a) == canonicalURL(b)
+}
+
+// canonicalURL 返回用于比较的服务器地址，无法解析时原样返回
+func canonicalURL(rawURL string) string {
+	if normalized, err := NormalizeURL(rawURL); err == nil {
+		return normalized
+	}
+	return rawURL
+}
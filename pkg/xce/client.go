@@ -0,0 +1,247 @@
+package xce
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/transport"
+	"github.com/zhengyansheng/xcli/pkg/config"
+)
+
+// Client XCE API 客户端，可在多个 goroutine 中共用，但 Logon 和 SetToken 不应与其他请求并发调用
+type Client struct {
+	api     *client.APIClient
+	baseURL string
+	token   string
+	// apiVersion 通过 WithAPIVersion 指定的 API 版本，为空时协商时选择最高版本
+	apiVersion string
+}
+
+// New 创建访问 baseURL 的客户端，baseURL 为服务器地址，如 https://xce.example.com:8443
+func New(baseURL string, opts ...Option) (*Client, error) {
+	baseURL, err := config.NormalizeURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return newClient(client.NewAPIClient(baseURL), baseURL, o)
+}
+
+// NewFromCLIConfig 使用 cli 配置中的当前服务器创建客户端，包括该服务器的 API 版本、代理等网络设置、证书、重试策略和登录令牌。
+// 服务器的选择与 cli 一致，支持 CLI_CONFIG、CLI_CONTEXT、CLI_SERVER 和 CLI_TOKEN 等环境变量，
+// opts 会覆盖配置文件中的设置
+func NewFromCLIConfig(opts ...Option) (*Client, error) {
+	server, err := config.NewConfigManager().CurrentServer()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := client.ServerRetryPolicy(server)
+	if err != nil {
+		return nil, fmt.Errorf("服务器 %s 的重试配置无效: %v", server.URL, err)
+	}
+	netOptions, err := transport.ServerOptions(server)
+	if err != nil {
+		return nil, fmt.Errorf("服务器 %s 的网络配置无效: %v", server.URL, err)
+	}
+	tlsOptions := TLSOptions(client.ServerTLSOptions(server))
+	retry := RetryPolicy(policy)
+
+	o := options{
+		token:        server.Token,
+		netOptions:   (*NetworkOptions)(&netOptions),
+		tlsOptions:   &tlsOptions,
+		retry:        &retry,
+		capabilities: client.ServerCapabilities(server),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c, err := newClient(client.NewAPIClient(server.URL), server.URL, o)
+	if err != nil {
+		return nil, err
+	}
+	// 配置中记录的是登录时协商的版本，不限制之后重新协商
+	if o.apiVersion == "" {
+		if err := c.api.SetAPIVersion(server.APIVersion); err != nil {
+			return nil, fmt.Errorf("服务器 %s 的 API 版本配置无效: %v", server.URL, err)
+		}
+	}
+	return c, nil
+}
+
+// newClient 按选项设置 API 客户端，先设置传输层再设置 TLS
+func newClient(api *client.APIClient, baseURL string, o options) (*Client, error) {
+	if err := api.SetAPIVersion(o.apiVersion); err != nil {
+		return nil, err
+	}
+	api.SetCapabilities(o.capabilities)
+	switch {
+	case o.transport != nil:
+		api.SetTransport(o.transport)
+	case o.netOptions != nil:
+		netOptions := transport.Options(*o.netOptions)
+		if err := netOptions.Validate(); err != nil {
+			return nil, err
+		}
+		// 静态解析按小写的主机名匹配
+		if len(netOptions.Hosts) > 0 {
+			netOptions.Hosts = make(map[string]string, len(o.netOptions.Hosts))
+			for host, address := range o.netOptions.Hosts {
+				netOptions.Hosts[strings.ToLower(host)] = address
+			}
+		}
+		api.SetTransportOptions(netOptions)
+	}
+	switch {
+	case o.tlsConfig != nil:
+		if err := api.SetTLSConfig(o.tlsConfig); err != nil {
+			return nil, err
+		}
+	case o.tlsOptions != nil && strings.HasPrefix(baseURL, "https://"):
+		if err := api.SetTLSOptions(client.TLSOptions(*o.tlsOptions)); err != nil {
+			return nil, fmt.Errorf("设置服务器 %s 的 TLS 配置失败: %v", baseURL, err)
+		}
+	}
+	if o.retry != nil {
+		api.SetRetryPolicy(client.RetryPolicy(*o.retry))
+	}
+	if o.timeout != nil {
+		api.SetTimeout(*o.timeout)
+	}
+	if o.logger != nil {
+		api.SetLogger(*o.logger)
+	}
+	return &Client{api: api, baseURL: baseURL, token: o.token, apiVersion: o.apiVersion}, nil
+}
+
+// BaseURL 返回规范化后的服务器地址
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Token 返回当前的登录令牌
+func (c *Client) Token() string {
+	return c.token
+}
+
+// SetToken 设置登录令牌
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// Negotiate 查询服务器版本，选择双方都支持的最高 API 版本并按服务器能力启用功能，
+// 通过 WithAPIVersion 指定了版本时服务器不支持该版本将返回 ErrUnsupported
+func (c *Client) Negotiate(ctx context.Context) (*VersionInfo, error) {
+	version, err := c.api.Negotiate(ctx, c.apiVersion)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return (*VersionInfo)(version), nil
+}
+
+// APIVersion 返回当前使用的 API 版本
+func (c *Client) APIVersion() string {
+	return c.api.APIVersion()
+}
+
+// Supports 判断服务器是否支持该能力，未调用 Negotiate 且 cli 配置中没有记录时返回 true
+func (c *Client) Supports(capability string) bool {
+	return c.api.Supports(capability)
+}
+
+// Logon 使用用户名和密码登录，成功后客户端保存并使用返回的令牌
+func (c *Client) Logon(ctx context.Context, username, password string) (string, error) {
+	resp, err := c.api.Logon(ctx, username, password)
+	if err != nil {
+		return "", convertError(err)
+	}
+	c.token = resp.Data.Token
+	return c.token, nil
+}
+
+// LogonWithCert 使用客户端证书登录，需通过 TLS 选项配置客户端证书
+func (c *Client) LogonWithCert(ctx context.Context, username string) (string, error) {
+	resp, err := c.api.LogonWithCert(ctx, username)
+	if err != nil {
+		return "", convertError(err)
+	}
+	c.token = resp.Data.Token
+	return c.token, nil
+}
+
+// Logout 登出并清除令牌
+func (c *Client) Logout(ctx context.Context) error {
+	if err := c.api.Logout(ctx, c.token); err != nil {
+		return convertError(err)
+	}
+	c.token = ""
+	return nil
+}
+
+// SubmitJob 提交作业。服务器在 Negotiate 或 cli 配置中声明了 CapIdempotency 时，网络错误会携带同一幂等键重试，不会重复提交；
+// 否则不重试，失败时作业可能已经提交
+func (c *Client) SubmitJob(ctx context.Context, req JobSubmitRequest) (*SubmitResult, error) {
+	resp, err := c.api.SubmitJob(ctx, c.token, (*client.JobSubmitRequest)(&req))
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return (*SubmitResult)(&resp.Data), nil
+}
+
+// Jobs 逐条遍历符合条件的作业，按页向服务器请求
+func (c *Client) Jobs(ctx context.Context, query JobQuery, opts ListOptions) iter.Seq2[Job, error] {
+	return convertSeq(c.api.Jobs(ctx, c.token, client.JobQuery(query).Params(), client.ListOptions(opts)), func(job client.Job) Job {
+		return Job(job)
+	})
+}
+
+// ListJobs 返回符合条件的全部作业
+func (c *Client) ListJobs(ctx context.Context, query JobQuery, opts ListOptions) ([]Job, error) {
+	return collect(c.Jobs(ctx, query, opts))
+}
+
+// Hosts 逐条遍历符合条件的主机，按页向服务器请求
+func (c *Client) Hosts(ctx context.Context, query HostQuery, opts ListOptions) iter.Seq2[Host, error] {
+	return convertSeq(c.api.Hosts(ctx, c.token, client.HostQuery(query).Params(), client.ListOptions(opts)), func(host client.Host) Host {
+		return Host(host)
+	})
+}
+
+// ListHosts 返回符合条件的全部主机
+func (c *Client) ListHosts(ctx context.Context, query HostQuery, opts ListOptions) ([]Host, error) {
+	return collect(c.Hosts(ctx, query, opts))
+}
+
+// convertSeq 将客户端的遍历结果转换为本包的类型，错误通过 convertError 转换
+func convertSeq[From, To any](seq iter.Seq2[From, error], convert func(From) To) iter.Seq2[To, error] {
+	return func(yield func(To, error) bool) {
+		for item, err := range seq {
+			if err != nil {
+				var zero To
+				yield(zero, convertError(err))
+				return
+			}
+			if !yield(convert(item), nil) {
+				return
+			}
+		}
+	}
+}
+
+// collect 收集遍历结果，遇到错误时返回错误
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,187 @@
+package xce_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/xcetest"
+	"github.com/zhengyansheng/xcli/pkg/config"
+	"github.com/zhengyansheng/xcli/pkg/xce"
+)
+
+func TestSubmitJob(t *testing.T) {
+	s := xcetest.NewTLSServer(xcetest.WithUser("alice", "secret"))
+	defer s.Close()
+	caCert := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caCert, s.CertPEM(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	c, err := xce.New(s.URL(),
+		xce.WithTLSOptions(xce.TLSOptions{CACert: caCert}),
+		xce.WithRetryPolicy(xce.RetryPolicy{MaxRetries: 5, WaitMin: time.Second, WaitMax: 30 * time.Second}),
+		xce.WithTimeout(time.Minute),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := c.Negotiate(ctx)
+	if err != nil {
+		t.Fatalf("Negotiate: %v", err)
+	}
+	if c.APIVersion() != xce.APIVersionV2 || !c.Supports(xce.CapPagination) {
+		t.Errorf("negotiated %s with %v, want v2 with pagination", c.APIVersion(), version.Capabilities)
+	}
+	if _, err := c.Logon(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("Logon: %v", err)
+	}
+
+	result, err := c.SubmitJob(ctx, xce.JobSubmitRequest{Queue: "normal", Command: "sleep 60"})
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	if result.JobID != 1 {
+		t.Errorf("job id = %d, want 1", result.JobID)
+	}
+	jobs, err := c.ListJobs(ctx, xce.JobQuery{JobID: result.JobID}, xce.ListOptions{})
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Command != "sleep 60" || jobs[0].User != "alice" {
+		t.Errorf("jobs = %+v, want the submitted job", jobs)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if c.Token() != "" {
+		t.Errorf("token = %q after Logout, want empty", c.Token())
+	}
+}
+
+func TestNewFromCLIConfig(t *testing.T) {
+	s := xcetest.NewServer()
+	defer s.Close()
+	for _, command := range []string{"sleep 60", "sleep 120"} {
+		if _, err := s.Submit("alice", client.JobSubmitRequest{Queue: "normal", Command: command}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 相当于执行了 cli apiserver logon -n alice --url <服务器地址>
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	cfg := fmt.Sprintf(`{"schemaVersion": %d, "defaultAPIserver": %q, "servers": [{"name": "apiserver1", "url": %q, "token": %q}]}`,
+		config.CurrentSchemaVersion, s.URL(), s.URL(), s.IssueToken("alice"))
+	if err := os.WriteFile(configPath, []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CLI_CONFIG", configPath)
+	t.Setenv("CLI_SYSTEM_CONFIG", filepath.Join(dir, "system.json"))
+
+	c, err := xce.NewFromCLIConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for job, err := range c.Jobs(context.Background(), xce.JobQuery{User: "alice"}, xce.ListOptions{Limit: 100}) {
+		if err != nil {
+			t.Fatalf("Jobs: %v", err)
+		}
+		got = append(got, fmt.Sprintf("%d %s %s", job.JobID, job.Status, job.Command))
+	}
+	if want := "[1 PEND sleep 60 2 PEND sleep 120]"; fmt.Sprint(got) != want {
+		t.Errorf("jobs = %v, want %s", got, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s := xcetest.NewServer(xcetest.WithLegacyAPI())
+	defer s.Close()
+	s.InjectFault(xcetest.Fault{Path: "/xce/v1/jobs", Status: 403, Msg: "没有权限", Header: http.Header{"X-Request-Id": {"req-1"}}})
+
+	c, err := xce.New(s.URL(), xce.WithToken(s.IssueToken("alice")), xce.WithRetryPolicy(xce.RetryPolicy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.ListJobs(context.Background(), xce.JobQuery{}, xce.ListOptions{})
+	var apiErr *xce.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("ListJobs error = %v (%T), want *xce.APIError", err, err)
+	}
+	if apiErr.StatusCode != 403 || apiErr.RequestID != "req-1" || !errors.Is(err, xce.ErrForbidden) {
+		t.Errorf("APIError = %+v, want HTTP 403 with request id req-1 matching ErrForbidden", apiErr)
+	}
+
+	// 旧服务器不提供版本接口，指定 v2 时不支持
+	c, err = xce.New(s.URL(), xce.WithAPIVersion(xce.APIVersionV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Negotiate(context.Background())
+	var unsupported *xce.UnsupportedError
+	if !errors.As(err, &unsupported) || !errors.Is(err, xce.ErrUnsupported) {
+		t.Fatalf("Negotiate error = %v (%T), want *xce.UnsupportedError", err, err)
+	}
+	if unsupported.Server != s.URL() {
+		t.Errorf("UnsupportedError.Server = %s, want %s", unsupported.Server, s.URL())
+	}
+}
+
+func TestWithNetworkOptions(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "xce.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets not available: %v", err)
+	}
+	s := xcetest.New()
+	server := httptest.NewUnstartedServer(s)
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	// 通过 Unix 域套接字连接时不使用代理，服务器地址无需解析
+	c, err := xce.New("http://xce.example.com:8080",
+		xce.WithNetworkOptions(xce.NetworkOptions{UnixSocket: socket, Proxy: "http://unreachable.invalid:3128"}),
+		xce.WithToken(s.IssueToken("alice")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.SubmitJob(context.Background(), xce.JobSubmitRequest{Command: "sleep 60"})
+	if err != nil {
+		t.Fatalf("SubmitJob over the unix socket: %v", err)
+	}
+	if result.JobID != 1 {
+		t.Errorf("job id = %d, want 1", result.JobID)
+	}
+
+	tests := []struct {
+		name    string
+		opts    xce.NetworkOptions
+		wantErr string
+	}{
+		{name: "不支持的代理协议", opts: xce.NetworkOptions{Proxy: "ftp://proxy.example.com"}, wantErr: "无效的 proxy"},
+		{name: "静态解析缺少地址", opts: xce.NetworkOptions{Hosts: map[string]string{"xce.example.com": ""}}, wantErr: "无效的 hosts"},
+		{name: "连接超时为负数", opts: xce.NetworkOptions{ConnectTimeout: -time.Second}, wantErr: "无效的 connect_timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := xce.New("https://xce.example.com", xce.WithNetworkOptions(tt.opts))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("New() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
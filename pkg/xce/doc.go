@@ -0,0 +1,39 @@
+// Package xce 提供访问 XCE 调度系统 API 的 Go 客户端，与 cli 命令行工具共用同一套请求、
+// 重试、分页和 TLS 实现。
+//
+// 直接指定服务器地址创建客户端:
+//
+//	c, err := xce.New("https://xce.example.com:8443",
+//		xce.WithTLSOptions(xce.TLSOptions{CACert: "/path/to/ca.crt"}),
+//		xce.WithRetryPolicy(xce.RetryPolicy{MaxRetries: 5, WaitMin: time.Second, WaitMax: 30 * time.Second}),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	if _, err := c.Negotiate(ctx); err != nil {
+//		return err
+//	}
+//	if _, err := c.Logon(ctx, "user", "password"); err != nil {
+//		return err
+//	}
+//
+// Negotiate 查询服务器版本并选择双方都支持的 API 版本，不调用时使用 v1。
+// 需要代理、静态解析或 Unix 域套接字时使用 WithNetworkOptions。
+//
+// 或者复用 cli 的配置文件，使用当前上下文中的服务器、证书、重试策略和登录令牌:
+//
+//	c, err := xce.NewFromCLIConfig()
+//
+// 提交作业并遍历作业列表:
+//
+//	result, err := c.SubmitJob(ctx, xce.JobSubmitRequest{Queue: "normal", Command: "sleep 60"})
+//	for job, err := range c.Jobs(ctx, xce.JobQuery{User: "user"}, xce.ListOptions{}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(job.JobID, job.Status)
+//	}
+//
+// 服务器返回的错误为 *APIError，可以用 errors.Is 判断 ErrUnauthorized 等错误类型；
+// 服务器不支持请求的功能时返回 ErrUnsupported。
+package xce
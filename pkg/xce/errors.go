@@ -0,0 +1,111 @@
+package xce
+
+import (
+	"errors"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+)
+
+// 可用 errors.Is 判断的错误类型
+var (
+	ErrUnauthorized = client.ErrUnauthorized
+	ErrForbidden    = client.ErrForbidden
+	ErrNotFound     = client.ErrNotFound
+	ErrConflict     = client.ErrConflict
+	ErrUnsupported  = client.ErrUnsupported
+)
+
+// APIError 服务器返回的错误，包括 HTTP 状态码非 200 和响应中的业务码表示失败两种情况，
+// 可以用 errors.Is 判断 ErrUnauthorized 等错误类型
+type APIError struct {
+	// StatusCode HTTP 状态码
+	StatusCode int
+	// Code 响应中的业务码
+	Code int
+	// Msg 响应中的错误信息
+	Msg string
+	// Body 原始响应内容
+	Body string
+	// RequestID 服务器返回的请求 ID，用于在服务器日志中定位请求
+	RequestID string
+
+	// err 客户端返回的原始错误，包含请求的上下文
+	err error
+}
+
+// Error 返回错误信息，包含 HTTP 状态码、业务码和请求 ID
+func (e *APIError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return e.apiError().Error()
+}
+
+// Is 按 HTTP 状态码匹配 ErrUnauthorized 等错误，HTTP 状态码为 200 时使用业务码
+func (e *APIError) Is(target error) bool {
+	return e.apiError().Is(target)
+}
+
+// Unwrap 返回客户端返回的原始错误
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+func (e *APIError) apiError() *client.APIError {
+	return &client.APIError{
+		StatusCode: e.StatusCode,
+		Code:       e.Code,
+		Msg:        e.Msg,
+		Body:       e.Body,
+		RequestID:  e.RequestID,
+	}
+}
+
+// UnsupportedError 服务器不支持请求的功能或 API 版本，可以用 errors.Is 判断 ErrUnsupported
+type UnsupportedError struct {
+	// Feature 不支持的功能
+	Feature string
+	// Server 服务器地址
+	Server string
+
+	// err 客户端返回的原始错误，包含请求的上下文
+	err error
+}
+
+// Error 返回错误信息
+func (e *UnsupportedError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return (&client.UnsupportedError{Feature: e.Feature, Server: e.Server}).Error()
+}
+
+// Is 匹配 ErrUnsupported
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// Unwrap 返回客户端返回的原始错误
+func (e *UnsupportedError) Unwrap() error {
+	return e.err
+}
+
+// convertError 将客户端返回的错误转换为本包的 *APIError 或 *UnsupportedError，以便调用方通过 errors.As 获取详情
+func convertError(err error) error {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return &APIError{
+			StatusCode: apiErr.StatusCode,
+			Code:       apiErr.Code,
+			Msg:        apiErr.Msg,
+			Body:       apiErr.Body,
+			RequestID:  apiErr.RequestID,
+			err:        err,
+		}
+	}
+	var unsupported *client.UnsupportedError
+	if errors.As(err, &unsupported) {
+		return &UnsupportedError{Feature: unsupported.Feature, Server: unsupported.Server, err: err}
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package xce_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zhengyansheng/xcli/pkg/xce"
+)
+
+// 登录并提交作业
+func ExampleNew() {
+	ctx := context.Background()
+	c, err := xce.New("https://xce.example.com:8443",
+		xce.WithTLSOptions(xce.TLSOptions{CACert: "/etc/xce/ca.crt"}),
+		xce.WithRetryPolicy(xce.RetryPolicy{MaxRetries: 5, WaitMin: time.Second, WaitMax: 30 * time.Second}),
+		xce.WithTimeout(time.Minute),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if _, err := c.Negotiate(ctx); err != nil {
+		fmt.Println("查询服务器版本失败:", err)
+		return
+	}
+	if _, err := c.Logon(ctx, "alice", "secret"); err != nil {
+		fmt.Println("登录失败:", err)
+		return
+	}
+	defer c.Logout(context.Background())
+
+	result, err := c.SubmitJob(ctx, xce.JobSubmitRequest{Queue: "normal", Command: "sleep 60"})
+	if err != nil {
+		var apiErr *xce.APIError
+		if errors.As(err, &apiErr) && apiErr.RequestID != "" {
+			fmt.Println("请求 ID:", apiErr.RequestID)
+		}
+		fmt.Println("提交作业失败:", err)
+		return
+	}
+	fmt.Printf("API %s，作业 <%d> 已提交\n", c.APIVersion(), result.JobID)
+}
+
+// 复用 cli 的配置和登录令牌，逐页遍历作业列表
+func ExampleNewFromCLIConfig() {
+	c, err := xce.NewFromCLIConfig()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	query := xce.JobQuery{User: "alice"}
+	for job, err := range c.Jobs(context.Background(), query, xce.ListOptions{Limit: 100}) {
+		if err != nil {
+			if errors.Is(err, xce.ErrUnauthorized) {
+				fmt.Println("登录已过期，请重新执行 cli apiserver logon")
+			}
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("%d\t%s\t%s\t%s\t%s\n", job.JobID, job.User, job.Status, job.Queue, job.Command)
+	}
+}
+
+// 通过代理访问服务器，内网地址直接连接
+func ExampleWithNetworkOptions() {
+	c, err := xce.New("https://xce.example.com:8443",
+		xce.WithNetworkOptions(xce.NetworkOptions{
+			Proxy:          "http://proxy.example.com:3128",
+			NoProxy:        "10.0.0.0/8,.internal",
+			Hosts:          map[string]string{"xce.example.com": "10.0.0.5"},
+			ConnectTimeout: 10 * time.Second,
+		}),
+		xce.WithToken("token"),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	hosts, err := c.ListHosts(context.Background(), xce.HostQuery{HostType: "X86_64"}, xce.ListOptions{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, host := range hosts {
+		fmt.Println(host.HostName, host.MaxCpus)
+	}
+}
@@ -0,0 +1,90 @@
+package xce
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Option 创建客户端时的可选配置
+type Option func(*options)
+
+type options struct {
+	token      string
+	tlsConfig  *tls.Config
+	tlsOptions *TLSOptions
+	retry      *RetryPolicy
+	timeout    *time.Duration
+	logger     *logr.Logger
+	transport  http.RoundTripper
+	netOptions *NetworkOptions
+	apiVersion string
+	// capabilities cli 配置中记录的服务器能力
+	capabilities []string
+}
+
+// WithToken 使用已有的登录令牌，无需再调用 Logon
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithTLSConfig 直接使用给定的 TLS 配置，优先于 WithTLSOptions
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// WithTLSOptions 按 CA 证书、客户端证书、指纹等选项构建 TLS 配置
+func WithTLSOptions(tlsOptions TLSOptions) Option {
+	return func(o *options) {
+		o.tlsOptions = &tlsOptions
+	}
+}
+
+// WithRetryPolicy 设置请求重试策略，MaxRetries 为 0 时不重试
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = &policy
+	}
+}
+
+// WithTimeout 设置单次请求的超时时间，0 表示不限制
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = &timeout
+	}
+}
+
+// WithLogger 设置客户端日志，默认使用 klog
+func WithLogger(logger logr.Logger) Option {
+	return func(o *options) {
+		o.logger = &logger
+	}
+}
+
+// WithNetworkOptions 设置代理、静态解析、Unix 域套接字和连接超时，覆盖 cli 配置中的网络设置
+func WithNetworkOptions(netOptions NetworkOptions) Option {
+	return func(o *options) {
+		o.netOptions = &netOptions
+	}
+}
+
+// WithTransport 设置发送请求使用的 HTTP 传输层，优先于 WithNetworkOptions 和 cli 配置中的网络设置。
+// 同时设置 TLS 时传输层须为 *http.Transport
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithAPIVersion 使用指定的 API 版本，默认为 v1，调用 Negotiate 时只接受该版本
+func WithAPIVersion(apiVersion string) Option {
+	return func(o *options) {
+		o.apiVersion = apiVersion
+	}
+}
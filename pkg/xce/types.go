@@ -0,0 +1,173 @@
+package xce
+
+import (
+	"time"
+
+	"github.com/zhengyansheng/xcli/internal/client"
+	"github.com/zhengyansheng/xcli/internal/transport"
+)
+
+// 以下类型与 internal/client 中的同名类型字段一致，在 Client 的方法中直接转换
+
+// Job 作业信息
+type Job struct {
+	JobID          int64  `json:"jobid"`
+	User           string `json:"user"`
+	Status         string `json:"status"`
+	JobName        string `json:"jobname"`
+	Queue          string `json:"queue"`
+	ProjectName    string `json:"projectname"`
+	Command        string `json:"command"`
+	ResReq         string `json:"resreq"`
+	SubmitTime     string `json:"submittime"`
+	JobDescription string `json:"jobdescription"`
+}
+
+// Host 主机信息
+type Host struct {
+	HostName       string    `json:"hostName"`
+	HostType       string    `json:"hostType"`
+	HostModel      string    `json:"hostModel"`
+	CpuFactor      float64   `json:"cpuFactor"`
+	MaxCpus        int       `json:"maxCpus"`
+	MaxMem         int64     `json:"maxMem"`
+	MaxSwap        int64     `json:"maxSwap"`
+	MaxTmp         int64     `json:"maxTmp"`
+	NDisks         int       `json:"nDisks"`
+	NRes           int       `json:"nRes"`
+	Resources      []string  `json:"resources"`
+	NDRes          int       `json:"nDRes"`
+	DResources     []string  `json:"DResources"`
+	Windows        string    `json:"windows"`
+	NumIndx        int       `json:"numIndx"`
+	BusyThreshold  []float64 `json:"busyThreshold"`
+	IsServer       bool      `json:"isServer"`
+	Cores          int       `json:"cores"`
+	HostAddr       string    `json:"hostAddr"`
+	Pprocs         int       `json:"pprocs"`
+	CoresPerProc   int       `json:"cores_per_proc"`
+	ThreadsPerCore int       `json:"threads_per_core"`
+}
+
+// JobSubmitRequest 作业提交请求
+type JobSubmitRequest struct {
+	Queue   string `json:"queue"`
+	ResReq  string `json:"resreq"`
+	Command string `json:"command"`
+}
+
+// SubmitResult 作业提交结果
+type SubmitResult struct {
+	JobID   int64  `json:"jobid"`
+	Message string `json:"message"`
+}
+
+// JobQuery 作业查询条件
+type JobQuery struct {
+	// JobID 只查询该作业，为 0 时不限制
+	JobID int64
+	// JobIDs 只查询这些作业，需要服务器支持 CapJobIDFilter
+	JobIDs []int64
+	// User 只查询该用户的作业
+	User string
+	// Queue 只查询该队列中的作业
+	Queue string
+	// Fields 只返回这些字段，为空时返回全部字段
+	Fields []string
+}
+
+// HostQuery 主机查询条件
+type HostQuery struct {
+	// Type 信息类型，basic 或 full，为空时为 basic
+	Type string
+	// HostType 只查询该类型的主机，如 X86_64、ARM
+	HostType string
+}
+
+// ListOptions 分页遍历选项
+type ListOptions struct {
+	// PageSize 每次请求的条数，0 表示使用 DefaultPageSize
+	PageSize int
+	// Limit 最多返回的条数，0 表示不限制
+	Limit int
+}
+
+// RetryPolicy 请求重试策略
+type RetryPolicy struct {
+	// MaxRetries 最多重试次数，0 表示不重试
+	MaxRetries int
+	// WaitMin 第一次重试前的等待时间，之后每次翻倍
+	WaitMin time.Duration
+	// WaitMax 两次重试之间的最长等待时间
+	WaitMax time.Duration
+}
+
+// TLSOptions 服务器 TLS 校验选项
+type TLSOptions struct {
+	// CACert 服务器的 CA 证书
+	CACert string
+	// CABundle 额外信任的 CA 证书包
+	CABundle string
+	// SystemRoots 是否同时信任系统证书库
+	SystemRoots bool
+	// Fingerprint 固定的服务器证书指纹
+	Fingerprint string
+	// ServerName TLS 握手及证书校验使用的服务器名称 (SNI)
+	ServerName string
+	// ClientCert 客户端证书 (PEM)
+	ClientCert string
+	// ClientKey 客户端私钥 (PEM，可加密)
+	ClientKey string
+	// PKCS12 包含客户端证书和私钥的 PKCS#12 文件
+	PKCS12 string
+	// KeyPassphrase 加密私钥或 PKCS#12 文件的密码
+	KeyPassphrase string
+}
+
+// NetworkOptions 连接服务器的网络选项，与 cli 配置中的 proxy、no_proxy、hosts、unix_socket 和 connect_timeout 相同
+type NetworkOptions struct {
+	// Proxy 代理地址，支持 http、https 和 socks5，为空时使用 HTTPS_PROXY 等环境变量
+	Proxy string
+	// NoProxy 不使用代理的主机，格式与 NO_PROXY 环境变量相同，与环境变量中的设置合并
+	NoProxy string
+	// Hosts 静态解析，键为主机名或 主机名:端口，值为 IP 地址或 IP:端口
+	Hosts map[string]string
+	// UnixSocket 通过 Unix 域套接字连接服务器，设置后不使用代理和静态解析
+	UnixSocket string
+	// ConnectTimeout 建立连接的超时时间，0 时使用 DefaultConnectTimeout
+	ConnectTimeout time.Duration
+}
+
+// VersionInfo 服务器版本、支持的 API 版本和能力
+type VersionInfo struct {
+	// Version 服务器软件版本，如 2.1.0
+	Version string `json:"version"`
+	// APIVersions 服务器支持的 API 版本，如 [v1 v2]
+	APIVersions []string `json:"apiVersions"`
+	// Capabilities 服务器支持的能力，如 pagination
+	Capabilities []string `json:"capabilities"`
+}
+
+// DefaultRetryPolicy 默认的重试策略
+var DefaultRetryPolicy = RetryPolicy(client.DefaultRetryPolicy)
+
+// DefaultPageSize 分页查询时每页的默认条数
+const DefaultPageSize = client.DefaultPageSize
+
+// DefaultConnectTimeout 未设置 NetworkOptions.ConnectTimeout 时建立连接的超时时间
+const DefaultConnectTimeout = transport.DefaultConnectTimeout
+
+// API 版本
+const (
+	APIVersionV1 = client.APIVersionV1
+	APIVersionV2 = client.APIVersionV2
+)
+
+// 服务器能力，可通过 Client.Supports 判断
+const (
+	CapCertLogon   = client.CapCertLogon
+	CapPagination  = client.CapPagination
+	CapJobFields   = client.CapJobFields
+	CapIdempotency = client.CapIdempotency
+	CapJobIDFilter = client.CapJobIDFilter
+)